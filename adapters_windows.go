@@ -0,0 +1,42 @@
+package localaddr
+
+import (
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// adapters returns the GetAdaptersAddresses list of all adapters, requested with flags.
+func adapters(flags uint32) (*windows.IpAdapterAddresses, error) {
+	size := uint32(15 << 10)
+	for {
+		buf := make([]byte, size)
+		first := (*windows.IpAdapterAddresses)(unsafe.Pointer(&buf[0]))
+		err := windows.GetAdaptersAddresses(syscall.AF_UNSPEC, flags, 0, first, &size)
+		if err == nil {
+			return first, nil
+		}
+		if err != windows.ERROR_BUFFER_OVERFLOW || size <= uint32(len(buf)) {
+			return nil, err
+		}
+	}
+}
+
+// adapter returns the GetAdaptersAddresses entry of the interface with the given index.
+// Unicast, anycast, multicast, and DNS server lists are not requested.
+func adapter(index int) (*windows.IpAdapterAddresses, error) {
+	list, err := adapters(windows.GAA_FLAG_SKIP_UNICAST | windows.GAA_FLAG_SKIP_ANYCAST | windows.GAA_FLAG_SKIP_MULTICAST | windows.GAA_FLAG_SKIP_DNS_SERVER)
+	if err != nil {
+		return nil, err
+	}
+	for aa := list; aa != nil; aa = aa.Next {
+		if int(aa.IfIndex) == index || int(aa.Ipv6IfIndex) == index {
+			return aa, nil
+		}
+	}
+	return nil, errNoAdapter
+}
+
+// errNoAdapter is returned by adapter for unknown interfaces.
+var errNoAdapter = syscall.Errno(windows.ERROR_NOT_FOUND)
@@ -0,0 +1,134 @@
+package localaddr
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+)
+
+// Address is a selected address together with what is known about its interface, so
+// callers do not have to enumerate the interfaces again to find it.
+type Address struct {
+	IP        netip.Addr
+	Prefix    netip.Prefix // IP with the length of its on-link network, e.g. 192.168.1.42/24
+	Interface string
+	Adapter   string // description of the adapter on Windows, e.g. "Intel(R) Ethernet Connection I219-V"
+	Index     int
+	MAC       net.HardwareAddr // empty for interfaces without one, such as tunnels
+	Flags     net.Flags
+	Kind      Kind
+	MTU       int    // largest packet the interface sends, in bytes
+	Speed     uint64 // negotiated link speed in bits per second, zero if unknown
+	Stability Stability
+}
+
+// GetDetailed is like Get, but returns the address with its interface details.
+//
+// Returns:
+//   - Address: The address and its interface (e.g., 192.168.1.2 on "eth0")
+//   - error: An error if no suitable address is found or if there's an issue accessing network interfaces
+func GetDetailed(opts ...Option) (Address, error) {
+	cfg := newConfig(ipv4, opts)
+	c, err := first(cfg)
+	if err != nil {
+		return Address{}, err
+	}
+	return cfg.address(&c), nil
+}
+
+// GetIPv6Detailed is like GetIPv6, but returns the address with its interface details.
+func GetIPv6Detailed(opts ...Option) (Address, error) {
+	cfg := newConfig(ipv6, opts)
+	c, err := first(cfg)
+	if err != nil {
+		return Address{}, err
+	}
+	return cfg.address(&c), nil
+}
+
+// GetAllDetailed is like GetAll, but returns the addresses with their interface details.
+func GetAllDetailed(opts ...Option) ([]Address, error) {
+	cfg := newConfig(anyFamily, opts)
+	found, err := scan(cfg)
+	if err != nil {
+		return nil, err
+	}
+	all := make([]Address, len(found))
+	for i := range found {
+		all[i] = cfg.address(&found[i])
+	}
+	return all, nil
+}
+
+// address converts c, found for cfg, to its exported form.
+func (cfg *config) address(c *candidate) Address {
+	return Address{
+		IP:        c.ip,
+		Prefix:    c.prefix,
+		Interface: c.iface.Name,
+		Adapter:   cfg.description(&c.iface),
+		Index:     c.iface.Index,
+		MAC:       c.iface.HardwareAddr,
+		Flags:     c.iface.Flags,
+		Kind:      cfg.kind(&c.iface),
+		MTU:       c.iface.MTU,
+		Speed:     cfg.speed(&c.iface),
+		Stability: cfg.stability(c),
+	}
+}
+
+// speed returns the link speed of iface in bits per second, or zero if it is unknown or
+// the interface comes from a Provider.
+func (cfg *config) speed(iface *net.Interface) uint64 {
+	if cfg.provider != nil {
+		return 0
+	}
+	return readSpeed(iface.Name, iface.Index)
+}
+
+// IsLocal reports whether addr is assigned to an interface of this machine, whether Get
+// would consider it or not: loopback, virtual, and link-local addresses count. Servers
+// can check a configured or advertised address against it before binding to it or
+// telling peers about it. IPv4-mapped IPv6 addresses are compared as IPv4 addresses; a
+// link-local address with a zone must be on the interface the zone names.
+//
+// Of the options, only WithProvider applies. Addresses of EnvOverride are not assigned
+// by it, and still need to be on an interface.
+//
+// Returns:
+//   - bool: Whether an interface has addr (e.g., true for 127.0.0.1)
+//   - error: An error if addr is invalid or the interfaces cannot be read
+func IsLocal(addr netip.Addr, opts ...Option) (bool, error) {
+	if !addr.IsValid() {
+		return false, fmt.Errorf("invalid address")
+	}
+	cfg := newConfig(anyFamily, opts)
+	if cfg.err != nil {
+		return false, cfg.err
+	}
+	var links []link
+	var err error
+	if cfg.provider != nil {
+		links, err = walkProvider(cfg.provider)
+	} else {
+		links, err = walk()
+	}
+	if err != nil {
+		return false, err
+	}
+	zone := addr.Zone()
+	addr = addr.Unmap().WithZone("")
+	for i := range links {
+		l := &links[i]
+		if zone != "" && l.iface.Name != zone && strconv.Itoa(l.iface.Index) != zone {
+			continue
+		}
+		for _, prefix := range l.addrs {
+			if prefix.Addr() == addr {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
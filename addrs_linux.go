@@ -0,0 +1,98 @@
+package localaddr
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// Address flags of <linux/if_addr.h>. The kernel reports the low 8 bits in the message
+// header and all of them in the IFA_FLAGS attribute.
+const (
+	ifaCacheinfo  = 6    // IFA_CACHEINFO, the lifetimes
+	ifaFlags      = 8    // IFA_FLAGS
+	ifaFTemporary = 0x01 // IFA_F_TEMPORARY of IPv6 addresses
+	ifaFSecondary = 0x01 // IFA_F_SECONDARY, the same bit for IPv4 ones
+)
+
+// interfaceAddrs fills in the addresses of links, or the error of parsing them.
+//
+// net.Interface.Addrs dumps every address of the system over netlink to find those of
+// one interface, so calling it per interface costs one dump each. This reads a single
+// dump for all of them instead.
+func interfaceAddrs(links []link) error {
+	rib, err := syscall.NetlinkRIB(syscall.RTM_GETADDR, syscall.AF_UNSPEC)
+	if err != nil {
+		return err
+	}
+	msgs, err := syscall.ParseNetlinkMessage(rib)
+	if err != nil {
+		return err
+	}
+	byIndex := make(map[int]*link, len(links))
+	for i := range links {
+		byIndex[links[i].iface.Index] = &links[i]
+	}
+	for _, m := range msgs {
+		if m.Header.Type != syscall.RTM_NEWADDR || len(m.Data) < syscall.SizeofIfAddrmsg {
+			continue
+		}
+		ifam := (*syscall.IfAddrmsg)(unsafe.Pointer(&m.Data[0]))
+		l := byIndex[int(ifam.Index)]
+		if l == nil {
+			continue // interface appeared after the enumeration
+		}
+		attrs, err := syscall.ParseNetlinkRouteAttr(&m)
+		if err != nil {
+			l.err = err
+			continue
+		}
+		if prefix, flags, ok := parseIfAddrmsg(ifam, attrs); ok {
+			l.add(prefix, flags)
+		}
+	}
+	return nil
+}
+
+// parseIfAddrmsg extracts the local address of an RTM_NEWADDR message. As in package net,
+// IFA_LOCAL wins over IFA_ADDRESS for IPv4, where the latter is the peer address on
+// point-to-point links. The valid lifetime of the address is the second field of
+// IFA_CACHEINFO, in seconds, all ones for addresses without one.
+func parseIfAddrmsg(ifam *syscall.IfAddrmsg, attrs []syscall.NetlinkRouteAttr) (netip.Prefix, addrFlags, bool) {
+	var ip netip.Addr
+	var flags addrFlags
+	kernelFlags := uint32(ifam.Flags)
+	for _, a := range attrs {
+		switch a.Attr.Type {
+		case syscall.IFA_LOCAL:
+			if ifam.Family == syscall.AF_INET {
+				ip, _ = netip.AddrFromSlice(a.Value)
+			}
+		case syscall.IFA_ADDRESS:
+			if !ip.IsValid() || ifam.Family == syscall.AF_INET6 {
+				ip, _ = netip.AddrFromSlice(a.Value)
+			}
+		case ifaFlags:
+			if len(a.Value) >= 4 {
+				kernelFlags = binary.NativeEndian.Uint32(a.Value)
+			}
+		case ifaCacheinfo:
+			if len(a.Value) >= 8 {
+				valid := binary.NativeEndian.Uint32(a.Value[4:])
+				flags |= lifetimeFlags(time.Duration(valid)*time.Second, valid == ^uint32(0))
+			}
+		}
+	}
+	if !ip.IsValid() || int(ifam.Prefixlen) > ip.BitLen() {
+		return netip.Prefix{}, 0, false
+	}
+	switch {
+	case ifam.Family == syscall.AF_INET6 && kernelFlags&ifaFTemporary != 0:
+		flags |= addrTemporary
+	case ifam.Family == syscall.AF_INET && kernelFlags&ifaFSecondary != 0:
+		flags |= addrSecondary
+	}
+	return netip.PrefixFrom(ip, int(ifam.Prefixlen)), flags, true
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !windows
+
+package localaddr
+
+// interfaceAddrs fills in the addresses of links, or the error of reading them.
+func interfaceAddrs(links []link) error {
+	readAddrs(links, system{}.Addrs)
+	return nil
+}
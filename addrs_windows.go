@@ -0,0 +1,56 @@
+package localaddr
+
+import (
+	"net"
+	"net/netip"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// ipSuffixOriginRandom is the IP_SUFFIX_ORIGIN of privacy extension addresses.
+const ipSuffixOriginRandom = 4
+
+// interfaceAddrs fills in the addresses of links from a single GetAdaptersAddresses call,
+// which, unlike net.Interface.Addrs, also tells the lifetimes of the addresses and which
+// ones are temporary.
+func interfaceAddrs(links []link) error {
+	list, err := adapters(windows.GAA_FLAG_SKIP_ANYCAST | windows.GAA_FLAG_SKIP_MULTICAST | windows.GAA_FLAG_SKIP_DNS_SERVER)
+	if err != nil {
+		return err
+	}
+	byIndex := make(map[int]*link, len(links))
+	for i := range links {
+		if links[i].iface.Flags&net.FlagUp != 0 {
+			byIndex[links[i].iface.Index] = &links[i] // addresses of down interfaces are never used
+		}
+	}
+	for aa := list; aa != nil; aa = aa.Next {
+		index := int(aa.IfIndex)
+		if index == 0 {
+			index = int(aa.Ipv6IfIndex)
+		}
+		l := byIndex[index]
+		if l == nil {
+			continue
+		}
+		for ua := aa.FirstUnicastAddress; ua != nil; ua = ua.Next {
+			ip, ok := netip.AddrFromSlice(ua.Address.IP())
+			if !ok {
+				continue
+			}
+			ip = ip.Unmap()
+			bits := int(ua.OnLinkPrefixLength)
+			if bits > ip.BitLen() {
+				bits = ip.BitLen()
+			}
+			var flags addrFlags
+			if ua.SuffixOrigin == ipSuffixOriginRandom {
+				flags |= addrTemporary
+			}
+			flags |= lifetimeFlags(time.Duration(ua.ValidLifetime)*time.Second, ua.ValidLifetime == ^uint32(0))
+			l.add(netip.PrefixFrom(ip, bits), flags)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package localaddr
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+)
+
+// AdvertiseAddr returns the host:port under which other machines on the network reach
+// port of this one, for registering a service with Consul, etcd, or a gRPC resolver.
+// The address is chosen as by Get, so loopback and link-local addresses, VPN tunnels,
+// and virtual bridges are skipped unless the options say otherwise, and EnvOverride
+// pins it on machines where the guess is wrong.
+//
+// Returns:
+//   - string: The address and port (e.g., "192.168.1.42:50051" or "[fd00::42]:50051")
+//   - error: An error if no address is selected or the port is invalid
+func AdvertiseAddr(port int, opts ...Option) (string, error) {
+	if port < 1 || port > 65535 {
+		return "", fmt.Errorf("invalid port %d", port)
+	}
+	c, err := first(newConfig(ipv4, opts))
+	if err != nil {
+		return "", err
+	}
+	return netip.AddrPortFrom(c.ip, uint16(port)).String(), nil
+}
+
+// Advertiser keeps a service registration in step with the local address: it registers
+// the address once there is one, and moves the registration whenever a Watcher reports a
+// change.
+type Advertiser struct {
+	// Port is the port of the service, as passed to AdvertiseAddr.
+	Port int
+	// Register adds or updates the registration of addr, a host:port as returned by
+	// AdvertiseAddr.
+	Register func(ctx context.Context, addr string) error
+	// Deregister, if set, removes the registration of an address that is no longer the
+	// local one, before the new one is registered.
+	Deregister func(ctx context.Context, addr string) error
+	// OnError, if set, is called when registering or deregistering addr failed.
+	OnError func(addr string, err error)
+}
+
+// Run registers the current address of w, then follows its changes until ctx is done.
+// Failures are passed to OnError and do not stop the advertiser; the registration is
+// retried with the next change. The registration is left in place when ctx is done, so
+// deregister it then if the service stops.
+//
+// Returns:
+//   - error: An error if the port is invalid or watching cannot be started, nil once ctx
+//     is done
+func (a *Advertiser) Run(ctx context.Context, w *Watcher) error {
+	if a.Port < 1 || a.Port > 65535 {
+		return fmt.Errorf("invalid port %d", a.Port)
+	}
+	events, err := w.Watch(ctx)
+	if err != nil {
+		return err
+	}
+	var current string // registered address, "" if none
+	if c := w.lookup(); c.ip.IsValid() {
+		current = a.register(ctx, "", c.ip)
+	}
+	for ev := range events {
+		current = a.register(ctx, current, ev.New)
+	}
+	return nil
+}
+
+// register moves the registration from old to ip, which may be invalid if the machine
+// lost its address, and returns the address now registered.
+func (a *Advertiser) register(ctx context.Context, old string, ip netip.Addr) string {
+	addr := ""
+	if ip.IsValid() {
+		addr = netip.AddrPortFrom(ip, uint16(a.Port)).String()
+	}
+	if addr == old {
+		return old
+	}
+	if old != "" && a.Deregister != nil {
+		if err := a.Deregister(ctx, old); err != nil {
+			a.fail(old, err)
+		}
+	}
+	if addr == "" {
+		return ""
+	}
+	if err := a.Register(ctx, addr); err != nil {
+		a.fail(addr, err)
+		return ""
+	}
+	return addr
+}
+
+// fail reports err for addr to OnError.
+func (a *Advertiser) fail(addr string, err error) {
+	if a.OnError != nil {
+		a.OnError(addr, err)
+	}
+}
@@ -0,0 +1,338 @@
+// Package announce advertises a service on the local network with multicast DNS and
+// DNS-based service discovery (RFC 6762, RFC 6763), as Bonjour and Avahi do, so that
+// browsers such as "dns-sd -B _http._tcp" or "avahi-browse _http._tcp" find it.
+//
+// The service is advertised on the address that localaddr.Get selects, and advertised
+// again whenever a localaddr.Watcher reports that the address changed.
+package announce
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golovingreg/localaddr"
+	"github.com/golovingreg/localaddr/internal/dns"
+	"github.com/golovingreg/localaddr/internal/mcast"
+)
+
+// mDNS groups and port (RFC 6762).
+var (
+	groupIPv4 = netip.MustParseAddrPort("224.0.0.251:5353")
+	groupIPv6 = netip.MustParseAddrPort("[ff02::fb]:5353")
+)
+
+// Record lifetimes recommended by RFC 6762 section 10.
+const (
+	hostTTL    = 120  // records naming a host: A, AAAA, SRV
+	serviceTTL = 4500 // the others: PTR, TXT
+	legacyTTL  = 10   // cap for answers to one-shot queries from ordinary resolvers
+)
+
+// servicesName lists the service types on the network (RFC 6763 section 9).
+const servicesName = "_services._dns-sd._udp.local"
+
+// Service describes a service to advertise.
+type Service struct {
+	Instance string             // name shown to users, e.g. "Living Room Printer"
+	Type     string             // service type and transport, e.g. "_http._tcp"
+	Port     int                // port the service listens on
+	Text     []string           // TXT record entries, e.g. "path=/api"
+	Host     string             // host name in the SRV record; default the machine's name with ".local"
+	Options  []localaddr.Option // selection of the address, as for localaddr.Get
+	OnError  func(error)        // called with errors that do not stop the service, may be nil
+}
+
+// Run advertises s and answers queries for it until ctx is done, then withdraws it
+// with goodbye packets.
+//
+// Run does not probe for name conflicts (RFC 6762 section 8.1); pick an instance name
+// that is unique on the network, e.g. by including the host name. While the machine
+// has no address, nothing is advertised; use localaddr.WaitForNetwork before Run to wait
+// for one instead. Failures to send or to follow an address change are passed to
+// OnError and do not stop the service.
+//
+// Returns:
+//   - error: An error if s is invalid, no address is selected, or listening fails; nil once ctx is done
+func (s *Service) Run(ctx context.Context) error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+	host := s.Host
+	if host == "" {
+		name, err := os.Hostname()
+		if err != nil {
+			return err
+		}
+		name, _, _ = strings.Cut(name, ".") // macOS reports "mybox.local", others may add a domain
+		host = name + ".local"
+	}
+	addr, err := localaddr.GetDetailed(s.Options...)
+	if err != nil {
+		return err
+	}
+	events, err := localaddr.NewWatcher(s.Options...).Watch(ctx)
+	if err != nil {
+		return err
+	}
+	r := &responder{svc: s, host: strings.TrimSuffix(host, "."), packets: make(chan packet)}
+	if err := r.start(ctx, addr); err != nil {
+		return err
+	}
+	defer func() { r.stop() }()
+
+	announce := time.NewTimer(time.Second) // the second announcement, RFC 6762 section 8.3
+	defer announce.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-announce.C:
+			r.send(r.records(), nil)
+		case _, ok := <-events:
+			if !ok {
+				return nil
+			}
+			r.stop()
+			addr, err := localaddr.GetDetailed(s.Options...)
+			if err != nil {
+				continue // disconnected, the watcher reports when the address is back
+			}
+			if err := r.start(ctx, addr); err != nil {
+				s.report(err)
+				continue
+			}
+			announce.Reset(time.Second)
+		case p := <-r.packets:
+			if p.conn == r.conn {
+				r.answer(p)
+			}
+		}
+	}
+}
+
+// validate checks the fields of s.
+func (s *Service) validate() error {
+	if s.Instance == "" || len(s.Instance) > 63 {
+		return fmt.Errorf("announce: invalid instance name %q", s.Instance)
+	}
+	name, transport, ok := strings.Cut(s.Type, ".")
+	if !ok || len(name) < 2 || name[0] != '_' || (transport != "_tcp" && transport != "_udp") {
+		return fmt.Errorf("announce: invalid service type %q, want e.g. \"_http._tcp\"", s.Type)
+	}
+	if s.Port < 1 || s.Port > 65535 {
+		return fmt.Errorf("announce: invalid port %d", s.Port)
+	}
+	return nil
+}
+
+// report passes err to OnError, if set.
+func (s *Service) report(err error) {
+	if s.OnError != nil {
+		s.OnError(err)
+	}
+}
+
+// packet is a query received on conn.
+type packet struct {
+	conn *net.UDPConn
+	msg  *dns.Message
+	from *net.UDPAddr
+}
+
+// responder advertises a service on one address at a time.
+type responder struct {
+	svc     *Service
+	host    string
+	packets chan packet
+
+	addr  netip.Addr
+	iface *net.Interface
+	group netip.AddrPort
+	conn  *net.UDPConn // nil while stopped
+}
+
+// start listens on the interface of addr and announces the service there.
+func (r *responder) start(ctx context.Context, addr localaddr.Address) error {
+	iface, err := net.InterfaceByIndex(addr.Index)
+	if err != nil {
+		return err
+	}
+	group := groupIPv4
+	if addr.IP.Is6() {
+		group = groupIPv6
+	}
+	conn, err := mcast.Listen(iface, group)
+	if err != nil {
+		return fmt.Errorf("announce: listening on %s: %w", iface.Name, err)
+	}
+	r.addr, r.iface, r.group, r.conn = addr.IP.WithZone(""), iface, group, conn
+	go r.read(ctx, conn)
+	r.send(r.records(), nil)
+	return nil
+}
+
+// stop withdraws the service from the current interface, if any, and stops listening.
+func (r *responder) stop() {
+	if r.conn == nil {
+		return
+	}
+	goodbye := r.records()
+	for i := range goodbye {
+		goodbye[i].TTL = 0
+	}
+	r.send(goodbye, nil)
+	r.conn.Close()
+	r.conn = nil
+}
+
+// read passes the queries received on conn to Run, until conn is closed.
+func (r *responder) read(ctx context.Context, conn *net.UDPConn) {
+	buf := make([]byte, 9000)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		msg, err := dns.Parse(buf[:n])
+		if err != nil || msg.Flags&dns.FlagResponse != 0 {
+			continue
+		}
+		select {
+		case r.packets <- packet{conn, msg, from}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// records returns the records of the service: the PTR records of its type and of the
+// type list, its SRV and TXT records, and the address record of its host.
+func (r *responder) records() []dns.Resource {
+	typeName := r.svc.Type + ".local"
+	instance := dns.Escape(r.svc.Instance) + "." + typeName
+	addr := dns.Resource{Name: r.host, Type: dns.TypeA, Class: dns.ClassINET | dns.ClassUnique, TTL: hostTTL, Addr: r.addr}
+	if r.addr.Is6() {
+		addr.Type = dns.TypeAAAA
+	}
+	return []dns.Resource{
+		{Name: typeName, Type: dns.TypePTR, Class: dns.ClassINET, TTL: serviceTTL, Target: instance},
+		{Name: instance, Type: dns.TypeSRV, Class: dns.ClassINET | dns.ClassUnique, TTL: hostTTL, Target: r.host, Port: uint16(r.svc.Port)},
+		{Name: instance, Type: dns.TypeTXT, Class: dns.ClassINET | dns.ClassUnique, TTL: serviceTTL, Text: r.svc.Text},
+		addr,
+		{Name: servicesName, Type: dns.TypePTR, Class: dns.ClassINET, TTL: serviceTTL, Target: typeName},
+	}
+}
+
+// answer responds to the questions of p that concern the service. Records the querier
+// already lists as known answers are left out (RFC 6762 section 7.1).
+func (r *responder) answer(p packet) {
+	records := r.records()
+	var answers, additionals []dns.Resource
+	for _, q := range p.msg.Questions {
+		for _, rr := range records {
+			if matches(q, rr) && !known(p.msg.Answers, rr) && !contains(answers, rr) {
+				answers = append(answers, rr)
+			}
+		}
+	}
+	if len(answers) == 0 {
+		return
+	}
+	// RFC 6763 section 12: what a browser looks up next comes along.
+	for _, a := range answers {
+		var next []dns.Resource
+		switch {
+		case a.Type == dns.TypePTR && a.Name != servicesName:
+			next = records[1:4]
+		case a.Type == dns.TypeSRV:
+			next = records[3:4]
+		}
+		for _, rr := range next {
+			if !contains(answers, rr) && !contains(additionals, rr) {
+				additionals = append(additionals, rr)
+			}
+		}
+	}
+	if p.from.Port == int(r.group.Port()) {
+		r.send(answers, additionals)
+		return
+	}
+	// A one-shot query from an ordinary resolver: answer it directly, like a unicast DNS
+	// server would (RFC 6762 section 6.7).
+	for _, section := range [][]dns.Resource{answers, additionals} {
+		for i := range section {
+			section[i].Class &^= dns.ClassUnique
+			section[i].TTL = min(section[i].TTL, legacyTTL)
+		}
+	}
+	r.write(&dns.Message{
+		ID:          p.msg.ID,
+		Flags:       dns.FlagResponse | dns.FlagAuthoritative,
+		Questions:   p.msg.Questions,
+		Answers:     answers,
+		Additionals: additionals,
+	}, p.from)
+}
+
+// send multicasts a response with answers and additionals on the current interface.
+func (r *responder) send(answers, additionals []dns.Resource) {
+	r.write(&dns.Message{
+		Flags:       dns.FlagResponse | dns.FlagAuthoritative,
+		Answers:     answers,
+		Additionals: additionals,
+	}, mcast.Dest(r.iface, r.group))
+}
+
+// write sends msg to dst, reporting failures to OnError.
+func (r *responder) write(msg *dns.Message, dst *net.UDPAddr) {
+	if r.conn == nil {
+		return // disconnected
+	}
+	b, err := msg.Pack()
+	if err == nil {
+		_, err = r.conn.WriteToUDP(b, dst)
+	}
+	if err != nil && !errors.Is(err, net.ErrClosed) {
+		r.svc.report(fmt.Errorf("announce: sending on %s: %w", r.iface.Name, err))
+	}
+}
+
+// matches reports whether rr answers q.
+func matches(q dns.Question, rr dns.Resource) bool {
+	class := q.Class &^ dns.ClassUnique
+	return (q.Type == rr.Type || q.Type == dns.TypeANY) &&
+		(class == dns.ClassINET || class == dns.TypeANY) &&
+		dns.EqualNames(q.Name, rr.Name)
+}
+
+// known reports whether the known answers of a query list rr with at least half its TTL.
+func known(answers []dns.Resource, rr dns.Resource) bool {
+	for _, a := range answers {
+		if same(a, rr) && a.TTL >= rr.TTL/2 {
+			return true
+		}
+	}
+	return false
+}
+
+// contains reports whether list contains rr.
+func contains(list []dns.Resource, rr dns.Resource) bool {
+	for _, a := range list {
+		if same(a, rr) {
+			return true
+		}
+	}
+	return false
+}
+
+// same reports whether a and b are the same record, apart from the TTL.
+func same(a, b dns.Resource) bool {
+	return a.Type == b.Type && dns.EqualNames(a.Name, b.Name) &&
+		dns.EqualNames(a.Target, b.Target) && a.Addr == b.Addr && a.Port == b.Port
+}
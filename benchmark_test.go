@@ -0,0 +1,83 @@
+package localaddr_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golovingreg/localaddr"
+	"github.com/golovingreg/localaddr/localaddrtest"
+)
+
+// The benchmarks select from DockerHeavy, whose LAN address comes after six virtual
+// interfaces, so the walk and the filters do their full work.
+
+func BenchmarkGet(b *testing.B) {
+	opt := localaddrtest.DockerHeavy().Option()
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := localaddr.Get(opt); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetAddr(b *testing.B) {
+	opt := localaddrtest.DockerHeavy().Option()
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := localaddr.GetAddr(opt); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGetAll(b *testing.B) {
+	opt := localaddrtest.DockerHeavy().Option()
+	b.ReportAllocs()
+	for range b.N {
+		if _, err := localaddr.GetAll(opt); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCacheGet(b *testing.B) {
+	cache := localaddr.NewCache(time.Hour, localaddrtest.DockerHeavy().Option())
+	if _, err := cache.Get(); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		cache.Get()
+	}
+	if n := testing.AllocsPerRun(100, func() { cache.Get() }); n != 0 {
+		b.Errorf("Cache.Get allocates %v times per call, want 0", n)
+	}
+}
+
+func BenchmarkCacheGetParallel(b *testing.B) {
+	cache := localaddr.NewCache(time.Hour, localaddrtest.DockerHeavy().Option())
+	cache.Get()
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			cache.Get()
+		}
+	})
+}
+
+// BenchmarkCached reads the package level cache, which holds the address of the machine
+// running the benchmark, or the error of not finding one; both are answered alike.
+func BenchmarkCached(b *testing.B) {
+	localaddr.Cached()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		localaddr.Cached()
+	}
+	if n := testing.AllocsPerRun(100, func() { localaddr.Cached() }); n != 0 {
+		b.Errorf("Cached allocates %v times per call, want 0", n)
+	}
+}
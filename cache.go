@@ -0,0 +1,150 @@
+package localaddr
+
+import (
+	"context"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Cache memoizes the result of Get, so hot paths such as HTTP handlers do not enumerate
+// every interface on each call. It is safe for concurrent use.
+//
+// The result is kept for the TTL given to NewCache. Failed lookups are cached too, so a
+// disconnected machine is not rescanned on every call either. Use Watch to also drop the
+// result as soon as the network changes, or Invalidate to drop it by hand.
+type Cache struct {
+	ttl  time.Duration
+	opts []Option
+
+	mu      sync.RWMutex
+	r       cachedLookup
+	expires time.Time // zero if nothing is cached
+}
+
+// NewCache returns a cache of the address that Get(opts...) selects, kept for ttl.
+func NewCache(ttl time.Duration, opts ...Option) *Cache {
+	return &Cache{ttl: ttl, opts: opts}
+}
+
+// Get is like the package level Get, but answers from the cache while it is fresh.
+// Answering from the cache does not allocate.
+func (c *Cache) Get() (string, error) {
+	r := c.lookup()
+	return r.s, r.err
+}
+
+// Addr is like GetAddr, but answers from the cache while it is fresh.
+func (c *Cache) Addr() (netip.Addr, error) {
+	r := c.lookup()
+	return r.c.ip, r.err
+}
+
+// Invalidate drops the cached result, so the next call scans the interfaces again.
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	c.expires = time.Time{}
+	c.mu.Unlock()
+}
+
+// Watch invalidates the cache whenever the network configuration changes, until ctx is
+// done. It returns immediately; the error reports whether watching could be started.
+func (c *Cache) Watch(ctx context.Context) error {
+	cfg := newConfig(ipv4, c.opts)
+	if cfg.err != nil {
+		return cfg.err
+	}
+	changed, err := cfg.changes(ctx)
+	if err != nil {
+		return err
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-changed:
+				c.Invalidate()
+			}
+		}
+	}()
+	return nil
+}
+
+// lookup returns the cached result, refreshing it if it has expired.
+func (c *Cache) lookup() cachedLookup {
+	c.mu.RLock()
+	if time.Now().Before(c.expires) {
+		defer c.mu.RUnlock()
+		return c.r
+	}
+	c.mu.RUnlock()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if now.Before(c.expires) {
+		return c.r // refreshed by another goroutine meanwhile
+	}
+	c.r = newCachedLookup(first(newConfig(ipv4, c.opts)))
+	c.expires = now.Add(c.ttl)
+	return c.r
+}
+
+// The package level cache behind Cached. It never expires on its own.
+var (
+	cachedOnce   sync.Once
+	cachedResult atomic.Pointer[cachedLookup]
+)
+
+// cachedLookup is an immutable snapshot of a Get result.
+type cachedLookup struct {
+	c   candidate
+	s   string // c.ip formatted once, so readers do not allocate
+	err error
+}
+
+// newCachedLookup wraps the result of first.
+func newCachedLookup(c candidate, err error) cachedLookup {
+	r := cachedLookup{c: c, err: err}
+	if err == nil {
+		r.s = c.ip.String()
+	}
+	return r
+}
+
+// Cached returns the address Get() returned when Cached was first called, or when Refresh
+// was last called. It is meant for programs that read the address from many goroutines:
+// after the first call it is a single atomic load, without allocations.
+//
+// Unlike a Cache, the result never expires; call Refresh, e.g. from a Watcher loop, to
+// detect the address again.
+func Cached() (string, error) {
+	r := loadCached()
+	return r.s, r.err
+}
+
+// CachedAddr is like Cached, but returns the address as a netip.Addr.
+func CachedAddr() (netip.Addr, error) {
+	r := loadCached()
+	return r.c.ip, r.err
+}
+
+// loadCached returns the package level cache, filling it on first use.
+func loadCached() *cachedLookup {
+	cachedOnce.Do(func() {
+		if cachedResult.Load() == nil {
+			Refresh()
+		}
+	})
+	return cachedResult.Load()
+}
+
+// Refresh detects the address again and atomically replaces the value returned by Cached.
+// It returns the error of the new detection, if any.
+func Refresh() error {
+	r := newCachedLookup(first(newConfig(ipv4, nil)))
+	cachedResult.Store(&r)
+	return r.err
+}
@@ -0,0 +1,34 @@
+package localaddr
+
+import "net"
+
+// WithIncludeNoCarrier keeps interfaces that are up but have no link, such as an
+// Ethernet port without a cable or a Wi-Fi adapter that is not associated, which the
+// selection skips by default. Their addresses are only left over from before the link
+// went away and cannot reach anything. They still rank below interfaces with a link.
+func WithIncludeNoCarrier() Option {
+	return func(c *config) {
+		c.includeNoCarrier = true
+	}
+}
+
+// HasCarrier reports whether iface has a link: a cable plugged in and a peer on the
+// other end, or a Wi-Fi association. An interface can be up, as net.FlagUp tells,
+// without one.
+//
+// It is read from the operstate and carrier files in sysfs on Linux and from the media
+// status of SIOCGIFMEDIA on macOS; interfaces without media, such as tunnels, count as
+// having a link there. Elsewhere it is net.FlagRunning, which Windows sets from the
+// adapter's operational status.
+func HasCarrier(iface net.Interface) bool {
+	return hasCarrier(&iface)
+}
+
+// carrier reports whether v has a link. Interfaces of a Provider have one while they
+// are running, see localaddrtest.Network.Unplugged.
+func (cfg *config) carrier(v *net.Interface) bool {
+	if cfg.provider != nil {
+		return v.Flags&net.FlagRunning != 0
+	}
+	return hasCarrier(v)
+}
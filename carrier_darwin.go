@@ -0,0 +1,32 @@
+package localaddr
+
+import (
+	"net"
+	"syscall"
+	"unsafe"
+)
+
+// Status bits of struct ifmediareq, from <net/if_media.h>.
+const (
+	ifmAvalid = 0x1 // the status is valid
+	ifmActive = 0x2 // the link is up
+)
+
+// hasCarrier asks the driver of iface for its media status with SIOCGIFMEDIA.
+// Interfaces without media, such as utun tunnels and bridges, have a link while they
+// are running.
+func hasCarrier(iface *net.Interface) bool {
+	running := iface.Flags&net.FlagRunning != 0
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM, 0)
+	if err != nil {
+		return running
+	}
+	defer syscall.Close(fd)
+	var req ifmediareq
+	copy(req.name[:len(req.name)-1], iface.Name)
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.SIOCGIFMEDIA, uintptr(unsafe.Pointer(&req)))
+	if errno != 0 || req.status&ifmAvalid == 0 {
+		return running
+	}
+	return req.status&ifmActive != 0
+}
@@ -0,0 +1,30 @@
+package localaddr
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// hasCarrier reads the RFC 2863 operational state of iface from sysfs. Drivers that do
+// not track it, such as those of tunnels and dummies, report "unknown", and then the
+// carrier file decides.
+func hasCarrier(iface *net.Interface) bool {
+	dir := filepath.Join("/sys/class/net", iface.Name)
+	state, err := os.ReadFile(filepath.Join(dir, "operstate"))
+	if err != nil {
+		return iface.Flags&net.FlagRunning != 0
+	}
+	switch strings.TrimSpace(string(state)) {
+	case "up":
+		return true
+	case "unknown":
+		carrier, err := os.ReadFile(filepath.Join(dir, "carrier"))
+		if err != nil {
+			return iface.Flags&net.FlagRunning != 0 // fails while the interface is down
+		}
+		return strings.TrimSpace(string(carrier)) == "1"
+	}
+	return false // down, lowerlayerdown, dormant (Wi-Fi not associated), notpresent, testing
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package localaddr
+
+import "net"
+
+// hasCarrier reports whether iface is running. Windows sets the flag from the adapter's
+// operational status, which is down without a link.
+func hasCarrier(iface *net.Interface) bool {
+	return iface.Flags&net.FlagRunning != 0
+}
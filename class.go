@@ -0,0 +1,146 @@
+package localaddr
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+// Class is the kind of address space an address belongs to.
+type Class int
+
+const (
+	ClassReserved  Class = iota // special purpose space: unspecified, multicast, documentation, ...
+	ClassLoopback               // 127.0.0.0/8, ::1
+	ClassLinkLocal              // 169.254.0.0/16, fe80::/10
+	ClassPrivate                // RFC 1918, and IPv6 unique local addresses fc00::/7
+	ClassShared                 // RFC 6598 carrier-grade NAT space, 100.64.0.0/10
+	ClassGlobal                 // globally routable
+)
+
+var classNames = [...]string{
+	ClassReserved:  "reserved",
+	ClassLoopback:  "loopback",
+	ClassLinkLocal: "link-local",
+	ClassPrivate:   "private",
+	ClassShared:    "shared",
+	ClassGlobal:    "global",
+}
+
+// String returns the lower case name of c, e.g. "private".
+func (c Class) String() string {
+	if c < 0 || int(c) >= len(classNames) {
+		return "reserved"
+	}
+	return classNames[c]
+}
+
+// sharedSpace is the RFC 6598 range used by carrier-grade NAT.
+var sharedSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// reservedSpace lists the special purpose unicast ranges that netip.Addr.IsGlobalUnicast
+// does not exclude, from the IANA special-purpose address registries.
+var reservedSpace = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this network"
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // TEST-NET-1
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // TEST-NET-2
+	netip.MustParsePrefix("203.0.113.0/24"),  // TEST-NET-3
+	netip.MustParsePrefix("240.0.0.0/4"),     // future use
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+	netip.MustParsePrefix("100::/64"),        // discard-only
+}
+
+// Classify returns the address space that addr belongs to. IPv4-mapped IPv6 addresses
+// are classified as the IPv4 address they map.
+func Classify(addr netip.Addr) Class {
+	addr = addr.Unmap()
+	switch {
+	case addr.IsLoopback():
+		return ClassLoopback
+	case addr.IsLinkLocalUnicast():
+		return ClassLinkLocal
+	case addr.IsPrivate():
+		return ClassPrivate
+	case sharedSpace.Contains(addr):
+		return ClassShared
+	case !addr.IsGlobalUnicast():
+		return ClassReserved // unspecified, multicast, broadcast, invalid
+	}
+	for _, prefix := range reservedSpace {
+		if prefix.Contains(addr) {
+			return ClassReserved
+		}
+	}
+	return ClassGlobal
+}
+
+// WithOnlyPrivate restricts the result to private addresses, see ClassPrivate. It
+// is meant for services that must only ever be reachable from the LAN.
+func WithOnlyPrivate() Option {
+	return withClass(ClassPrivate)
+}
+
+// WithOnlyGlobal restricts the result to globally routable addresses, see ClassGlobal,
+// e.g. for a server with a public address next to its private ones.
+func WithOnlyGlobal() Option {
+	return withClass(ClassGlobal)
+}
+
+// withClass restricts the result to addresses of class c.
+func withClass(c Class) Option {
+	return WithFilter(func(_ net.Interface, addr netip.Addr) bool {
+		return Classify(addr) == c
+	})
+}
+
+// IPv6Scope selects IPv6 addresses by scope, see WithIPv6Scope.
+type IPv6Scope int
+
+const (
+	ScopeAny    IPv6Scope = iota // unique local and global addresses
+	ScopeULA                     // unique local addresses, fc00::/7
+	ScopeGlobal                  // globally routable addresses
+)
+
+var scopeNames = [...]string{
+	ScopeAny:    "any",
+	ScopeULA:    "ula",
+	ScopeGlobal: "global",
+}
+
+// String returns the lower case name of s, e.g. "ula".
+func (s IPv6Scope) String() string {
+	if s < ScopeAny || s > ScopeGlobal {
+		return fmt.Sprintf("IPv6Scope(%d)", int(s))
+	}
+	return scopeNames[s]
+}
+
+// WithIPv6Scope restricts IPv6 candidates to unique local (ScopeULA) or global
+// (ScopeGlobal) addresses. On dual-scope networks an interface carries both, and which
+// one the operating system lists first says nothing about which one is wanted: ULAs stay
+// stable when the provider changes the prefix, global addresses are reachable from
+// outside. The default is ScopeAny. IPv4 addresses are not affected.
+func WithIPv6Scope(scope IPv6Scope) Option {
+	return func(c *config) {
+		if scope < ScopeAny || scope > ScopeGlobal {
+			c.setErr(fmt.Errorf("invalid IPv6 scope %d", int(scope)))
+			return
+		}
+		c.ipv6Scope = scope
+	}
+}
+
+// inScope reports whether ip passes the WithIPv6Scope restriction.
+func (c *config) inScope(ip netip.Addr) bool {
+	switch {
+	case !ip.Is6() || c.ipv6Scope == ScopeAny:
+		return true
+	case c.ipv6Scope == ScopeULA:
+		return Classify(ip) == ClassPrivate
+	default:
+		return Classify(ip) == ClassGlobal
+	}
+}
@@ -0,0 +1,42 @@
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/golovingreg/localaddr"
+)
+
+// Azure reads the private IPv4 address of the first network interface of a Microsoft
+// Azure virtual machine from the Instance Metadata Service.
+type Azure struct {
+	// Endpoint is the base URL of the metadata service. Empty means DefaultEndpoint.
+	Endpoint string
+	// Client is used for the requests. Nil means a client that ignores proxies.
+	Client *http.Client
+}
+
+// azureAPIVersion is the version of the Instance Metadata Service API that is asked.
+const azureAPIVersion = "2021-02-01"
+
+// Resolve implements localaddr.Strategy.
+//
+// Returns:
+//   - localaddr.Address: The address (e.g., 10.0.0.4 on "eth0")
+//   - error: An error if the metadata service cannot be reached or answers otherwise
+func (m Azure) Resolve(ctx context.Context) (localaddr.Address, error) {
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+	url := endpoint(m.Endpoint) + "/metadata/instance/network/interface/0/ipv4/ipAddress/0/privateIpAddress?format=text&api-version=" + azureAPIVersion
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return localaddr.Address{}, err
+	}
+	req.Header.Set("Metadata", "true")
+	answer, err := fetch(m.Client, req)
+	if err != nil {
+		return localaddr.Address{}, fmt.Errorf("cloud: azure: %w", err)
+	}
+	return address("azure", answer)
+}
@@ -0,0 +1,134 @@
+// Package cloud reads the private address of a cloud virtual machine from the metadata
+// service of its provider: Amazon EC2, Google Compute Engine, or Microsoft Azure.
+//
+// On cloud machines the metadata service knows which address is the primary one of the
+// instance, which interface enumeration can only guess at, e.g. with secondary network
+// interfaces or container bridges. Each provider is a localaddr.Strategy, to be combined
+// with the strategies of the parent package:
+//
+//	addr, err := localaddr.Chain(cloud.Any(), localaddr.DefaultChain()).Resolve(ctx)
+//
+// The metadata services are only reachable from the instance itself, at a link-local
+// address; elsewhere the requests time out, within DefaultTimeout unless the context
+// has an earlier deadline.
+package cloud
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/golovingreg/localaddr"
+)
+
+// DefaultTimeout bounds a lookup whose context has no deadline. The metadata services
+// answer within milliseconds, so it only matters off the cloud.
+const DefaultTimeout = 2 * time.Second
+
+// DefaultEndpoint is the base URL of the metadata services of all three providers.
+const DefaultEndpoint = "http://169.254.169.254"
+
+// Any returns a strategy that asks the metadata services of all providers at once and
+// returns the first answer, for programs that do not know which cloud they run on.
+func Any() localaddr.Strategy {
+	return localaddr.StrategyFunc(func(ctx context.Context) (localaddr.Address, error) {
+		ctx, cancel := withDefaultTimeout(ctx)
+		defer cancel() // also aborts the requests that are still running
+		strategies := []localaddr.Strategy{EC2{}, GCE{}, Azure{}}
+		type result struct {
+			addr localaddr.Address
+			err  error
+		}
+		results := make(chan result, len(strategies))
+		for _, s := range strategies {
+			go func() {
+				addr, err := s.Resolve(ctx)
+				results <- result{addr, err}
+			}()
+		}
+		var errs []error
+		for range strategies {
+			res := <-results
+			if res.err == nil {
+				return res.addr, nil
+			}
+			errs = append(errs, res.err)
+		}
+		return localaddr.Address{}, fmt.Errorf("cloud: no metadata service answered: %w", errors.Join(errs...))
+	})
+}
+
+// fetch sends req with client, or with a client that ignores proxies if it is nil, and
+// returns the trimmed body of a 200 answer. want lists pairs of a header and the value
+// the answer must have for it.
+func fetch(client *http.Client, req *http.Request, want ...string) (string, error) {
+	if client == nil {
+		client = defaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	for i := 0; i+1 < len(want); i += 2 {
+		if got := resp.Header.Get(want[i]); got != want[i+1] {
+			return "", fmt.Errorf("answer has %s %q, want %q", want[i], got, want[i+1])
+		}
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// defaultClient reaches the metadata services directly: a proxy from the environment
+// could not reach them, and must not see the tokens.
+var defaultClient = func() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	return &http.Client{Transport: transport}
+}()
+
+// address parses the answer of a metadata service and fills in the details of the
+// interface that has the address, if one has it.
+func address(provider, answer string) (localaddr.Address, error) {
+	ip, err := netip.ParseAddr(answer)
+	if err != nil {
+		return localaddr.Address{}, fmt.Errorf("cloud: %s: unexpected answer: %w", provider, err)
+	}
+	ip = ip.Unmap()
+	all, err := localaddr.GetAllDetailed(localaddr.WithIncludeVirtual(), localaddr.WithIncludeNoCarrier(), localaddr.WithIgnoreEnv())
+	if err == nil {
+		for _, a := range all {
+			if a.IP == ip {
+				return a, nil
+			}
+		}
+	}
+	return localaddr.Address{IP: ip, Prefix: netip.PrefixFrom(ip, ip.BitLen())}, nil
+}
+
+// endpoint returns the base URL of the metadata service, given the configured one.
+func endpoint(configured string) string {
+	if configured == "" {
+		return DefaultEndpoint
+	}
+	return strings.TrimSuffix(configured, "/")
+}
+
+// withDefaultTimeout applies DefaultTimeout to ctx unless it already has a deadline.
+func withDefaultTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, DefaultTimeout)
+}
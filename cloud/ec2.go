@@ -0,0 +1,48 @@
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/golovingreg/localaddr"
+)
+
+// EC2 reads the primary private IPv4 address of an Amazon EC2 instance with IMDSv2,
+// which first asks for a session token, so it also works on instances that require it.
+type EC2 struct {
+	// Endpoint is the base URL of the metadata service. Empty means DefaultEndpoint.
+	Endpoint string
+	// Client is used for the requests. Nil means a client that ignores proxies.
+	Client *http.Client
+}
+
+// Resolve implements localaddr.Strategy.
+//
+// Returns:
+//   - localaddr.Address: The address (e.g., 172.31.5.17 on "ens5")
+//   - error: An error if the metadata service cannot be reached or answers otherwise
+func (m EC2) Resolve(ctx context.Context) (localaddr.Address, error) {
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+	base := endpoint(m.Endpoint)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, base+"/latest/api/token", nil)
+	if err != nil {
+		return localaddr.Address{}, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	token, err := fetch(m.Client, req)
+	if err != nil {
+		return localaddr.Address{}, fmt.Errorf("cloud: ec2 token: %w", err)
+	}
+	req, err = http.NewRequestWithContext(ctx, http.MethodGet, base+"/latest/meta-data/local-ipv4", nil)
+	if err != nil {
+		return localaddr.Address{}, err
+	}
+	req.Header.Set("X-aws-ec2-metadata-token", token)
+	answer, err := fetch(m.Client, req)
+	if err != nil {
+		return localaddr.Address{}, fmt.Errorf("cloud: ec2: %w", err)
+	}
+	return address("ec2", answer)
+}
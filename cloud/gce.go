@@ -0,0 +1,41 @@
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/golovingreg/localaddr"
+)
+
+// GCE reads the internal IPv4 address of the first network interface of a Google Compute
+// Engine instance.
+type GCE struct {
+	// Endpoint is the base URL of the metadata service. Empty means DefaultEndpoint,
+	// the address that metadata.google.internal resolves to.
+	Endpoint string
+	// Client is used for the requests. Nil means a client that ignores proxies.
+	Client *http.Client
+}
+
+// Resolve implements localaddr.Strategy. Answers without the Metadata-Flavor header of
+// the real service are rejected, so another provider's service is not mistaken for it.
+//
+// Returns:
+//   - localaddr.Address: The address (e.g., 10.128.0.2 on "ens4")
+//   - error: An error if the metadata service cannot be reached or answers otherwise
+func (m GCE) Resolve(ctx context.Context) (localaddr.Address, error) {
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+	url := endpoint(m.Endpoint) + "/computeMetadata/v1/instance/network-interfaces/0/ip"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return localaddr.Address{}, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	answer, err := fetch(m.Client, req, "Metadata-Flavor", "Google")
+	if err != nil {
+		return localaddr.Address{}, fmt.Errorf("cloud: gce: %w", err)
+	}
+	return address("gce", answer)
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// commands lists the subcommands and their flags besides the selection flags, for the
+// completion scripts. The empty name is the form without a subcommand.
+var commands = []struct {
+	name  string
+	flags []string
+}{
+	{"", []string{"all", "outbound", "cidr", "json", "format", "quiet"}},
+	{"watch", []string{"json", "format", "debounce"}},
+	{"diagnose", []string{"json", "no-public"}},
+	{"qr", []string{"port"}},
+	{"completion", nil},
+}
+
+var (
+	// selectionFlagNames are the flags defined by selectionFlags.
+	selectionFlagNames = []string{"6", "iface", "subnet", "default-route", "virtual", "config"}
+	// valueFlags are the flags that take a value. -iface is completed with interface
+	// names, the others with nothing.
+	valueFlags = []string{"iface", "subnet", "config", "format", "debounce", "port"}
+)
+
+// runCompletion implements the completion subcommand.
+func runCompletion(args []string) error {
+	if len(args) != 1 {
+		return usageError(fmt.Errorf("usage: localaddr completion bash|zsh|fish"))
+	}
+	var script string
+	switch args[0] {
+	case "bash":
+		script = bashCompletion()
+	case "zsh":
+		script = zshCompletion()
+	case "fish":
+		script = fishCompletion()
+	default:
+		return usageError(fmt.Errorf("unsupported shell %q", args[0]))
+	}
+	_, err := os.Stdout.WriteString(script)
+	return err
+}
+
+// subcommands returns the names of the subcommands.
+func subcommands() string {
+	var names []string
+	for _, c := range commands[1:] {
+		names = append(names, c.name)
+	}
+	return strings.Join(names, " ")
+}
+
+// flagsOf returns the flags of commands[i], with their dash.
+func flagsOf(i int) string {
+	c := commands[i]
+	if c.name == "completion" {
+		return ""
+	}
+	var flags []string
+	for _, f := range slices.Concat(selectionFlagNames, c.flags) {
+		flags = append(flags, "-"+f)
+	}
+	return strings.Join(flags, " ")
+}
+
+// valueFlagPattern returns the flags that take a value other than an interface as a
+// shell case pattern, e.g. "-subnet|-format".
+func valueFlagPattern() string {
+	var flags []string
+	for _, f := range valueFlags {
+		if f != "iface" {
+			flags = append(flags, "-"+f)
+		}
+	}
+	return strings.Join(flags, "|")
+}
+
+func bashCompletion() string {
+	var b strings.Builder
+	b.WriteString(`# bash completion for localaddr; load with: source <(localaddr completion bash)
+_localaddr() {
+	local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]}
+	local cmd=${COMP_WORDS[1]} flags
+	case $prev in
+	-iface)
+		COMPREPLY=($(compgen -W "$(ls /sys/class/net 2>/dev/null || ifconfig -l 2>/dev/null)" -- "$cur"))
+		return ;;
+	` + valueFlagPattern() + `)
+		return ;;
+	esac
+	if [[ $COMP_CWORD -eq 1 && $cur != -* ]]; then
+		COMPREPLY=($(compgen -W "` + subcommands() + `" -- "$cur"))
+		return
+	fi
+	case $cmd in
+`)
+	for i, c := range commands[1:] {
+		if c.name == "completion" {
+			fmt.Fprintf(&b, "\tcompletion) [[ $COMP_CWORD -eq 2 ]] && COMPREPLY=($(compgen -W \"bash zsh fish\" -- \"$cur\")); return ;;\n")
+			continue
+		}
+		fmt.Fprintf(&b, "\t%s) flags=%q ;;\n", c.name, flagsOf(i+1))
+	}
+	fmt.Fprintf(&b, "\t*) flags=%q ;;\n", flagsOf(0))
+	b.WriteString(`	esac
+	COMPREPLY=($(compgen -W "$flags" -- "$cur"))
+}
+complete -F _localaddr localaddr
+`)
+	return b.String()
+}
+
+func zshCompletion() string {
+	var b strings.Builder
+	b.WriteString(`#compdef localaddr
+# zsh completion for localaddr; load with: source <(localaddr completion zsh)
+_localaddr() {
+	local -a flags
+	case $words[CURRENT-1] in
+	-iface) _net_interfaces; return ;;
+	` + valueFlagPattern() + `) _message value; return ;;
+	esac
+	if (( CURRENT == 2 )) && [[ $words[CURRENT] != -* ]]; then
+		compadd -- ` + subcommands() + `
+		return
+	fi
+	case $words[2] in
+`)
+	for i, c := range commands[1:] {
+		if c.name == "completion" {
+			b.WriteString("\tcompletion) (( CURRENT == 3 )) && compadd -- bash zsh fish; return ;;\n")
+			continue
+		}
+		fmt.Fprintf(&b, "\t%s) flags=(%s) ;;\n", c.name, flagsOf(i+1))
+	}
+	fmt.Fprintf(&b, "\t*) flags=(%s) ;;\n", flagsOf(0))
+	b.WriteString(`	esac
+	compadd -- $flags
+}
+compdef _localaddr localaddr
+`)
+	return b.String()
+}
+
+func fishCompletion() string {
+	var b strings.Builder
+	b.WriteString(`# fish completion for localaddr; load with: localaddr completion fish | source
+complete -c localaddr -f
+complete -c localaddr -n __fish_use_subcommand -a '` + subcommands() + `'
+complete -c localaddr -n '__fish_seen_subcommand_from completion' -a 'bash zsh fish'
+`)
+	for i, c := range commands {
+		cond := "'__fish_seen_subcommand_from " + c.name + "'"
+		if c.name == "" {
+			cond = "__fish_use_subcommand"
+		}
+		for _, f := range strings.Fields(flagsOf(i)) {
+			f = f[1:]
+			fmt.Fprintf(&b, "complete -c localaddr -n %s -o %s", cond, f)
+			switch {
+			case f == "iface":
+				b.WriteString(" -x -a '(__fish_print_interfaces)'")
+			case slices.Contains(valueFlags, f):
+				b.WriteString(" -x")
+			}
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/golovingreg/localaddr"
+	"github.com/golovingreg/localaddr/nat"
+	"github.com/golovingreg/localaddr/publicaddr"
+)
+
+// report is the output of the diagnose subcommand.
+type report struct {
+	Hostname   string                  `json:"hostname"`
+	FQDN       string                  `json:"fqdn"`
+	Selected   netip.Addr              `json:"selected"`
+	SelectErr  string                  `json:"select_error,omitempty"`
+	Ranking    []localaddr.Scored      `json:"ranking"`
+	Interfaces []ifaceReport           `json:"interfaces"`
+	Gateway    netip.Addr              `json:"gateway"`
+	GatewayIf  string                  `json:"gateway_interface,omitempty"`
+	DNSServers []localaddr.DNSServer   `json:"dns_servers"`
+	Proxy      localaddr.ProxySettings `json:"proxy"`
+	Public     netip.Addr              `json:"public"`
+	PublicErr  string                  `json:"public_error,omitempty"`
+	Public6    netip.Addr              `json:"public_ipv6"`
+	Public6Err string                  `json:"public_ipv6_error,omitempty"`
+	RouterWAN  netip.Addr              `json:"router_wan"`
+	DoubleNAT  bool                    `json:"double_nat"`
+	NATErr     string                  `json:"double_nat_error,omitempty"`
+}
+
+// ifaceReport describes one interface and what the selection made of it.
+type ifaceReport struct {
+	Name      string         `json:"name"`
+	Index     int            `json:"index"`
+	Kind      string         `json:"kind"`
+	Flags     string         `json:"flags"`
+	MAC       string         `json:"mac,omitempty"`
+	Addresses []netip.Prefix `json:"addresses"`
+	Verdict   string         `json:"verdict"`
+}
+
+// runDiagnose implements the diagnose subcommand.
+func runDiagnose(args []string) error {
+	fs := flag.NewFlagSet("localaddr diagnose", flag.ContinueOnError)
+	selection := selectionFlags(fs)
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	noPublic := fs.Bool("no-public", false, "do not look up the public addresses or ask the router")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	opts, err := selection()
+	if err != nil {
+		return err
+	}
+
+	var r report
+	r.Hostname, _ = localaddr.Hostname()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	r.FQDN, _ = localaddr.FQDN(ctx)
+	cancel()
+	r.Selected, err = localaddr.GetAddr(opts...)
+	if err != nil {
+		r.SelectErr = err.Error()
+	}
+	r.Ranking, _ = localaddr.Rank(opts...)
+	if r.Ranking == nil {
+		r.Ranking = []localaddr.Scored{}
+	}
+	candidates, _ := localaddr.GetAllAddrs(append(opts, localaddr.WithIncludeVirtual())...)
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return err
+	}
+	for _, iface := range interfaces {
+		r.Interfaces = append(r.Interfaces, inspect(iface, r.Selected, candidates))
+	}
+	r.Gateway, r.GatewayIf, _ = localaddr.Gateway(opts...)
+	r.DNSServers, _ = localaddr.DNSServers()
+	if r.DNSServers == nil {
+		r.DNSServers = []localaddr.DNSServer{}
+	}
+	r.Proxy, _ = localaddr.ProxyConfig()
+	if !*noPublic {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		r.Public, err = publicaddr.HTTP(ctx)
+		cancel()
+		if err != nil {
+			r.PublicErr = err.Error()
+		}
+		ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+		r.Public6, err = publicaddr.IPv6(ctx, opts...)
+		cancel()
+		if err != nil {
+			r.Public6Err = err.Error()
+		}
+		ctx, cancel = context.WithTimeout(context.Background(), 8*time.Second)
+		r.DoubleNAT, r.RouterWAN, err = nat.DoubleNAT(ctx, opts...)
+		cancel()
+		if err != nil {
+			r.NATErr = err.Error()
+		}
+	}
+
+	if *asJSON {
+		return printJSON(r)
+	}
+	printReport(&r)
+	return nil
+}
+
+// inspect describes iface and the reason it was or was not selected.
+func inspect(iface net.Interface, selected netip.Addr, candidates []netip.Addr) ifaceReport {
+	ir := ifaceReport{
+		Name:      iface.Name,
+		Index:     iface.Index,
+		Kind:      localaddr.InterfaceKind(iface).String(),
+		Flags:     iface.Flags.String(),
+		MAC:       iface.HardwareAddr.String(),
+		Addresses: []netip.Prefix{},
+	}
+	addrs, _ := iface.Addrs()
+	candidate := false
+	for _, a := range addrs {
+		ipnet, ok := a.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip, _ := netip.AddrFromSlice(ipnet.IP)
+		ip = ip.Unmap()
+		bits, _ := ipnet.Mask.Size()
+		if ip.Is4() && len(ipnet.Mask) == net.IPv6len {
+			bits -= 96
+		}
+		ir.Addresses = append(ir.Addresses, netip.PrefixFrom(ip, bits))
+		if ip == selected {
+			ir.Verdict = "selected"
+		}
+		for _, c := range candidates {
+			candidate = candidate || c == ip
+		}
+	}
+	switch {
+	case ir.Verdict != "":
+	case iface.Flags&net.FlagUp == 0:
+		ir.Verdict = "skipped: down"
+	case iface.Flags&net.FlagLoopback != 0:
+		ir.Verdict = "skipped: loopback"
+	case !candidate:
+		ir.Verdict = "skipped: no usable address"
+	case localaddr.IsVirtual(iface.Name):
+		ir.Verdict = "skipped: virtual (use -virtual to include)"
+	default:
+		ir.Verdict = "candidate, ranked lower"
+	}
+	return ir
+}
+
+// printReport writes r in human readable form.
+func printReport(r *report) {
+	fmt.Printf("Host:      %s (%s)\n", r.Hostname, r.FQDN)
+	if r.SelectErr != "" {
+		fmt.Printf("Selected:  none (%s)\n", r.SelectErr)
+	} else {
+		fmt.Printf("Selected:  %s\n", r.Selected)
+	}
+	if r.Gateway.IsValid() {
+		fmt.Printf("Gateway:   %s (%s)\n", r.Gateway, r.GatewayIf)
+	} else {
+		fmt.Println("Gateway:   none")
+	}
+	fmt.Printf("DNS:       %s\n", joinDNS(r.DNSServers))
+	fmt.Printf("Proxy:     %s\n", joinProxy(r.Proxy))
+	switch {
+	case r.Public.IsValid():
+		fmt.Printf("Public:    %s\n", r.Public)
+	case r.PublicErr != "":
+		fmt.Printf("Public:    unknown (%s)\n", strings.ReplaceAll(r.PublicErr, "\n", "; "))
+	}
+	switch {
+	case r.Public6.IsValid():
+		fmt.Printf("Public v6: %s\n", r.Public6)
+	case r.Public6Err != "":
+		fmt.Printf("Public v6: unknown (%s)\n", strings.ReplaceAll(r.Public6Err, "\n", "; "))
+	}
+	switch {
+	case r.DoubleNAT:
+		fmt.Printf("Router:    WAN %s, behind another NAT: port forwarding will not work\n", r.RouterWAN)
+	case r.RouterWAN.IsValid():
+		fmt.Printf("Router:    WAN %s\n", r.RouterWAN)
+	case r.NATErr != "":
+		fmt.Printf("Router:    WAN unknown (%s)\n", strings.ReplaceAll(r.NATErr, "\n", "; "))
+	}
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if len(r.Ranking) > 0 {
+		fmt.Fprintln(w, "CANDIDATE\tINTERFACE\tSCORE\tREASONS")
+		for _, c := range r.Ranking {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", c.Addr, c.Interface, c.Score, strings.Join(c.Reasons, ", "))
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintln(w, "INTERFACE\tKIND\tFLAGS\tADDRESSES\tVERDICT")
+	for _, ir := range r.Interfaces {
+		addrs := make([]string, len(ir.Addresses))
+		for i, a := range ir.Addresses {
+			addrs[i] = a.String()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", ir.Name, ir.Kind, ir.Flags, strings.Join(addrs, " "), ir.Verdict)
+	}
+	w.Flush()
+}
+
+// joinProxy formats the proxies of p with their schemes and where they were read from,
+// or "none".
+func joinProxy(p localaddr.ProxySettings) string {
+	var s []string
+	for _, proxy := range []struct {
+		scheme string
+		u      *url.URL
+	}{{"http", p.HTTP}, {"https", p.HTTPS}, {"socks", p.SOCKS}, {"pac", p.AutoConfig}} {
+		if proxy.u != nil {
+			s = append(s, proxy.scheme+"="+proxy.u.String())
+		}
+	}
+	if len(s) == 0 {
+		return "none"
+	}
+	if len(p.NoProxy) > 0 {
+		s = append(s, "except "+strings.Join(p.NoProxy, ","))
+	}
+	return strings.Join(s, " ") + " (" + p.Source + ")"
+}
+
+// joinDNS formats servers as a space separated list, with the interface of each in
+// parentheses if known, or "none".
+func joinDNS(servers []localaddr.DNSServer) string {
+	if len(servers) == 0 {
+		return "none"
+	}
+	s := make([]string, len(servers))
+	for i, d := range servers {
+		s[i] = d.Addr.String()
+		if d.Interface != "" {
+			s[i] += " (" + d.Interface + ")"
+		}
+	}
+	return strings.Join(s, " ")
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/golovingreg/localaddr"
+)
+
+// document describes the selected address. It is the --json output and the data that
+// --format templates are executed on.
+type document struct {
+	IP         netip.Addr   `json:"address"`
+	Class      string       `json:"class"`
+	Interface  string       `json:"interface"`
+	Adapter    string       `json:"adapter,omitempty"`
+	Kind       string       `json:"kind,omitempty"`
+	SSID       string       `json:"ssid,omitempty"`
+	Prefix     netip.Prefix `json:"prefix"`
+	MAC        string       `json:"mac,omitempty"`
+	Flags      []string     `json:"flags"`
+	Candidates []netip.Addr `json:"candidates"`
+}
+
+// describe builds the --json document for the selected address addr.
+func describe(addr localaddr.Address, opts []localaddr.Option) (*document, error) {
+	doc := &document{IP: addr.IP, Class: localaddr.Classify(addr.IP).String(), Flags: []string{}}
+	if addr.Interface != "" {
+		doc.Interface = addr.Interface
+		doc.Adapter = addr.Adapter
+		doc.Kind = addr.Kind.String()
+		if addr.Kind == localaddr.KindWiFi {
+			doc.SSID, _ = localaddr.SSID(localaddr.WithInterface(addr.Interface)) // best effort
+		}
+		doc.Prefix = addr.Prefix
+		doc.MAC = addr.MAC.String()
+		if addr.Flags != 0 {
+			doc.Flags = strings.Split(addr.Flags.String(), "|")
+		}
+	}
+	var err error
+	doc.Candidates, err = localaddr.GetAllAddrs(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// owner returns the details of ip, which GetOutbound picked without looking at the
+// interfaces. Only IP is set if no interface has it.
+func owner(ip netip.Addr) (localaddr.Address, error) {
+	all, err := localaddr.GetAllDetailed(localaddr.WithIncludeVirtual(), localaddr.WithAllowLinkLocal())
+	if err != nil && !errors.Is(err, localaddr.ErrNotConnected) {
+		return localaddr.Address{}, err
+	}
+	for _, a := range all {
+		if a.IP == ip {
+			return a, nil
+		}
+	}
+	return localaddr.Address{IP: ip}, nil
+}
+
+// parseFormat parses a --format template. Output of every execution ends with a newline.
+func parseFormat(format string) (*template.Template, error) {
+	tmpl, err := template.New("format").Parse(format + "\n")
+	if err != nil {
+		return nil, usageError(fmt.Errorf("invalid -format: %w", err))
+	}
+	return tmpl, nil
+}
+
+// printJSON writes v as indented JSON to stdout.
+func printJSON(v any) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("encoding JSON: %w", err)
+	}
+	return nil
+}
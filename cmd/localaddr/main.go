@@ -0,0 +1,314 @@
+// Command localaddr prints the local IP address of the machine, for use in shell scripts.
+//
+// Usage:
+//
+//	localaddr [flags]
+//	localaddr watch [flags]
+//	localaddr diagnose [flags]
+//	localaddr qr [flags]
+//	localaddr completion bash|zsh|fish
+//
+// The first form prints the address. The flags mirror the options of the localaddr package:
+//
+//	-6              print an IPv6 address instead of an IPv4 one
+//	-iface name     use the named interface
+//	-subnet prefix  only consider addresses inside prefix, e.g. 192.168.0.0/16
+//	-all            print every candidate address, one per line
+//	-default-route  use the interface of the default route
+//	-outbound       print the address used for outbound traffic
+//	-cidr           print the address with the length of its network, e.g. 192.168.1.42/24
+//	-virtual        also consider virtual interfaces (Docker bridges, VM adapters, ...)
+//	-config file    read the selection policy from a JSON, YAML, or TOML file, see
+//	                localaddr.LoadConfig; the default is $LOCALADDR_CONFIG
+//	-json           print a JSON document with the address and its class, its interface
+//	                and its kind, prefix, MAC address, interface flags, Wi-Fi SSID, and
+//	                all candidates
+//	-format tmpl    print the same data through a Go template, e.g. '{{.IP}}:{{.Interface}}';
+//	                the fields are IP, Class, Interface, Kind, SSID, Prefix, MAC, Flags,
+//	                and Candidates
+//	-quiet          print only the address, and no error message if there is none
+//
+// The policy can also be set with the variables of localaddr.ConfigFromEnv, such as
+// LOCALADDR_EXCLUDE_INTERFACES=docker*; they override the fields of the file that they
+// set, lists as a whole, and the flags override both.
+//
+// The watch form keeps running and prints a line for every change of the address, until
+// interrupted. It accepts the selection flags above, plus:
+//
+//	-json           print every change as a JSON object on its own line
+//	-format tmpl    print every change through a Go template, e.g. '{{.New}}';
+//	                the fields are Time, Old, New, and Interface
+//	-debounce d     wait until the network has been quiet for d, e.g. 500ms
+//
+// The diagnose form prints a report for troubleshooting: every interface with its
+// addresses, flags, and why it was or was not selected, the default gateway, the DNS
+// servers, the public IPv4 and IPv6 addresses, and the WAN address of the router with
+// a warning if the router is behind another NAT. It accepts the selection flags above,
+// plus:
+//
+//	-json           print the report as JSON
+//	-no-public      do not look up the public addresses or ask the router
+//
+// The qr form prints a QR code of http://<address>:<port>/ to the terminal, for opening
+// a development server from a phone on the same network. It accepts the selection flags
+// above, plus:
+//
+//	-port port      put port in the URL, e.g. 8080
+//
+// The completion form prints a completion script for the given shell, e.g.
+//
+//	source <(localaddr completion bash)
+//
+// The exit status is 0 if an address was printed, 2 if no usable address was found
+// (typically because the machine is not connected yet), 3 if the command line or the
+// configuration is invalid, including an -iface that does not exist, and 1 for any
+// other error.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/netip"
+	"os"
+	"text/template"
+
+	"github.com/golovingreg/localaddr"
+)
+
+func main() {
+	var cmd string
+	if len(os.Args) > 1 {
+		cmd = os.Args[1]
+	}
+	var err error
+	switch cmd {
+	case "watch":
+		err = runWatch(os.Args[2:])
+	case "diagnose":
+		err = runDiagnose(os.Args[2:])
+	case "qr":
+		err = runQR(os.Args[2:])
+	case "completion":
+		err = runCompletion(os.Args[2:])
+	default:
+		err = run(os.Args[1:])
+	}
+	if err != nil {
+		code := exitFailure
+		var e *exitError
+		if errors.As(err, &e) {
+			code, err = e.code, e.err
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "localaddr:", err)
+		}
+		os.Exit(code)
+	}
+}
+
+// Exit codes, see the package documentation.
+const (
+	exitFailure      = 1
+	exitNotConnected = 2
+	exitUsage        = 3
+)
+
+// exitError makes the program exit with a specific code. If err is nil, nothing is printed.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	if e.err == nil {
+		return fmt.Sprintf("exit status %d", e.code)
+	}
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error { return e.err }
+
+// usageError reports a problem with the command line.
+func usageError(err error) error {
+	return &exitError{exitUsage, err}
+}
+
+// lookupError gives err of a getter its exit code: exitNotConnected if no address was
+// found, exitUsage if the selection flags or the configuration are invalid, e.g. name an
+// interface that does not exist, and exitFailure otherwise.
+func lookupError(err error) error {
+	switch {
+	case errors.Is(err, localaddr.ErrInvalidOption), errors.Is(err, localaddr.ErrInterfaceNotFound):
+		return usageError(err)
+	case errors.Is(err, localaddr.ErrNotConnected), errors.Is(err, localaddr.ErrNoIPv4), errors.Is(err, localaddr.ErrNoIPv6):
+		return &exitError{exitNotConnected, err}
+	}
+	return err
+}
+
+// parseArgs parses the flags of a subcommand, which takes no arguments besides them.
+func parseArgs(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		return usageError(nil) // the flag package has printed the error
+	}
+	if fs.NArg() > 0 {
+		return usageError(fmt.Errorf("unexpected arguments: %v", fs.Args()))
+	}
+	return nil
+}
+
+// run parses the command line and prints the requested addresses.
+func run(args []string) error {
+	fs := flag.NewFlagSet("localaddr", flag.ContinueOnError)
+	selection := selectionFlags(fs)
+	all := fs.Bool("all", false, "print every candidate address, one per line")
+	outbound := fs.Bool("outbound", false, "print the address used for outbound traffic")
+	cidr := fs.Bool("cidr", false, "print the address with the length of its network")
+	asJSON := fs.Bool("json", false, "print a JSON document with details about the address")
+	format := fs.String("format", "", "print details about the address through a Go `template`")
+	quiet := fs.Bool("quiet", false, "print only the address, and nothing if there is none")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	opts, err := selection()
+	if err != nil {
+		return err
+	}
+
+	var tmpl *template.Template
+	if *format != "" {
+		if tmpl, err = parseFormat(*format); err != nil {
+			return err
+		}
+	}
+	detailed := *asJSON || tmpl != nil
+	if *cidr && detailed {
+		return usageError(errors.New("-cidr cannot be combined with -json or -format"))
+	}
+	if *quiet {
+		if detailed {
+			return usageError(errors.New("-quiet cannot be combined with -json or -format"))
+		}
+		err := show(opts, *all, *outbound, *cidr, nil, false)
+		if e := (*exitError)(nil); errors.As(err, &e) {
+			return &exitError{e.code, nil} // keep the code, drop the message
+		}
+		return err
+	}
+	return show(opts, *all, *outbound, *cidr, tmpl, *asJSON)
+}
+
+// show prints the address selected by opts in the requested form.
+func show(opts []localaddr.Option, all, outbound, cidr bool, tmpl *template.Template, asJSON bool) error {
+	detailed := asJSON || tmpl != nil
+	if all && cidr {
+		addrs, err := localaddr.GetAllDetailed(opts...)
+		if err != nil {
+			return lookupError(err)
+		}
+		for _, addr := range addrs {
+			fmt.Println(addr.Prefix)
+		}
+		return nil
+	}
+	if all && !detailed {
+		addrs, err := localaddr.GetAll(opts...)
+		if err != nil {
+			return lookupError(err)
+		}
+		for _, addr := range addrs {
+			fmt.Println(addr)
+		}
+		return nil
+	}
+
+	var addr localaddr.Address
+	var err error
+	if outbound {
+		addr.IP, err = localaddr.GetOutboundAddr(opts...)
+		if err == nil {
+			addr, err = owner(addr.IP)
+		}
+	} else {
+		addr, err = localaddr.GetDetailed(opts...)
+	}
+	if err != nil {
+		return lookupError(err)
+	}
+	if cidr {
+		if !addr.Prefix.IsValid() {
+			addr.Prefix = netip.PrefixFrom(addr.IP.WithZone(""), addr.IP.BitLen()) // no interface has it
+		}
+		fmt.Println(addr.Prefix)
+		return nil
+	}
+	if !detailed {
+		fmt.Println(addr.IP)
+		return nil
+	}
+	doc, err := describe(addr, opts)
+	if err != nil {
+		return err
+	}
+	if tmpl != nil {
+		return tmpl.Execute(os.Stdout, doc)
+	}
+	return printJSON(doc)
+}
+
+// selectionFlags defines the flags that choose which address is used, and returns a
+// function that turns them into options once fs is parsed.
+func selectionFlags(fs *flag.FlagSet) func() ([]localaddr.Option, error) {
+	ipv6 := fs.Bool("6", false, "use an IPv6 address instead of an IPv4 one")
+	iface := fs.String("iface", "", "use the named `interface`")
+	subnet := fs.String("subnet", "", "only consider addresses inside `prefix`, e.g. 192.168.0.0/16")
+	defaultRoute := fs.Bool("default-route", false, "use the interface of the default route")
+	virtual := fs.Bool("virtual", false, "also consider virtual interfaces")
+	config := fs.String("config", os.Getenv("LOCALADDR_CONFIG"), "read the selection policy from a JSON, YAML, or TOML `file`")
+	return func() ([]localaddr.Option, error) {
+		var policy localaddr.Config
+		if *config != "" {
+			c, err := localaddr.LoadConfig(*config)
+			if err != nil {
+				return nil, usageError(err)
+			}
+			policy = c
+		}
+		env, err := localaddr.ConfigFromEnv()
+		if err != nil {
+			return nil, usageError(err)
+		}
+		policy = policy.Merge(env)
+		if *subnet != "" {
+			policy.Subnets = nil // replaced by the flag
+		}
+		opts := policy.Options() // the other flags are applied after it, so they win
+		if *ipv6 {
+			opts = append(opts, localaddr.WithIPv6())
+		}
+		if *iface != "" {
+			opts = append(opts, localaddr.WithInterface(*iface))
+		}
+		if *subnet != "" {
+			prefix, err := netip.ParsePrefix(*subnet)
+			if err != nil {
+				return nil, usageError(fmt.Errorf("invalid -subnet: %w", err))
+			}
+			opts = append(opts, localaddr.WithSubnet(prefix))
+			if prefix.Addr().Is6() {
+				opts = append(opts, localaddr.WithIPv6()) // as GetInSubnet does
+			}
+		}
+		if *defaultRoute {
+			opts = append(opts, localaddr.WithDefaultRoute())
+		}
+		if *virtual {
+			opts = append(opts, localaddr.WithIncludeVirtual())
+		}
+		return opts, nil
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/golovingreg/localaddr"
+	"github.com/golovingreg/localaddr/internal/qr"
+)
+
+// runQR implements the qr subcommand.
+func runQR(args []string) error {
+	fs := flag.NewFlagSet("localaddr qr", flag.ContinueOnError)
+	selection := selectionFlags(fs)
+	port := fs.Int("port", 0, "put `port` in the URL, e.g. 8080")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if *port < 0 || *port > 65535 {
+		return usageError(fmt.Errorf("invalid -port %d", *port))
+	}
+	opts, err := selection()
+	if err != nil {
+		return err
+	}
+	url, err := localaddr.BuildURL("http", *port, "/", opts...)
+	if err != nil {
+		return lookupError(err)
+	}
+	code, err := qr.Encode(url)
+	if err != nil {
+		return err
+	}
+	printQR(os.Stdout, code)
+	fmt.Println(url)
+	return nil
+}
+
+// printQR draws code with half block characters, two rows of modules per line. The
+// colors are set explicitly, so the code scans on dark and light terminals alike.
+func printQR(w io.Writer, code *qr.Code) {
+	const quiet = 2 // modules of light border
+	var b strings.Builder
+	for y := -quiet; y < code.Size+quiet; y += 2 {
+		b.WriteString("\x1b[30;47m") // black on white
+		for x := -quiet; x < code.Size+quiet; x++ {
+			switch top, bottom := code.Black(x, y), code.Black(x, y+1); {
+			case top && bottom:
+				b.WriteString("█")
+			case top:
+				b.WriteString("▀")
+			case bottom:
+				b.WriteString("▄")
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString("\x1b[0m\n")
+	}
+	io.WriteString(w, b.String())
+}
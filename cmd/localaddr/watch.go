@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/netip"
+	"os"
+	"os/signal"
+	"text/template"
+	"time"
+
+	"github.com/golovingreg/localaddr"
+)
+
+// watchLine is a change printed by watch -json.
+type watchLine struct {
+	Time      time.Time  `json:"time"`
+	Old       netip.Addr `json:"old"`
+	New       netip.Addr `json:"new"`
+	Interface string     `json:"interface"`
+}
+
+// runWatch implements the watch subcommand.
+func runWatch(args []string) error {
+	fs := flag.NewFlagSet("localaddr watch", flag.ContinueOnError)
+	selection := selectionFlags(fs)
+	asJSON := fs.Bool("json", false, "print every change as a JSON object on its own line")
+	format := fs.String("format", "", "print every change through a Go `template`")
+	debounce := fs.Duration("debounce", 0, "wait until the network has been quiet for `duration`")
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	opts, err := selection()
+	if err != nil {
+		return err
+	}
+	var tmpl *template.Template
+	if *format != "" {
+		if tmpl, err = parseFormat(*format); err != nil {
+			return err
+		}
+	}
+	if *debounce > 0 {
+		opts = append(opts, localaddr.WithDebounce(*debounce))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	events, err := localaddr.NewWatcher(opts...).Watch(ctx)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	for ev := range events {
+		if tmpl != nil {
+			if err := tmpl.Execute(os.Stdout, ev); err != nil {
+				return err
+			}
+			continue
+		}
+		if *asJSON {
+			if err := enc.Encode(watchLine{ev.Time.UTC(), ev.Old, ev.New, ev.Interface}); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Printf("%s %s -> %s", ev.Time.Format(time.RFC3339), orNone(ev.Old), orNone(ev.New))
+		if ev.Interface != "" {
+			fmt.Printf(" (%s)", ev.Interface)
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+// orNone formats addr, or "none" for the zero Addr.
+func orNone(addr netip.Addr) string {
+	if !addr.IsValid() {
+		return "none"
+	}
+	return addr.String()
+}
@@ -0,0 +1,361 @@
+package localaddr
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Config is a declarative form of the selection options, so that a team can keep its
+// address selection policy in a file and apply it the same way in every service and in
+// the command line tool.
+//
+// The field names are those of the JSON form, e.g. "exclude_interfaces", also in YAML
+// and TOML files (see LoadConfig). The struct carries the same names as yaml and toml
+// tags, so YAML and TOML packages that honor them, and encoding.TextUnmarshaler for the
+// prefixes and enumerations, decode it too.
+// Each field stands for the option of the same name, e.g. VPN for WithVPN; zero values
+// leave it unset.
+type Config struct {
+	IPv6              bool           `json:"ipv6,omitempty" yaml:"ipv6,omitempty" toml:"ipv6,omitempty"`
+	Interface         string         `json:"interface,omitempty" yaml:"interface,omitempty" toml:"interface,omitempty"`
+	DefaultRoute      bool           `json:"default_route,omitempty" yaml:"default_route,omitempty" toml:"default_route,omitempty"`
+	IncludeInterfaces []string       `json:"include_interfaces,omitempty" yaml:"include_interfaces,omitempty" toml:"include_interfaces,omitempty"`
+	ExcludeInterfaces []string       `json:"exclude_interfaces,omitempty" yaml:"exclude_interfaces,omitempty" toml:"exclude_interfaces,omitempty"`
+	IncludeVirtual    bool           `json:"include_virtual,omitempty" yaml:"include_virtual,omitempty" toml:"include_virtual,omitempty"`
+	Subnets           []netip.Prefix `json:"subnets,omitempty" yaml:"subnets,omitempty" toml:"subnets,omitempty"`
+	PreferredSubnets  []netip.Prefix `json:"preferred_subnets,omitempty" yaml:"preferred_subnets,omitempty" toml:"preferred_subnets,omitempty"`
+	PreferWired       bool           `json:"prefer_wired,omitempty" yaml:"prefer_wired,omitempty" toml:"prefer_wired,omitempty"`
+	PreferStable      bool           `json:"prefer_stable,omitempty" yaml:"prefer_stable,omitempty" toml:"prefer_stable,omitempty"`
+	AllowLinkLocal    bool           `json:"allow_link_local,omitempty" yaml:"allow_link_local,omitempty" toml:"allow_link_local,omitempty"`
+	IPv6Scope         IPv6Scope      `json:"ipv6_scope,omitempty" yaml:"ipv6_scope,omitempty" toml:"ipv6_scope,omitempty"`
+	Temporary         Preference     `json:"temporary,omitempty" yaml:"temporary,omitempty" toml:"temporary,omitempty"`
+	VPN               Preference     `json:"vpn,omitempty" yaml:"vpn,omitempty" toml:"vpn,omitempty"`
+	CGNAT             Preference     `json:"cgnat,omitempty" yaml:"cgnat,omitempty" toml:"cgnat,omitempty"`
+	Tailscale         Preference     `json:"tailscale,omitempty" yaml:"tailscale,omitempty" toml:"tailscale,omitempty"`
+	VMAdapters        Preference     `json:"vm_adapters,omitempty" yaml:"vm_adapters,omitempty" toml:"vm_adapters,omitempty"`
+	Transition        Preference     `json:"transition,omitempty" yaml:"transition,omitempty" toml:"transition,omitempty"`
+}
+
+// LoadConfig reads a Config from a JSON, YAML, or TOML file, by the extension of path:
+// .yaml or .yml for YAML, .toml for TOML, and JSON otherwise. Unknown fields are an
+// error, so a typo does not silently change the policy.
+//
+// Config is flat, so YAML and TOML are read without a dependency, as far as a Config
+// needs them: one field per line, with a scalar value, quoted or not, or a list, either
+// inline as in ["docker*", "veth*"] or, in YAML, as "- item" lines below the field.
+// Comments start with #. Nested mappings, TOML tables, and multi-line strings are an
+// error.
+//
+// Returns:
+//   - Config: The configuration
+//   - error: An error if the file cannot be read or decoded, or contains unknown fields
+func LoadConfig(path string) (Config, error) {
+	var c Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = decodeFlat(data, ':', &c)
+	case ".toml":
+		err = decodeFlat(data, '=', &c)
+	default:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&c)
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("config %s: %w", path, err)
+	}
+	return c, nil
+}
+
+// decodeFlat decodes the subset of YAML (sep ':') or TOML (sep '=') described at
+// LoadConfig into c.
+func decodeFlat(data []byte, sep byte, c *Config) error {
+	v := reflect.ValueOf(c).Elem()
+	fields := make(map[string]reflect.Value, v.NumField())
+	for i := 0; i < v.NumField(); i++ {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		fields[name] = v.Field(i)
+	}
+	seen := make(map[string]bool)
+	var field reflect.Value // the YAML list that "- item" lines add to
+	var items []string
+	flush := func() error {
+		if field.IsValid() && len(items) > 0 {
+			if err := setValues(field, items, true); err != nil {
+				return err
+			}
+		}
+		field, items = reflect.Value{}, nil
+		return nil
+	}
+	for n, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(stripComment(line))
+		if line == "" || (sep == ':' && line == "---") {
+			continue
+		}
+		if item, ok := strings.CutPrefix(line, "- "); ok && sep == ':' {
+			if !field.IsValid() {
+				return fmt.Errorf("line %d: list item without a field", n+1)
+			}
+			items = append(items, unquote(strings.TrimSpace(item)))
+			continue
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		if strings.HasPrefix(line, "[") {
+			return fmt.Errorf("line %d: tables are not supported", n+1)
+		}
+		key, value, ok := strings.Cut(line, string(sep))
+		if !ok {
+			return fmt.Errorf("line %d: expected %q after the field name", n+1, sep)
+		}
+		key, value = unquote(strings.TrimSpace(key)), strings.TrimSpace(value)
+		f, known := fields[key]
+		if !known {
+			return fmt.Errorf("line %d: unknown field %q", n+1, key)
+		}
+		if seen[key] {
+			return fmt.Errorf("line %d: field %q given twice", n+1, key)
+		}
+		seen[key] = true
+		var err error
+		switch {
+		case value == "" && sep == ':':
+			field = f // the items follow
+		case strings.HasPrefix(value, "["):
+			inner, ok := strings.CutSuffix(value, "]")
+			if !ok {
+				return fmt.Errorf("line %d: unterminated list", n+1)
+			}
+			var list []string
+			for _, item := range strings.Split(inner[1:], ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					list = append(list, unquote(item))
+				}
+			}
+			err = setValues(f, list, true)
+		default:
+			err = setValues(f, []string{unquote(value)}, false)
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: field %q: %w", n+1, key, err)
+		}
+	}
+	return flush()
+}
+
+// setValues sets f to values, which form a list if list is true.
+func setValues(f reflect.Value, values []string, list bool) error {
+	if f.Kind() != reflect.Slice {
+		if list {
+			return errors.New("not a list")
+		}
+		return setField(f, values[0])
+	}
+	s := reflect.MakeSlice(f.Type(), len(values), len(values))
+	for i, value := range values {
+		if err := setField(s.Index(i), value); err != nil {
+			return err
+		}
+	}
+	f.Set(s)
+	return nil
+}
+
+// stripComment removes a # comment from line, unless the # is quoted.
+func stripComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// unquote removes the double or single quotes around s, if any. Double quoted strings
+// may contain escapes.
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		if u, err := strconv.Unquote(s); err == nil {
+			return u
+		}
+	}
+	if len(s) >= 2 && s[0] == '\'' && s[len(s)-1] == '\'' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// ConfigFromEnv reads a Config from environment variables named after its JSON fields,
+// with a LOCALADDR_ prefix: LOCALADDR_EXCLUDE_INTERFACES=docker*,veth*,
+// LOCALADDR_PREFERRED_SUBNETS=10.0.0.0/8, LOCALADDR_VPN=exclude, and so on. Lists are
+// separated by commas, booleans are parsed by strconv.ParseBool. Unset and empty
+// variables leave their field unset.
+//
+// LOCALADDR_INTERFACE is the same variable as EnvInterface, which the getters apply by
+// themselves anyway.
+func ConfigFromEnv() (Config, error) {
+	var c Config
+	v := reflect.ValueOf(&c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+		env := "LOCALADDR_" + strings.ToUpper(name)
+		s := os.Getenv(env)
+		if s == "" {
+			continue
+		}
+		if err := setField(v.Field(i), s); err != nil {
+			return Config{}, fmt.Errorf("invalid %s: %w", env, err)
+		}
+	}
+	return c, nil
+}
+
+// setField parses s into a field of Config.
+func setField(f reflect.Value, s string) error {
+	if u, ok := f.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+	switch f.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		f.SetBool(b)
+		return err
+	case reflect.String:
+		f.SetString(s)
+		return nil
+	case reflect.Slice:
+		parts := strings.Split(s, ",")
+		list := reflect.MakeSlice(f.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setField(list.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		f.Set(list)
+		return nil
+	}
+	return fmt.Errorf("unsupported field type %s", f.Type())
+}
+
+// Merge returns c with the fields that are set in over replaced by those of over, e.g.
+// the policy of a file with that of ConfigFromEnv on top. Lists are replaced as a whole,
+// not appended to.
+func (c Config) Merge(over Config) Config {
+	v, o := reflect.ValueOf(&c).Elem(), reflect.ValueOf(over)
+	for i := 0; i < v.NumField(); i++ {
+		if f := o.Field(i); !f.IsZero() {
+			v.Field(i).Set(f)
+		}
+	}
+	return c
+}
+
+// Options returns the options that c stands for.
+func (c *Config) Options() []Option {
+	var opts []Option
+	if c.IPv6 {
+		opts = append(opts, WithIPv6())
+	}
+	if c.Interface != "" {
+		opts = append(opts, WithInterface(c.Interface))
+	}
+	if c.DefaultRoute {
+		opts = append(opts, WithDefaultRoute())
+	}
+	if len(c.IncludeInterfaces) > 0 {
+		opts = append(opts, WithIncludeInterfaces(c.IncludeInterfaces...))
+	}
+	if len(c.ExcludeInterfaces) > 0 {
+		opts = append(opts, WithExcludeInterfaces(c.ExcludeInterfaces...))
+	}
+	if c.IncludeVirtual {
+		opts = append(opts, WithIncludeVirtual())
+	}
+	for _, prefix := range c.Subnets {
+		opts = append(opts, WithSubnet(prefix))
+	}
+	for _, prefix := range c.PreferredSubnets {
+		opts = append(opts, WithPreferredSubnet(prefix.String()))
+	}
+	if c.PreferWired {
+		opts = append(opts, WithPreferWired())
+	}
+	if c.PreferStable {
+		opts = append(opts, WithPreferStable())
+	}
+	if c.AllowLinkLocal {
+		opts = append(opts, WithAllowLinkLocal())
+	}
+	if c.IPv6Scope != ScopeAny {
+		opts = append(opts, WithIPv6Scope(c.IPv6Scope))
+	}
+	for _, p := range []struct {
+		value  Preference
+		option func(Preference) Option
+	}{{c.Temporary, WithTemporary}, {c.VPN, WithVPN}, {c.CGNAT, WithCGNAT}, {c.Tailscale, WithTailscale}, {c.VMAdapters, WithVMAdapters}, {c.Transition, WithTransition}} {
+		if p.value != 0 {
+			opts = append(opts, p.option(p.value))
+		}
+	}
+	return opts
+}
+
+// Resolver selects addresses according to a Config. Its methods take further options,
+// which are applied after those of the configuration.
+type Resolver struct {
+	opts []Option
+}
+
+// NewResolver returns a Resolver for cfg.
+func NewResolver(cfg Config) *Resolver {
+	return &Resolver{opts: cfg.Options()}
+}
+
+// Options returns the options of the configuration followed by opts, for the functions
+// of this package that Resolver has no method for.
+func (r *Resolver) Options(opts ...Option) []Option {
+	return append(append([]Option(nil), r.opts...), opts...)
+}
+
+// Get is like the package function Get, for the configuration of r.
+func (r *Resolver) Get(opts ...Option) (string, error) { return Get(r.Options(opts...)...) }
+
+// GetIPv6 is like the package function GetIPv6, for the configuration of r.
+func (r *Resolver) GetIPv6(opts ...Option) (string, error) { return GetIPv6(r.Options(opts...)...) }
+
+// GetAll is like the package function GetAll, for the configuration of r.
+func (r *Resolver) GetAll(opts ...Option) ([]string, error) { return GetAll(r.Options(opts...)...) }
+
+// GetDetailed is like the package function GetDetailed, for the configuration of r.
+func (r *Resolver) GetDetailed(opts ...Option) (Address, error) {
+	return GetDetailed(r.Options(opts...)...)
+}
+
+// Rank is like the package function Rank, for the configuration of r.
+func (r *Resolver) Rank(opts ...Option) ([]Scored, error) { return Rank(r.Options(opts...)...) }
+
+// NewWatcher is like the package function NewWatcher, for the configuration of r.
+func (r *Resolver) NewWatcher(opts ...Option) *Watcher { return NewWatcher(r.Options(opts...)...) }
@@ -0,0 +1,82 @@
+package localaddr
+
+import (
+	"net/netip"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeFlat(t *testing.T) {
+	prefixes := func(s ...string) []netip.Prefix {
+		var p []netip.Prefix
+		for _, v := range s {
+			p = append(p, netip.MustParsePrefix(v))
+		}
+		return p
+	}
+	tests := []struct {
+		name string
+		sep  byte
+		data string
+		want Config
+		err  string // part of the error, "" if there is none
+	}{
+		{"yaml scalars", ':', "ipv6: true\ninterface: eth0\nvpn: prefer\nipv6_scope: ula\n",
+			Config{IPv6: true, Interface: "eth0", VPN: Prefer, IPv6Scope: ScopeULA}, ""},
+		{"yaml double quoted", ':', `interface: "Ethernet \"2\""`, Config{Interface: `Ethernet "2"`}, ""},
+		{"yaml single quoted", ':', "interface: 'Wi-Fi'", Config{Interface: "Wi-Fi"}, ""},
+		{"yaml quoted key", ':', `"interface": en0`, Config{Interface: "en0"}, ""},
+		{"yaml inline list", ':', `exclude_interfaces: ["docker*", veth*, 'br-*']`,
+			Config{ExcludeInterfaces: []string{"docker*", "veth*", "br-*"}}, ""},
+		{"yaml empty inline list", ':', "exclude_interfaces: []", Config{ExcludeInterfaces: []string{}}, ""},
+		{"yaml dash list", ':', "---\ninclude_interfaces:\n  - en*\n  - \"eth*\"  # wired\nprefer_wired: true\n",
+			Config{IncludeInterfaces: []string{"en*", "eth*"}, PreferWired: true}, ""},
+		{"yaml comments", ':', "# policy\ninterface: en0 # the LAN\n\ncgnat: avoid\n", Config{Interface: "en0", CGNAT: Avoid}, ""},
+		{"yaml quoted hash", ':', `interface: "a#b" # c`, Config{Interface: "a#b"}, ""},
+		{"yaml IPv6 prefixes", ':', "subnets: [fd00::/8, \"2001:db8::/32\"]\npreferred_subnets:\n  - fd00:1::/64\n",
+			Config{Subnets: prefixes("fd00::/8", "2001:db8::/32"), PreferredSubnets: prefixes("fd00:1::/64")}, ""},
+		{"yaml duplicate", ':', "interface: en0\ninterface: en1\n", Config{}, `field "interface" given twice`},
+		{"yaml unknown", ':', "interfaces: en0\n", Config{}, `unknown field "interfaces"`},
+		{"yaml nested mapping", ':', "interface:\n  name: en0\n", Config{}, `unknown field "name"`},
+		{"yaml item without field", ':', "- en0\n", Config{}, "list item without a field"},
+		{"yaml list for a scalar", ':', "interface: [en0, en1]\n", Config{}, "not a list"},
+		{"yaml unterminated list", ':', "subnets: [10.0.0.0/8\n", Config{}, "unterminated list"},
+		{"yaml invalid prefix", ':', "subnets: [10.0.0.0]\n", Config{}, `field "subnets"`},
+		{"yaml invalid preference", ':', "vpn: sometimes\n", Config{}, `field "vpn"`},
+		{"yaml without separator", ':', "ipv6\n", Config{}, `expected ':'`},
+		{"toml", '=', "# policy\nipv6 = true\ninterface = \"eth0\" # wired\nsubnets = [\"fd00::/8\", \"10.0.0.0/8\"]\ntransition = 'avoid'\n",
+			Config{IPv6: true, Interface: "eth0", Subnets: prefixes("fd00::/8", "10.0.0.0/8"), Transition: Avoid}, ""},
+		{"toml table", '=', "[localaddr]\nipv6 = true\n", Config{}, "tables are not supported"},
+		{"toml duplicate", '=', "vpn = \"allow\"\nvpn = \"avoid\"\n", Config{}, `field "vpn" given twice`},
+		{"toml unknown", '=', "vpns = \"allow\"\n", Config{}, `unknown field "vpns"`},
+		{"toml dash item", '=', "- en0\n", Config{}, `expected '='`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Config
+			err := decodeFlat([]byte(tt.data), tt.sep, &got)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("decodeFlat() error = %v, want one with %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeFlat() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeFlat() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigMerge(t *testing.T) {
+	file := Config{Interface: "en0", ExcludeInterfaces: []string{"docker*"}, Subnets: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, VPN: Avoid}
+	env := Config{ExcludeInterfaces: []string{"veth*"}, VPN: Exclude}
+	want := Config{Interface: "en0", ExcludeInterfaces: []string{"veth*"}, Subnets: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}, VPN: Exclude}
+	if got := file.Merge(env); !reflect.DeepEqual(got, want) {
+		t.Errorf("Merge() = %+v, want %+v", got, want)
+	}
+}
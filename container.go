@@ -0,0 +1,43 @@
+package localaddr
+
+import (
+	"os"
+	"sync"
+)
+
+// containerInterface is the interface that container runtimes and CNI plugins give the
+// container side of its network.
+const containerInterface = "eth0"
+
+// InContainer reports whether the process runs in a container: a Docker, Podman, LXC,
+// or systemd-nspawn one, or a Kubernetes pod. It looks for the marker files and
+// environment variables that the runtimes leave, and on Linux for a container in the
+// control group of the init process. The result is computed once.
+//
+// Inside a container, the getters prefer eth0, the interface the runtime connects the
+// container with. Its address is private, e.g. 172.17.0.2 on the Docker default network,
+// and its interface a veth, so without the preference it would lose to any physical
+// interface that is shared into the container; see WithIgnoreContainer.
+func InContainer() bool {
+	return inContainer()
+}
+
+var inContainer = sync.OnceValue(func() bool {
+	if os.Getenv("container") != "" || InKubernetes() { // set by Podman, LXC, and systemd-nspawn
+		return true
+	}
+	for _, marker := range []string{"/.dockerenv", "/run/.containerenv"} {
+		if _, err := os.Stat(marker); err == nil {
+			return true
+		}
+	}
+	return containerCgroup()
+})
+
+// WithIgnoreContainer makes the getters rank interfaces as on a host even inside a
+// container, see InContainer.
+func WithIgnoreContainer() Option {
+	return func(c *config) {
+		c.ignoreContainer = true
+	}
+}
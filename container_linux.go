@@ -0,0 +1,28 @@
+package localaddr
+
+import (
+	"os"
+	"strings"
+)
+
+// containerCgroup reports whether the init process runs in the control group of a
+// container. With cgroup v2 namespaces the path is just "/", so this only catches
+// cgroup v1 hosts and runtimes that do not unshare the cgroup namespace.
+func containerCgroup() bool {
+	data, err := os.ReadFile("/proc/1/cgroup")
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.SplitN(line, ":", 3) // "4:memory:/docker/3f2a..."
+		if len(fields) < 3 {
+			continue
+		}
+		for _, runtime := range []string{"/docker", "/kubepods", "/containerd", "/lxc", "/libpod"} {
+			if strings.Contains(fields[2], runtime) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package localaddr
+
+// containerCgroup reports whether the process runs in a container's control group,
+// which only Linux has.
+func containerCgroup() bool { return false }
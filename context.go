@@ -0,0 +1,51 @@
+package localaddr
+
+import "context"
+
+// GetContext is like Get, but gives up once ctx is done.
+//
+// Enumerating interfaces is quick on most systems, but can stall for seconds, e.g. on
+// Windows while an adapter is being reconfigured. If ctx is done first, GetContext
+// returns ctx.Err() right away, while the enumeration finishes in the background.
+func GetContext(ctx context.Context, opts ...Option) (string, error) {
+	return withContext(ctx, func() (string, error) { return Get(opts...) })
+}
+
+// GetIPv6Context is like GetIPv6, but gives up once ctx is done, as GetContext does.
+func GetIPv6Context(ctx context.Context, opts ...Option) (string, error) {
+	return withContext(ctx, func() (string, error) { return GetIPv6(opts...) })
+}
+
+// GetAllContext is like GetAll, but gives up once ctx is done, as GetContext does.
+func GetAllContext(ctx context.Context, opts ...Option) ([]string, error) {
+	return withContext(ctx, func() ([]string, error) { return GetAll(opts...) })
+}
+
+// GetOutboundContext is like GetOutbound, but the dial is bounded by ctx. This matters
+// when WithTarget names a host, which has to be resolved first.
+func GetOutboundContext(ctx context.Context, opts ...Option) (string, error) {
+	return str(outbound(ctx, newConfig(ipv4, opts)))
+}
+
+// withContext returns the result of f, or ctx.Err() if ctx is done before f returns.
+func withContext[T any](ctx context.Context, f func() (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+	type result struct {
+		v   T
+		err error
+	}
+	done := make(chan result, 1) // buffered, so an abandoned f does not leak its goroutine
+	go func() {
+		v, err := f()
+		done <- result{v, err}
+	}()
+	select {
+	case r := <-done:
+		return r.v, r.err
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
@@ -0,0 +1,56 @@
+package localaddr
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+)
+
+// ErrNoLease is returned by DHCPLease when the interface has no DHCP lease, because
+// its address is configured statically, or no DHCP client that DHCPLease knows keeps one.
+var ErrNoLease = errors.New("no DHCP lease")
+
+// Lease is the DHCP lease of an address.
+type Lease struct {
+	Interface string
+	Addr      netip.Addr
+	Server    netip.Addr    // DHCP server that granted the lease
+	Duration  time.Duration // lease time the server granted, zero if unknown
+	Obtained  time.Time     // when the lease was obtained or last renewed, zero if unknown
+	Renews    time.Time     // when the client asks to extend it, zero if unknown
+	Expires   time.Time     // when the address is lost unless the lease is renewed, zero if unknown
+	Router    netip.Addr
+	DNS       []netip.Addr
+	Domain    string
+	// Options holds every option of the lease under the name its source uses, e.g.
+	// "domain-name-servers" for dhclient and "DNS" for systemd-networkd. Windows does not
+	// tell them.
+	Options map[string]string
+}
+
+// DHCPLease returns the DHCP lease of the selected address, to tell e.g. when the address
+// may change. The address is selected as by Get; pass WithInterface and the other options
+// as needed. Only DHCPv4 leases are known.
+//
+// On Linux, the lease is read from the files of systemd-networkd, NetworkManager, or
+// dhclient, whichever holds one for the address; on Windows, with GetAdaptersInfo. On
+// other platforms DHCPLease returns an error wrapping errors.ErrUnsupported.
+//
+// Returns:
+//   - Lease: The lease (e.g., 24 hours from "192.168.1.1", expiring at 09:00)
+//   - error: An error wrapping ErrNoLease if the address is not from DHCP, or an error if
+//     no address is found or the lease cannot be read
+func DHCPLease(opts ...Option) (Lease, error) {
+	cfg := newConfig(ipv4, opts)
+	c, err := first(cfg)
+	if err != nil {
+		return Lease{}, err
+	}
+	lease, err := readLease(c.iface.Name, c.iface.Index, c.ip.WithZone(""))
+	if err != nil {
+		return Lease{}, fmt.Errorf("interface %q: %w", c.iface.Name, err)
+	}
+	lease.Interface, lease.Addr = c.iface.Name, c.ip
+	return lease, nil
+}
@@ -0,0 +1,216 @@
+package localaddr
+
+import (
+	"bufio"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Where DHCP clients keep their leases. networkdLeases holds files named by interface
+// index; the patterns take the interface name.
+const (
+	networkdLeases = "/run/systemd/netif/leases"
+	nmInternal     = "/var/lib/NetworkManager/internal-*-%s.lease"
+)
+
+// dhclientLeases are the lease files of dhclient, including the ones NetworkManager
+// has it write.
+var dhclientLeases = []string{
+	"/var/lib/dhcp/dhclient*.leases",
+	"/var/lib/dhclient/*.lease*",
+	"/var/lib/NetworkManager/dhclient-*.lease",
+}
+
+// readLease looks for the lease of ip on the interface in the files of systemd-networkd,
+// the internal client of NetworkManager, which writes the same format, and dhclient. If
+// several hold one, the one that expires last is the current one.
+func readLease(name string, index int, ip netip.Addr) (Lease, error) {
+	var found []Lease
+	if l, ok := readNetworkdLease(filepath.Join(networkdLeases, strconv.Itoa(index)), ip); ok {
+		found = append(found, l)
+	}
+	nm, _ := filepath.Glob(fmt.Sprintf(nmInternal, name))
+	for _, file := range nm {
+		if l, ok := readNetworkdLease(file, ip); ok {
+			found = append(found, l)
+		}
+	}
+	for _, pattern := range dhclientLeases {
+		files, _ := filepath.Glob(pattern)
+		for _, file := range files {
+			if l, ok := readDhclientLease(file, name, ip); ok {
+				found = append(found, l)
+			}
+		}
+	}
+	if len(found) == 0 {
+		return Lease{}, ErrNoLease
+	}
+	best := found[0]
+	for _, l := range found[1:] {
+		if l.Expires.After(best.Expires) {
+			best = l
+		}
+	}
+	return best, nil
+}
+
+// readNetworkdLease parses a lease file of systemd-networkd, KEY=value lines:
+//
+//	ADDRESS=192.168.1.23
+//	SERVER_ADDRESS=192.168.1.1
+//	LIFETIME=86400
+//
+// The file is rewritten whenever the lease is renewed, so its modification time is when
+// it was obtained. It reports false if there is no such file or it is for another address.
+func readNetworkdLease(name string, ip netip.Addr) (Lease, bool) {
+	file, err := os.Open(name)
+	if err != nil {
+		return Lease{}, false
+	}
+	defer file.Close()
+	options := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if key, value, ok := strings.Cut(line, "="); ok && !strings.HasPrefix(line, "#") {
+			options[key] = value
+		}
+	}
+	if addr, err := netip.ParseAddr(options["ADDRESS"]); err != nil || addr != ip {
+		return Lease{}, false
+	}
+	l := Lease{
+		Server:  leaseAddr(options["SERVER_ADDRESS"]),
+		Router:  leaseAddr(options["ROUTER"]),
+		DNS:     leaseAddrs(options["DNS"]),
+		Domain:  options["DOMAINNAME"],
+		Options: options,
+	}
+	if info, err := file.Stat(); err == nil {
+		l.Obtained = info.ModTime()
+	}
+	if s, err := strconv.ParseUint(options["LIFETIME"], 10, 32); err == nil {
+		l.Duration = time.Duration(s) * time.Second
+		l.Expires = l.Obtained.Add(l.Duration)
+	}
+	if s, err := strconv.ParseUint(options["T1"], 10, 32); err == nil {
+		l.Renews = l.Obtained.Add(time.Duration(s) * time.Second)
+	}
+	return l, true
+}
+
+// readDhclientLease returns the last lease of ip on the interface in a dhclient lease
+// file, which dhclient appends to on every renewal:
+//
+//	lease {
+//	  interface "eth0";
+//	  fixed-address 192.168.1.23;
+//	  option dhcp-lease-time 86400;
+//	  option dhcp-server-identifier 192.168.1.1;
+//	  renew 3 2026/10/14 02:00:00;
+//	  expire 3 2026/10/14 09:00:00;
+//	}
+func readDhclientLease(name, iface string, ip netip.Addr) (Lease, bool) {
+	file, err := os.Open(name)
+	if err != nil {
+		return Lease{}, false
+	}
+	defer file.Close()
+	var last, l Lease
+	var found, ifaceOK, addrOK bool
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), "; #") // epoch times are followed by the date
+		line = strings.TrimSuffix(line, ";")
+		switch {
+		case line == "lease {":
+			l, ifaceOK, addrOK = Lease{Options: make(map[string]string)}, false, false
+			continue
+		case line == "}":
+			if ifaceOK && addrOK {
+				last, found = l, true
+			}
+			continue
+		}
+		if l.Options == nil {
+			continue // outside of a lease
+		}
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "interface":
+			ifaceOK = strings.Trim(value, `"`) == iface
+		case "fixed-address":
+			addr, err := netip.ParseAddr(value)
+			addrOK = err == nil && addr == ip
+		case "option":
+			opt, v, _ := strings.Cut(value, " ")
+			v = strings.Trim(v, `"`)
+			l.Options[opt] = v
+			switch opt {
+			case "dhcp-server-identifier":
+				l.Server = leaseAddr(v)
+			case "routers":
+				l.Router = leaseAddr(v)
+			case "domain-name-servers":
+				l.DNS = leaseAddrs(v)
+			case "domain-name":
+				l.Domain = v
+			case "dhcp-lease-time":
+				if s, err := strconv.ParseUint(v, 10, 32); err == nil {
+					l.Duration = time.Duration(s) * time.Second
+				}
+			}
+		case "renew":
+			l.Renews = dhclientTime(value)
+		case "expire":
+			l.Expires = dhclientTime(value)
+		}
+	}
+	if found && !last.Expires.IsZero() && last.Duration > 0 {
+		last.Obtained = last.Expires.Add(-last.Duration)
+	}
+	return last, found
+}
+
+// dhclientTime parses a time of a dhclient lease, "3 2026/10/14 09:00:00" in UTC, or
+// "epoch 1791968400" with db-time-format local. "never" and anything else are zero.
+func dhclientTime(s string) time.Time {
+	fields := strings.Fields(s)
+	switch {
+	case len(fields) >= 2 && fields[0] == "epoch":
+		if sec, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+			return time.Unix(sec, 0)
+		}
+	case len(fields) >= 3:
+		if t, err := time.Parse("2006/01/02 15:04:05", fields[1]+" "+fields[2]); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// leaseAddr parses the first address of a lease value, which are separated by spaces
+// or commas.
+func leaseAddr(s string) netip.Addr {
+	if addrs := leaseAddrs(s); len(addrs) > 0 {
+		return addrs[0]
+	}
+	return netip.Addr{}
+}
+
+// leaseAddrs parses the addresses of a lease value.
+func leaseAddrs(s string) []netip.Addr {
+	var addrs []netip.Addr
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' }) {
+		if addr, err := netip.ParseAddr(f); err == nil {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !windows
+
+package localaddr
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+// readLease is not implemented on this platform.
+func readLease(string, int, netip.Addr) (Lease, error) {
+	return Lease{}, fmt.Errorf("reading the DHCP lease: %w", errors.ErrUnsupported)
+}
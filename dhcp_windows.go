@@ -0,0 +1,63 @@
+package localaddr
+
+import (
+	"net/netip"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// readLease reads the lease of the adapter with GetAdaptersInfo, which tells the server
+// and times of DHCP leases, and its DNS servers with GetAdaptersAddresses.
+func readLease(name string, index int, ip netip.Addr) (Lease, error) {
+	size := uint32(15 << 10)
+	var buf []byte
+	for {
+		buf = make([]byte, size)
+		err := windows.GetAdaptersInfo((*windows.IpAdapterInfo)(unsafe.Pointer(&buf[0])), &size)
+		if err == nil {
+			break
+		}
+		if err != windows.ERROR_BUFFER_OVERFLOW || size <= uint32(len(buf)) {
+			return Lease{}, err
+		}
+	}
+	for ai := (*windows.IpAdapterInfo)(unsafe.Pointer(&buf[0])); ai != nil; ai = ai.Next {
+		if int(ai.Index) != index {
+			continue
+		}
+		if ai.DhcpEnabled == 0 || ai.LeaseExpires == 0 {
+			return Lease{}, ErrNoLease
+		}
+		l := Lease{
+			Server:   ipString(&ai.DhcpServer),
+			Router:   ipString(&ai.GatewayList),
+			Obtained: time.Unix(ai.LeaseObtained, 0),
+			Expires:  time.Unix(ai.LeaseExpires, 0),
+		}
+		l.Duration = l.Expires.Sub(l.Obtained)
+		l.Renews = l.Obtained.Add(l.Duration / 2) // T1 by RFC 2131; Windows does not tell
+		servers, _ := readDNSServers()
+		for _, s := range servers {
+			if s.Interface == name && s.Addr.Is4() {
+				l.DNS = append(l.DNS, s.Addr)
+			}
+		}
+		return l, nil
+	}
+	return Lease{}, ErrNoLease
+}
+
+// ipString parses the first address of an IP_ADDR_STRING list, in dotted decimal.
+func ipString(s *windows.IpAddrString) netip.Addr {
+	b := s.IpAddress.String[:]
+	for i, c := range b {
+		if c == 0 {
+			b = b[:i]
+			break
+		}
+	}
+	addr, _ := netip.ParseAddr(string(b))
+	return addr
+}
@@ -0,0 +1,277 @@
+// Package discovery finds the other processes of an application on the local network.
+// Every process runs a Beacon, which multicasts a small announcement on the subnet of
+// the local address at a fixed interval and lists the peers whose announcements it hears.
+//
+// It is meant for LAN-only tools that need to find each other without configuration,
+// such as a chat between the machines of a household; anyone on the subnet can send
+// beacons, so treat what peers announce as untrusted.
+package discovery
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/golovingreg/localaddr"
+	"github.com/golovingreg/localaddr/internal/mcast"
+)
+
+// DefaultInterval is the time between beacons if Beacon.Interval is zero. Peers that
+// have not been heard from for three intervals are dropped.
+const DefaultInterval = 5 * time.Second
+
+// Multicast groups used if Beacon.Group is zero, for IPv4 and IPv6 local addresses.
+// Both are scoped to the local network.
+var (
+	DefaultGroup  = netip.MustParseAddrPort("239.255.70.70:47070")
+	DefaultGroup6 = netip.MustParseAddrPort("[ff02::4c41]:47070")
+)
+
+// Peer is another process of the application.
+type Peer struct {
+	ID       string            // random identifier of the peer's Beacon, unique per Run
+	Addr     netip.Addr        // local address of the peer
+	Hostname string            // host name of the peer's machine
+	Meta     map[string]string // Beacon.Meta of the peer
+	LastSeen time.Time         // when its last beacon arrived
+}
+
+// Beacon announces the process and discovers its peers.
+type Beacon struct {
+	App      string             // name of the application; beacons of other applications are ignored
+	Meta     map[string]string  // sent along with the beacon, e.g. {"port": "8080"}
+	Group    netip.AddrPort     // multicast group and port, DefaultGroup or DefaultGroup6 if zero
+	Interval time.Duration      // time between beacons, DefaultInterval if zero
+	Options  []localaddr.Option // selection of the local address, as for localaddr.Get
+	OnError  func(error)        // called with errors that do not stop the beacon, may be nil
+
+	mu    sync.Mutex
+	id    string
+	peers map[string]Peer // by ID
+}
+
+// message is the JSON payload of a beacon.
+type message struct {
+	App  string            `json:"app"`
+	ID   string            `json:"id"`
+	Host string            `json:"host"`
+	Addr netip.Addr        `json:"addr"`
+	Meta map[string]string `json:"meta,omitempty"`
+	Bye  bool              `json:"bye,omitempty"` // sent when the beacon stops
+}
+
+// received is a beacon read from conn.
+type received struct {
+	conn *net.UDPConn
+	msg  message
+	from netip.Addr
+}
+
+// Run sends beacons and collects those of peers until ctx is done. On the way out, it
+// tells the peers that it leaves, so they drop it right away.
+//
+// The beacon follows the local address: at every interval, it selects the address
+// again and moves to its interface if it changed. A new peer is answered with a beacon
+// right away, so processes find each other without waiting for the interval.
+//
+// Returns:
+//   - error: An error if App is empty, no address is selected, or listening fails; nil once ctx is done
+func (b *Beacon) Run(ctx context.Context) error {
+	if b.App == "" {
+		return errors.New("discovery: no application name")
+	}
+	interval := b.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	host, _ := os.Hostname()
+	var id [8]byte
+	rand.Read(id[:])
+	b.mu.Lock()
+	b.id, b.peers = hex.EncodeToString(id[:]), map[string]Peer{}
+	b.mu.Unlock()
+
+	s := &sender{b: b, id: b.id, host: host, packets: make(chan received)}
+	addr, err := localaddr.GetDetailed(b.Options...)
+	if err != nil {
+		return err
+	}
+	if err := s.move(ctx, addr); err != nil {
+		return err
+	}
+	defer func() { s.leave() }()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			b.expire(time.Now().Add(-3 * interval))
+			addr, err := localaddr.GetDetailed(b.Options...)
+			if err != nil {
+				s.leave() // disconnected, try again at the next interval
+				continue
+			}
+			if s.conn == nil || addr.Index != s.iface.Index || addr.IP.WithZone("") != s.addr {
+				s.leave()
+				if err := s.move(ctx, addr); err != nil {
+					b.report(err)
+				}
+				continue
+			}
+			s.send(false)
+		case p := <-s.packets:
+			if p.conn == s.conn && b.heard(p) {
+				s.send(false)
+			}
+		}
+	}
+}
+
+// Peers returns the peers heard from recently, ordered by address.
+func (b *Beacon) Peers() []Peer {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	peers := make([]Peer, 0, len(b.peers))
+	for _, p := range b.peers {
+		peers = append(peers, p)
+	}
+	slices.SortFunc(peers, func(a, b Peer) int {
+		if c := a.Addr.Compare(b.Addr); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return peers
+}
+
+// heard records the beacon p and reports whether it came from a new peer.
+func (b *Beacon) heard(p received) bool {
+	m := p.msg
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if m.App != b.App || m.ID == "" || m.ID == b.id {
+		return false
+	}
+	if m.Bye {
+		delete(b.peers, m.ID)
+		return false
+	}
+	_, known := b.peers[m.ID]
+	addr := m.Addr
+	if !addr.IsValid() {
+		addr = p.from
+	}
+	b.peers[m.ID] = Peer{ID: m.ID, Addr: addr, Hostname: m.Host, Meta: m.Meta, LastSeen: time.Now()}
+	return !known
+}
+
+// expire drops the peers last heard from before deadline.
+func (b *Beacon) expire(deadline time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for id, p := range b.peers {
+		if p.LastSeen.Before(deadline) {
+			delete(b.peers, id)
+		}
+	}
+}
+
+// report passes err to OnError, if set.
+func (b *Beacon) report(err error) {
+	if b.OnError != nil {
+		b.OnError(err)
+	}
+}
+
+// sender sends the beacons of b on one interface at a time.
+type sender struct {
+	b       *Beacon
+	id      string
+	host    string
+	packets chan received
+
+	addr  netip.Addr
+	iface *net.Interface
+	group netip.AddrPort
+	conn  *net.UDPConn // nil while disconnected
+}
+
+// move starts listening and sending on the interface of addr.
+func (s *sender) move(ctx context.Context, addr localaddr.Address) error {
+	iface, err := net.InterfaceByIndex(addr.Index)
+	if err != nil {
+		return err
+	}
+	group := s.b.Group
+	if !group.IsValid() {
+		group = DefaultGroup
+		if addr.IP.Is6() {
+			group = DefaultGroup6
+		}
+	}
+	if !group.Addr().IsMulticast() {
+		return fmt.Errorf("discovery: %s is not a multicast group", group.Addr())
+	}
+	conn, err := mcast.Listen(iface, group)
+	if err != nil {
+		return fmt.Errorf("discovery: listening on %s: %w", iface.Name, err)
+	}
+	s.addr, s.iface, s.group, s.conn = addr.IP.WithZone(""), iface, group, conn
+	go s.read(ctx, conn)
+	s.send(false)
+	return nil
+}
+
+// leave says goodbye to the peers and stops listening.
+func (s *sender) leave() {
+	if s.conn == nil {
+		return
+	}
+	s.send(true)
+	s.conn.Close()
+	s.conn = nil
+}
+
+// send multicasts a beacon, or a goodbye if bye is set.
+func (s *sender) send(bye bool) {
+	data, err := json.Marshal(message{App: s.b.App, ID: s.id, Host: s.host, Addr: s.addr, Meta: s.b.Meta, Bye: bye})
+	if err == nil {
+		_, err = s.conn.WriteToUDP(data, mcast.Dest(s.iface, s.group))
+	}
+	if err != nil && !errors.Is(err, net.ErrClosed) {
+		s.b.report(fmt.Errorf("discovery: sending on %s: %w", s.iface.Name, err))
+	}
+}
+
+// read passes the beacons received on conn to Run, until conn is closed.
+func (s *sender) read(ctx context.Context, conn *net.UDPConn) {
+	buf := make([]byte, 9000)
+	for {
+		n, from, err := conn.ReadFromUDPAddrPort(buf)
+		if err != nil {
+			return
+		}
+		var m message
+		if json.Unmarshal(buf[:n], &m) != nil {
+			continue // not a beacon
+		}
+		select {
+		case s.packets <- received{conn, m, from.Addr().Unmap()}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package localaddr
+
+import (
+	"bufio"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// DNSServer is a resolver the system is configured to use.
+type DNSServer struct {
+	Addr      netip.Addr `json:"address"`
+	Interface string     `json:"interface,omitempty"` // interface the server was configured for, "" if it applies to all
+}
+
+// DNSServers returns the DNS servers the system resolves names with, in the order the
+// system asks them.
+//
+// They are read from /etc/resolv.conf, or from the per-link state of systemd-resolved
+// when resolv.conf points to its local stub on Linux, from "scutil --dns" on macOS, and
+// with GetAdaptersAddresses on Windows. The interface of a server is known on Linux with
+// systemd-resolved, on macOS, and on Windows; elsewhere it is empty. Resolvers that
+// macOS only uses for particular domains, such as mDNS for .local, are left out.
+//
+// Returns:
+//   - []DNSServer: The servers (e.g., "192.168.1.1" on "eth0"), possibly none
+//   - error: An error if the configuration cannot be read
+func DNSServers() ([]DNSServer, error) {
+	servers, err := readDNSServers()
+	if err != nil {
+		return nil, err
+	}
+	return dedupeDNS(servers), nil
+}
+
+// dedupeDNS drops repeated servers, and servers without an interface that are also
+// listed with one.
+func dedupeDNS(servers []DNSServer) []DNSServer {
+	scoped := make(map[netip.Addr]bool)
+	for _, s := range servers {
+		if s.Interface != "" {
+			scoped[s.Addr] = true
+		}
+	}
+	seen := make(map[DNSServer]bool)
+	out := servers[:0]
+	for _, s := range servers {
+		if seen[s] || s.Interface == "" && scoped[s.Addr] {
+			continue
+		}
+		seen[s] = true
+		out = append(out, s)
+	}
+	return out
+}
+
+// readResolvConf returns the nameservers listed in a resolv.conf file.
+func readResolvConf(name string) ([]DNSServer, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var servers []DNSServer
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+		if addr, ok := parseDNSAddr(fields[1]); ok {
+			servers = append(servers, DNSServer{Addr: addr})
+		}
+	}
+	return servers, scanner.Err()
+}
+
+// parseDNSAddr parses a server address as configuration files write it: a plain
+// address, possibly with a zone, or with a port and a "#name" for DNS over TLS.
+func parseDNSAddr(s string) (netip.Addr, bool) {
+	s, _, _ = strings.Cut(s, "#")
+	if addr, err := netip.ParseAddr(s); err == nil {
+		return addr.WithZone(""), true
+	}
+	if ap, err := netip.ParseAddrPort(s); err == nil {
+		return ap.Addr().WithZone(""), true
+	}
+	s, _, _ = strings.Cut(s, "%")
+	addr, err := netip.ParseAddr(s)
+	return addr, err == nil
+}
@@ -0,0 +1,69 @@
+package localaddr
+
+import (
+	"bufio"
+	"bytes"
+	"os/exec"
+	"strings"
+)
+
+// readDNSServers parses the resolvers of "scutil --dns", which unlike /etc/resolv.conf
+// tell the interface of each server. It falls back to resolv.conf if scutil fails.
+func readDNSServers() ([]DNSServer, error) {
+	out, err := exec.Command("/usr/sbin/scutil", "--dns").Output()
+	if err != nil {
+		return readResolvConf("/etc/resolv.conf")
+	}
+	return parseScutil(out), nil
+}
+
+// parseScutil parses the output of "scutil --dns":
+//
+//	resolver #1
+//	  nameserver[0] : 192.168.1.1
+//	  if_index : 6 (en0)
+//
+// Resolvers with a "domain" line answer only for that domain and are skipped.
+func parseScutil(out []byte) []DNSServer {
+	var servers []DNSServer
+	var block []DNSServer
+	supplemental := false
+	var iface string
+	flush := func() {
+		if !supplemental {
+			for _, s := range block {
+				s.Interface = iface
+				servers = append(servers, s)
+			}
+		}
+		block, supplemental, iface = nil, false, ""
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "resolver #") || strings.HasPrefix(line, "DNS configuration") {
+			flush()
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch {
+		case key == "domain":
+			supplemental = true
+		case strings.HasPrefix(key, "nameserver["):
+			if addr, ok := parseDNSAddr(value); ok {
+				block = append(block, DNSServer{Addr: addr})
+			}
+		case key == "if_index":
+			// "6 (en0)"
+			if open := strings.IndexByte(value, '('); open >= 0 && strings.HasSuffix(value, ")") {
+				iface = value[open+1 : len(value)-1]
+			}
+		}
+	}
+	flush()
+	return servers
+}
@@ -0,0 +1,83 @@
+package localaddr
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// resolvedLinks is where systemd-resolved keeps the state of each link, in files named
+// by interface index.
+const resolvedLinks = "/run/systemd/resolve/netif"
+
+// readDNSServers reads /etc/resolv.conf. If it only names a local stub, as with
+// systemd-resolved, the servers the stub forwards to are read from resolved's state.
+func readDNSServers() ([]DNSServer, error) {
+	servers, err := readResolvConf("/etc/resolv.conf")
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 || !onlyLoopback(servers) {
+		return servers, nil
+	}
+	if linked := readResolvedLinks(resolvedLinks); len(linked) > 0 {
+		return linked, nil
+	}
+	if upstream, err := readResolvConf("/run/systemd/resolve/resolv.conf"); err == nil && len(upstream) > 0 {
+		return upstream, nil
+	}
+	return servers, nil
+}
+
+// onlyLoopback reports whether all servers are on the loopback address.
+func onlyLoopback(servers []DNSServer) bool {
+	for _, s := range servers {
+		if !s.Addr.IsLoopback() {
+			return false
+		}
+	}
+	return true
+}
+
+// readResolvedLinks parses the SERVERS= lines of the link state files in dir.
+func readResolvedLinks(dir string) []DNSServer {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var indexes []int
+	for _, e := range entries {
+		if index, err := strconv.Atoi(e.Name()); err == nil {
+			indexes = append(indexes, index)
+		}
+	}
+	sort.Ints(indexes)
+	var servers []DNSServer
+	for _, index := range indexes {
+		name := ifaceName(index)
+		if name == "" {
+			continue // a stale file
+		}
+		file, err := os.Open(filepath.Join(dir, strconv.Itoa(index)))
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			value, ok := strings.CutPrefix(scanner.Text(), "SERVERS=")
+			if !ok {
+				continue
+			}
+			for _, field := range strings.Fields(value) {
+				if addr, ok := parseDNSAddr(field); ok {
+					servers = append(servers, DNSServer{Addr: addr, Interface: name})
+				}
+			}
+		}
+		file.Close()
+	}
+	return servers
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !darwin && !windows
+
+package localaddr
+
+// readDNSServers reads /etc/resolv.conf, which does not tell interfaces.
+func readDNSServers() ([]DNSServer, error) {
+	return readResolvConf("/etc/resolv.conf")
+}
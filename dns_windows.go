@@ -0,0 +1,43 @@
+package localaddr
+
+import (
+	"net/netip"
+
+	"golang.org/x/sys/windows"
+)
+
+// siteLocal6 holds fec0:0:0:ffff::1 to ::3, which Windows lists as DNS servers of
+// adapters that got none for IPv6. They were deprecated with site-local addresses.
+var siteLocal6 = netip.MustParsePrefix("fec0::/10")
+
+// readDNSServers lists the DNS servers of the adapters that are up, from
+// GetAdaptersAddresses.
+func readDNSServers() ([]DNSServer, error) {
+	list, err := adapters(windows.GAA_FLAG_SKIP_UNICAST | windows.GAA_FLAG_SKIP_ANYCAST | windows.GAA_FLAG_SKIP_MULTICAST)
+	if err != nil {
+		return nil, err
+	}
+	var servers []DNSServer
+	for aa := list; aa != nil; aa = aa.Next {
+		if aa.OperStatus != windows.IfOperStatusUp {
+			continue
+		}
+		index := int(aa.IfIndex)
+		if index == 0 {
+			index = int(aa.Ipv6IfIndex)
+		}
+		name := ifaceName(index)
+		for ds := aa.FirstDnsServerAddress; ds != nil; ds = ds.Next {
+			addr, ok := netip.AddrFromSlice(ds.Address.IP())
+			if !ok {
+				continue
+			}
+			addr = addr.Unmap().WithZone("")
+			if siteLocal6.Contains(addr) {
+				continue
+			}
+			servers = append(servers, DNSServer{Addr: addr, Interface: name})
+		}
+	}
+	return servers, nil
+}
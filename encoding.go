@@ -0,0 +1,458 @@
+package localaddr
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+// MarshalText returns the name of k, as String does.
+func (k Kind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText parses a name as returned by String.
+func (k *Kind) UnmarshalText(text []byte) error {
+	i, err := parseName(kindNames[:], string(text), "interface kind")
+	if err != nil {
+		return err
+	}
+	*k = Kind(i)
+	return nil
+}
+
+// MarshalText returns the name of c, as String does.
+func (c Class) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText parses a name as returned by String.
+func (c *Class) UnmarshalText(text []byte) error {
+	i, err := parseName(classNames[:], string(text), "address class")
+	if err != nil {
+		return err
+	}
+	*c = Class(i)
+	return nil
+}
+
+// MarshalText returns the name of p, as String does. The zero Preference has none.
+func (p Preference) MarshalText() ([]byte, error) {
+	if p < Allow || p > Exclude {
+		return nil, fmt.Errorf("invalid preference %d", int(p))
+	}
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText parses a name as returned by String.
+func (p *Preference) UnmarshalText(text []byte) error {
+	i, err := parseName(preferenceNames[:], string(text), "preference")
+	if err != nil {
+		return err
+	}
+	*p = Preference(i)
+	return nil
+}
+
+// MarshalText returns the name of f, as String does.
+func (f Family) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// UnmarshalText parses a name as returned by String.
+func (f *Family) UnmarshalText(text []byte) error {
+	i, err := parseName(familyNames[:], string(text), "address family")
+	if err != nil {
+		return err
+	}
+	*f = Family(i)
+	return nil
+}
+
+// MarshalText returns the name of s, as String does.
+func (s Stability) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText parses a name as returned by String.
+func (s *Stability) UnmarshalText(text []byte) error {
+	i, err := parseName(stabilityNames[:], string(text), "stability")
+	if err != nil {
+		return err
+	}
+	*s = Stability(i)
+	return nil
+}
+
+// MarshalText returns the name of s, as String does.
+func (s IPv6Scope) MarshalText() ([]byte, error) {
+	if s < ScopeAny || s > ScopeGlobal {
+		return nil, fmt.Errorf("invalid IPv6 scope %d", int(s))
+	}
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText parses a name as returned by String.
+func (s *IPv6Scope) UnmarshalText(text []byte) error {
+	i, err := parseName(scopeNames[:], string(text), "IPv6 scope")
+	if err != nil {
+		return err
+	}
+	*s = IPv6Scope(i)
+	return nil
+}
+
+// MarshalText returns the name of s, as String does.
+func (s NeighborState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText parses a name as returned by String.
+func (s *NeighborState) UnmarshalText(text []byte) error {
+	i, err := parseName(neighborStateNames[:], string(text), "neighbor state")
+	if err != nil {
+		return err
+	}
+	*s = NeighborState(i)
+	return nil
+}
+
+// MarshalText returns the name of s, as String does.
+func (s Status) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText parses a name as returned by String.
+func (s *Status) UnmarshalText(text []byte) error {
+	i, err := parseName(statusNames[:], string(text), "connectivity status")
+	if err != nil {
+		return err
+	}
+	*s = Status(i)
+	return nil
+}
+
+// parseName returns the index of name in names.
+func parseName(names []string, name, what string) (int, error) {
+	for i, n := range names {
+		if n != "" && strings.EqualFold(n, name) {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown %s %q", what, name)
+}
+
+// addressJSON is the JSON form of Address. Its field names are part of the API.
+type addressJSON struct {
+	IP        netip.Addr   `json:"address"`
+	Prefix    netip.Prefix `json:"prefix"`
+	Interface string       `json:"interface"`
+	Adapter   string       `json:"adapter,omitempty"`
+	Index     int          `json:"index"`
+	MAC       string       `json:"mac,omitempty"`
+	Flags     []string     `json:"flags"`
+	Kind      Kind         `json:"kind"`
+	MTU       int          `json:"mtu,omitempty"`
+	Speed     uint64       `json:"speed,omitempty"`
+	Stability Stability    `json:"stability,omitempty"`
+}
+
+// MarshalJSON encodes a as an object with the lower case fields address, prefix,
+// interface, adapter (omitted if empty), index, mac (omitted if empty), flags (a list
+// such as ["up", "running"]), kind, mtu, speed (in bits per second, omitted if unknown),
+// and stability (omitted if unknown).
+func (a Address) MarshalJSON() ([]byte, error) {
+	v := addressJSON{IP: a.IP, Prefix: a.Prefix, Interface: a.Interface, Adapter: a.Adapter, Index: a.Index, Flags: flagNames(a.Flags), Kind: a.Kind, MTU: a.MTU, Speed: a.Speed, Stability: a.Stability}
+	if len(a.MAC) > 0 {
+		v.MAC = a.MAC.String()
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes the form written by MarshalJSON.
+func (a *Address) UnmarshalJSON(data []byte) error {
+	var v addressJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	var mac net.HardwareAddr
+	if v.MAC != "" {
+		var err error
+		if mac, err = net.ParseMAC(v.MAC); err != nil {
+			return err
+		}
+	}
+	flags, err := parseFlags(v.Flags)
+	if err != nil {
+		return err
+	}
+	*a = Address{IP: v.IP, Prefix: v.Prefix, Interface: v.Interface, Adapter: v.Adapter, Index: v.Index, MAC: mac, Flags: flags, Kind: v.Kind, MTU: v.MTU, Speed: v.Speed, Stability: v.Stability}
+	return nil
+}
+
+// MarshalText returns a.IP as text, with a zone for link-local IPv6 addresses, for text
+// based encodings and logs where the interface details would be noise.
+func (a Address) MarshalText() ([]byte, error) {
+	return a.IP.MarshalText()
+}
+
+// UnmarshalText parses an IP address into a. The interface details are not part of the
+// text form and are left empty.
+func (a *Address) UnmarshalText(text []byte) error {
+	var ip netip.Addr
+	if err := ip.UnmarshalText(text); err != nil {
+		return err
+	}
+	*a = Address{IP: ip}
+	return nil
+}
+
+// scoredJSON is the JSON form of Scored. Its field names are part of the API.
+type scoredJSON struct {
+	Addr      netip.Addr `json:"address"`
+	Interface string     `json:"interface"`
+	Kind      Kind       `json:"kind"`
+	Class     Class      `json:"class"`
+	Stability Stability  `json:"stability"`
+	Score     int        `json:"score"`
+	Reasons   []string   `json:"reasons"`
+}
+
+// MarshalJSON encodes s as an object with the lower case fields address, interface,
+// kind, class, stability, score, and reasons.
+func (s Scored) MarshalJSON() ([]byte, error) {
+	v := scoredJSON(s)
+	if v.Reasons == nil {
+		v.Reasons = []string{}
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes the form written by MarshalJSON.
+func (s *Scored) UnmarshalJSON(data []byte) error {
+	var v scoredJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*s = Scored(v)
+	return nil
+}
+
+// choiceJSON is the JSON form of Choice. Its field names are part of the API.
+type choiceJSON struct {
+	IPv4 *Address `json:"ipv4,omitempty"`
+	IPv6 *Address `json:"ipv6,omitempty"`
+}
+
+// MarshalJSON encodes c as an object with the fields ipv4 and ipv6, each an Address
+// and omitted if none was selected.
+func (c Choice) MarshalJSON() ([]byte, error) {
+	var v choiceJSON
+	if c.IPv4.IP.IsValid() {
+		v.IPv4 = &c.IPv4
+	}
+	if c.IPv6.IP.IsValid() {
+		v.IPv6 = &c.IPv6
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes the form written by MarshalJSON.
+func (c *Choice) UnmarshalJSON(data []byte) error {
+	var v choiceJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*c = Choice{}
+	if v.IPv4 != nil {
+		c.IPv4 = *v.IPv4
+	}
+	if v.IPv6 != nil {
+		c.IPv6 = *v.IPv6
+	}
+	return nil
+}
+
+// proxySettingsJSON is the JSON form of ProxySettings. Its field names are part of the
+// API.
+type proxySettingsJSON struct {
+	HTTP       string   `json:"http,omitempty"`
+	HTTPS      string   `json:"https,omitempty"`
+	SOCKS      string   `json:"socks,omitempty"`
+	NoProxy    []string `json:"no_proxy,omitempty"`
+	AutoConfig string   `json:"auto_config,omitempty"`
+	Source     string   `json:"source,omitempty"`
+}
+
+// MarshalJSON encodes p as an object with the fields http, https, socks, and
+// auto_config (the URLs as strings), no_proxy, and source, each omitted if not set.
+func (p ProxySettings) MarshalJSON() ([]byte, error) {
+	str := func(u *url.URL) string {
+		if u == nil {
+			return ""
+		}
+		return u.String()
+	}
+	return json.Marshal(proxySettingsJSON{HTTP: str(p.HTTP), HTTPS: str(p.HTTPS), SOCKS: str(p.SOCKS),
+		NoProxy: p.NoProxy, AutoConfig: str(p.AutoConfig), Source: p.Source})
+}
+
+// UnmarshalJSON decodes the form written by MarshalJSON.
+func (p *ProxySettings) UnmarshalJSON(data []byte) error {
+	var v proxySettingsJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	parse := func(s string) (*url.URL, error) {
+		if s == "" {
+			return nil, nil
+		}
+		return url.Parse(s)
+	}
+	*p = ProxySettings{NoProxy: v.NoProxy, Source: v.Source}
+	var err error
+	for _, f := range []struct {
+		dst **url.URL
+		s   string
+	}{{&p.HTTP, v.HTTP}, {&p.HTTPS, v.HTTPS}, {&p.SOCKS, v.SOCKS}, {&p.AutoConfig, v.AutoConfig}} {
+		if *f.dst, err = parse(f.s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// neighborJSON is the JSON form of Neighbor. Its field names are part of the API.
+type neighborJSON struct {
+	IP        netip.Addr    `json:"address"`
+	MAC       string        `json:"mac,omitempty"`
+	Interface string        `json:"interface"`
+	State     NeighborState `json:"state"`
+}
+
+// MarshalJSON encodes n as an object with the lower case fields address, mac (omitted
+// if empty, e.g. "00:1a:2b:3c:4d:5e"), interface, and state.
+func (n Neighbor) MarshalJSON() ([]byte, error) {
+	v := neighborJSON{IP: n.IP, Interface: n.Interface, State: n.State}
+	if len(n.MAC) > 0 {
+		v.MAC = n.MAC.String()
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes the form written by MarshalJSON.
+func (n *Neighbor) UnmarshalJSON(data []byte) error {
+	var v neighborJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	var mac net.HardwareAddr
+	if v.MAC != "" {
+		var err error
+		if mac, err = net.ParseMAC(v.MAC); err != nil {
+			return err
+		}
+	}
+	*n = Neighbor{IP: v.IP, MAC: mac, Interface: v.Interface, State: v.State}
+	return nil
+}
+
+// flagBits maps the names that net.Flags.String uses to their flags.
+var flagBits = func() map[string]net.Flags {
+	m := make(map[string]net.Flags)
+	for i := 0; i < 32; i++ {
+		if f := net.Flags(1) << i; f.String() != "0" {
+			m[f.String()] = f
+		}
+	}
+	return m
+}()
+
+// flagNames splits f into the names of its flags, e.g. ["up", "running"].
+func flagNames(f net.Flags) []string {
+	if f == 0 {
+		return []string{}
+	}
+	return strings.Split(f.String(), "|")
+}
+
+// parseFlags is the inverse of flagNames.
+func parseFlags(names []string) (net.Flags, error) {
+	var f net.Flags
+	for _, name := range names {
+		bit, ok := flagBits[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown interface flag %q", name)
+		}
+		f |= bit
+	}
+	return f, nil
+}
+
+// interfaceReportJSON is the JSON form of InterfaceReport. Its field names are part of
+// the API.
+type interfaceReportJSON struct {
+	Name      string             `json:"name"`
+	Adapter   string             `json:"adapter,omitempty"`
+	Index     int                `json:"index"`
+	MAC       string             `json:"mac,omitempty"`
+	Flags     []string           `json:"flags"`
+	MTU       int                `json:"mtu"`
+	Kind      Kind               `json:"kind"`
+	Virtual   bool               `json:"virtual"`
+	Carrier   bool               `json:"carrier"`
+	Addresses []reportedAddrJSON `json:"addresses"`
+}
+
+// reportedAddrJSON is the JSON form of ReportedAddr. Its field names are part of the API.
+type reportedAddrJSON struct {
+	IP        netip.Addr   `json:"address"`
+	Prefix    netip.Prefix `json:"prefix"`
+	Class     Class        `json:"class"`
+	Temporary bool         `json:"temporary,omitempty"`
+	Secondary bool         `json:"secondary,omitempty"`
+}
+
+// MarshalJSON encodes r as an object with the lower case fields name, adapter (omitted
+// if empty), index, mac (omitted if empty), flags, mtu, kind, virtual, carrier, and
+// addresses, a list of objects with the fields address, prefix, class, and temporary
+// and secondary (omitted if false).
+func (r InterfaceReport) MarshalJSON() ([]byte, error) {
+	v := interfaceReportJSON{Name: r.Name, Adapter: r.Adapter, Index: r.Index, Flags: flagNames(r.Flags), MTU: r.MTU, Kind: r.Kind, Virtual: r.Virtual, Carrier: r.Carrier}
+	if len(r.MAC) > 0 {
+		v.MAC = r.MAC.String()
+	}
+	v.Addresses = make([]reportedAddrJSON, len(r.Addresses))
+	for i, a := range r.Addresses {
+		v.Addresses[i] = reportedAddrJSON(a)
+	}
+	return json.Marshal(v)
+}
+
+// UnmarshalJSON decodes the form written by MarshalJSON.
+func (r *InterfaceReport) UnmarshalJSON(data []byte) error {
+	var v interfaceReportJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	var mac net.HardwareAddr
+	if v.MAC != "" {
+		var err error
+		if mac, err = net.ParseMAC(v.MAC); err != nil {
+			return err
+		}
+	}
+	flags, err := parseFlags(v.Flags)
+	if err != nil {
+		return err
+	}
+	addrs := make([]ReportedAddr, len(v.Addresses))
+	for i, a := range v.Addresses {
+		addrs[i] = ReportedAddr(a)
+	}
+	*r = InterfaceReport{Name: v.Name, Adapter: v.Adapter, Index: v.Index, MAC: mac, Flags: flags, MTU: v.MTU, Kind: v.Kind, Virtual: v.Virtual, Carrier: v.Carrier, Addresses: addrs}
+	return nil
+}
@@ -0,0 +1,65 @@
+package localaddr
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// Environment variables that override the selection, for containers and CI machines
+// where detection picks the wrong address and the code cannot be changed.
+const (
+	// EnvOverride holds addresses, separated by commas, that the getters return instead
+	// of detecting any, e.g. "10.1.2.3" or "10.1.2.3,2001:db8::3". They are returned
+	// without interface details. Getters of a single family, such as GetIPv6, still
+	// detect an address if the list has none of that family.
+	EnvOverride = "LOCALADDR_OVERRIDE"
+	// EnvInterface names the interface to select from, replacing WithInterface and
+	// WithDefaultRoute, e.g. "eth1".
+	EnvInterface = "LOCALADDR_INTERFACE"
+)
+
+// WithIgnoreEnv makes the getters ignore EnvOverride and EnvInterface, as well as the pod
+// IP variables of WithKubernetes, e.g. in a library that must see the real addresses, or
+// in tests.
+func WithIgnoreEnv() Option {
+	return func(c *config) {
+		c.ignoreEnv = true
+	}
+}
+
+// applyEnv reads the environment variables into c, after the options.
+func (c *config) applyEnv() {
+	if c.ignoreEnv {
+		return
+	}
+	if name := os.Getenv(EnvInterface); name != "" {
+		c.iface, c.defaultRoute = name, false
+	}
+	list := os.Getenv(EnvOverride)
+	if list == "" {
+		return
+	}
+	for _, s := range strings.Split(list, ",") {
+		ip, err := netip.ParseAddr(strings.TrimSpace(s))
+		if err != nil {
+			c.setErr(fmt.Errorf("invalid %s: %w", EnvOverride, err))
+			return
+		}
+		c.override = append(c.override, ip.Unmap())
+	}
+	c.overrideEnv = EnvOverride
+}
+
+// overridden returns the EnvOverride addresses of the family that c looks for.
+func (c *config) overridden() []candidate {
+	var found []candidate
+	for _, ip := range c.override {
+		if c.family == ipv4 && !ip.Is4() || c.family == ipv6 && !ip.Is6() {
+			continue
+		}
+		found = append(found, candidate{ip: ip, prefix: netip.PrefixFrom(ip, ip.BitLen())})
+	}
+	return found
+}
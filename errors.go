@@ -0,0 +1,59 @@
+package localaddr
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// Errors returned when no address is found. They are wrapped, so test for them with
+// errors.Is, e.g. to keep retrying while the network comes up:
+//
+//	ip, err := localaddr.Get()
+//	if errors.Is(err, localaddr.ErrNotConnected) {
+//		// no network yet, try again later
+//	}
+var (
+	// ErrNotConnected means that no up interface, other than loopback, has a usable address.
+	ErrNotConnected = errors.New("not connected to the network")
+	// ErrInterfaceNotFound means that the interface named by WithInterface does not exist.
+	ErrInterfaceNotFound = errors.New("no such interface")
+	// ErrNoIPv4 means that there are usable addresses, but none is a matching IPv4 address.
+	ErrNoIPv4 = errors.New("no IPv4 address")
+	// ErrNoIPv6 means that there are usable addresses, but none is a matching IPv6 address.
+	ErrNoIPv6 = errors.New("no IPv6 address")
+	// ErrInvalidOption means that an option was given an invalid argument, e.g. a
+	// malformed prefix, in the code or in the environment (see EnvOverride). Retrying
+	// does not help.
+	ErrInvalidOption = errors.New("invalid option")
+	// ErrUnsupportedPlatform means that the platform gives no access to the network
+	// interfaces, as js/wasm in a browser and WASI. It wraps errors.ErrUnsupported.
+	ErrUnsupportedPlatform = fmt.Errorf("no network interfaces on %s: %w", runtime.GOOS, errors.ErrUnsupported)
+)
+
+// InterfaceError reports that the addresses of an interface could not be read, which
+// happens e.g. with VPN adapters that are half torn down.
+//
+// Such interfaces are skipped. Only if no address is found elsewhere, their errors are
+// returned, joined with the reason why nothing was found (see errors.Join); use
+// errors.As to get the details of the first, or unwrap the joined error for all of them.
+type InterfaceError struct {
+	Interface string // name of the interface
+	Err       error  // the failure reported by the operating system
+}
+
+func (e *InterfaceError) Error() string {
+	return fmt.Sprintf("interface %q: %v", e.Interface, e.Err)
+}
+
+func (e *InterfaceError) Unwrap() error { return e.Err }
+
+// optionError is the error of an invalid option. It reads as err, and wraps both err and
+// ErrInvalidOption.
+type optionError struct {
+	err error
+}
+
+func (e *optionError) Error() string { return e.err.Error() }
+
+func (e *optionError) Unwrap() []error { return []error{ErrInvalidOption, e.err} }
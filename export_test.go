@@ -0,0 +1,9 @@
+package localaddr
+
+// WithAliases gives interfaces other names, as Windows does with the friendly names and
+// descriptions of its adapters, which a Provider cannot report.
+func WithAliases(aliases map[string][]string) Option {
+	return func(c *config) {
+		c.aliases = aliases
+	}
+}
@@ -0,0 +1,71 @@
+package localaddr
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+// Family selects the IP version that GetFamily looks for.
+type Family int
+
+const (
+	IPv4       Family = iota // only IPv4 addresses, as Get
+	IPv6                     // only IPv6 addresses, as GetIPv6
+	Any                      // the best address of either version, as GetAll ranks them
+	PreferIPv6               // the IPv6 address if there is one, else the IPv4 one
+)
+
+var familyNames = [...]string{
+	IPv4:       "ipv4",
+	IPv6:       "ipv6",
+	Any:        "any",
+	PreferIPv6: "prefer-ipv6",
+}
+
+// String returns the lower case name of f, e.g. "prefer-ipv6".
+func (f Family) String() string {
+	if f < 0 || int(f) >= len(familyNames) {
+		return fmt.Sprintf("Family(%d)", int(f))
+	}
+	return familyNames[f]
+}
+
+// GetFamily returns the best address of the family f, so that the family can be chosen
+// at run time, e.g. from a flag, with a single call. Get is the same as GetFamily(IPv4),
+// GetIPv6 as GetFamily(IPv6). With PreferIPv6, the IPv4 address is returned only if
+// there are candidates, but none of them is an IPv6 address (ErrNoIPv6).
+//
+// The family is that of f; WithIPv6 in opts has no effect.
+//
+// Returns:
+//   - string: The address as a string (e.g., "2001:db8::2" or "192.168.1.2")
+//   - error: An error if f is invalid, no suitable address is found, or there's an
+//     issue accessing network interfaces
+func GetFamily(f Family, opts ...Option) (string, error) {
+	return str(familyAddr(f, opts))
+}
+
+// familyAddr returns the best address of the family f.
+func familyAddr(f Family, opts []Option) (netip.Addr, error) {
+	scanFamily := func(v family) (netip.Addr, error) {
+		cfg := newConfig(v, opts)
+		cfg.family = v // f decides, also against WithIPv6
+		return firstAddr(cfg)
+	}
+	switch f {
+	case IPv4:
+		return scanFamily(ipv4)
+	case IPv6:
+		return scanFamily(ipv6)
+	case Any:
+		return scanFamily(anyFamily)
+	case PreferIPv6:
+		ip, err := scanFamily(ipv6)
+		if errors.Is(err, ErrNoIPv6) {
+			return scanFamily(ipv4)
+		}
+		return ip, err
+	}
+	return netip.Addr{}, fmt.Errorf("invalid address family %d", int(f))
+}
@@ -0,0 +1,42 @@
+package localaddr
+
+import (
+	"net"
+	"net/netip"
+)
+
+// WithFilter adds a custom selection rule: only addresses for which filter returns true
+// are considered. Filters run after the built-in rules, so they never see loopback,
+// down, or (unless WithIncludeVirtual is given) virtual interfaces. Several filters
+// must all accept an address.
+//
+// For example, to skip addresses of interfaces without a hardware address:
+//
+//	localaddr.Get(localaddr.WithFilter(func(iface net.Interface, _ netip.Addr) bool {
+//		return len(iface.HardwareAddr) > 0
+//	}))
+func WithFilter(filter func(iface net.Interface, addr netip.Addr) bool) Option {
+	return func(c *config) {
+		c.filters = append(c.filters, filter)
+	}
+}
+
+// GetFunc returns the first address that filter accepts, leaving the enumeration,
+// parsing, and error handling to the package. It is Get with WithFilter(filter).
+//
+// Returns:
+//   - string: The address as a string (e.g., "192.168.1.2")
+//   - error: An error if filter accepts no address or if there's an issue accessing network interfaces
+func GetFunc(filter func(iface net.Interface, addr netip.Addr) bool, opts ...Option) (string, error) {
+	return Get(append(opts, WithFilter(filter))...)
+}
+
+// accepts reports whether every WithFilter filter accepts ip of iface.
+func (c *config) accepts(iface *net.Interface, ip netip.Addr) bool {
+	for _, filter := range c.filters {
+		if !filter(*iface, ip) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,87 @@
+package localaddr
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+)
+
+// WithPortRange makes FreePort and FreeAddr pick a port between first and last,
+// inclusive, instead of one the operating system assigns from its ephemeral range.
+func WithPortRange(first, last int) Option {
+	return func(c *config) {
+		if first < 1 || last > 65535 || first > last {
+			c.setErr(fmt.Errorf("invalid port range %d-%d", first, last))
+			return
+		}
+		c.portFirst, c.portLast = first, last
+	}
+}
+
+// FreePort finds a TCP port that nothing listens on at the selected address, which is
+// chosen as by Get.
+//
+// The port is found by listening on it and closing the listener again, so another
+// process may take it before the caller does; listen on the result right away.
+//
+// Returns:
+//   - int: The port (e.g., 52344)
+//   - error: An error if no address is selected or every port of WithPortRange is taken
+func FreePort(opts ...Option) (int, error) {
+	ap, err := freeAddr(newConfig(ipv4, opts))
+	return int(ap.Port()), err
+}
+
+// FreeAddr is like FreePort, but returns the address and port joined, ready to pass to
+// net.Listen or http.Server.
+//
+// Returns:
+//   - string: The address and port (e.g., "192.168.1.42:52344" or "[fd00::42]:52344")
+//   - error: An error if no address is selected or every port of WithPortRange is taken
+func FreeAddr(opts ...Option) (string, error) {
+	ap, err := freeAddr(newConfig(ipv4, opts))
+	if err != nil {
+		return "", err
+	}
+	return ap.String(), nil
+}
+
+// freeAddr selects an address for cfg and finds a free port on it.
+func freeAddr(cfg *config) (netip.AddrPort, error) {
+	c, err := first(cfg)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	ip := c.ip // with a zone if link-local
+	if cfg.portFirst == 0 {
+		return listenFree(netip.AddrPortFrom(ip, 0))
+	}
+	// Start at a random port of the range, so that concurrent callers rarely race for
+	// the same one.
+	n := cfg.portLast - cfg.portFirst + 1
+	start := rand.IntN(n)
+	var errs []error
+	for i := range n {
+		port := cfg.portFirst + (start+i)%n
+		ap, err := listenFree(netip.AddrPortFrom(ip, uint16(port)))
+		if err == nil {
+			return ap, nil
+		}
+		if len(errs) < 3 { // enough to tell why, not one per port
+			errs = append(errs, err)
+		}
+	}
+	return netip.AddrPort{}, fmt.Errorf("no free port in %d-%d on %s: %w", cfg.portFirst, cfg.portLast, ip, errors.Join(errs...))
+}
+
+// listenFree listens on ap and closes the listener, and returns ap with the port it had.
+func listenFree(ap netip.AddrPort) (netip.AddrPort, error) {
+	l, err := net.ListenTCP("tcp", net.TCPAddrFromAddrPort(ap))
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	defer l.Close()
+	return netip.AddrPortFrom(ap.Addr(), uint16(l.Addr().(*net.TCPAddr).Port)), nil
+}
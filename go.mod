@@ -1,3 +1,5 @@
 module github.com/golovingreg/localaddr
 
 go 1.23
+
+require golang.org/x/sys v0.30.0
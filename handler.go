@@ -0,0 +1,73 @@
+package localaddr
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// handlerReport is the JSON document served by Handler. Its field names are part of
+// the API.
+type handlerReport struct {
+	Hostname   string    `json:"hostname,omitempty"`
+	FQDN       string    `json:"fqdn,omitempty"`
+	IPv4       *Address  `json:"ipv4"`
+	IPv4Error  string    `json:"ipv4_error,omitempty"`
+	IPv6       *Address  `json:"ipv6"`
+	IPv6Error  string    `json:"ipv6_error,omitempty"`
+	Outbound   string    `json:"outbound,omitempty"`
+	Candidates []Scored  `json:"candidates"`
+	Time       time.Time `json:"time"`
+}
+
+// Handler returns an http.Handler that reports what this process thinks its local
+// addresses are, for mounting at a debug path such as "/debug/localaddr". Each GET
+// request selects the addresses anew with opts, as GetDetailed and GetIPv6Detailed
+// would, and answers with a JSON object, which also holds the names of Hostname and
+// FQDN:
+//
+//	{"hostname": "host1", "fqdn": "host1.example.com", "ipv4": {"address": "192.168.1.42", ...},
+//	 "ipv6": null, "ipv6_error": "no IPv6 address", "outbound": "192.168.1.42",
+//	 "candidates": [{"address": "192.168.1.42", "score": 482, ...}], "time": "2024-05-01T10:00:00Z"}
+//
+// The addresses have the form of Address.MarshalJSON and the candidates, ranked as by
+// Rank, that of Scored.MarshalJSON. A family without an address is null, with the
+// reason in ipv4_error or ipv6_error. The handler does not restrict who may ask; mount
+// it where only operators can reach it.
+func Handler(opts ...Option) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		report := handlerReport{Candidates: []Scored{}, Time: time.Now().UTC()}
+		report.Hostname, _ = Hostname()
+		ctx, cancel := context.WithTimeout(r.Context(), hostnameTimeout)
+		report.FQDN, _ = FQDN(ctx)
+		cancel()
+		if a, err := GetDetailed(opts...); err == nil {
+			report.IPv4 = &a
+		} else {
+			report.IPv4Error = err.Error()
+		}
+		if a, err := GetIPv6Detailed(opts...); err == nil {
+			report.IPv6 = &a
+		} else {
+			report.IPv6Error = err.Error()
+		}
+		report.Outbound, _ = GetOutbound(opts...) // empty without a route
+		if ranked, err := Rank(opts...); err == nil {
+			report.Candidates = ranked
+		}
+		body, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(append(body, '\n'))
+	})
+}
@@ -0,0 +1,154 @@
+package localaddr
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// hostnameTimeout bounds the lookup of WithHostnameFallback, and that of the FQDN
+// Handler reports.
+const hostnameTimeout = 2 * time.Second
+
+// WithHostnameFallback breaks ties between equally ranked candidates by resolving the
+// machine's host name (os.Hostname) with the system resolver and preferring an address
+// it resolves to. Many operations tools treat that address as the machine's address,
+// and on machines with unusual interface setups it is often the one an administrator
+// put into /etc/hosts or DNS on purpose.
+//
+// The host name is only resolved when there are several candidates, and for at most two
+// seconds; if the lookup fails, the ranking is as without the option.
+func WithHostnameFallback() Option {
+	return func(c *config) {
+		c.byHostname = true
+	}
+}
+
+// hostnameAddrs returns the addresses the host name resolves to, looking them up once.
+func (r *ranker) hostnameAddrs() []netip.Addr {
+	if !r.readHostname {
+		r.readHostname = true
+		r.hostname = resolveHostname()
+	}
+	return r.hostname
+}
+
+// resolveHostname returns the addresses of os.Hostname, or nil if it cannot be resolved.
+func resolveHostname() []netip.Addr {
+	ctx, cancel := context.WithTimeout(context.Background(), hostnameTimeout)
+	defer cancel()
+	addrs, _ := lookupHostname(ctx)
+	return addrs
+}
+
+// lookupHostname resolves os.Hostname with the system resolver, leaving out loopback
+// addresses.
+func lookupHostname(ctx context.Context) ([]netip.Addr, error) {
+	name, err := os.Hostname()
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return nil, errors.New("no host name")
+	}
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", name)
+	if err != nil {
+		return nil, err
+	}
+	for i := range addrs {
+		addrs[i] = addrs[i].Unmap()
+	}
+	return slices.DeleteFunc(addrs, netip.Addr.IsLoopback), nil // Debian maps the name to 127.0.1.1
+}
+
+// Hostname returns the short host name of the machine, os.Hostname up to the first dot,
+// e.g. "host1" where the kernel was given "host1.example.com".
+//
+// Returns:
+//   - string: The host name (e.g., "host1")
+//   - error: An error if the system does not tell the host name
+func Hostname() (string, error) {
+	name, err := os.Hostname()
+	if err != nil {
+		return "", err
+	}
+	if name == "" {
+		return "", errors.New("no host name")
+	}
+	short, _, _ := strings.Cut(name, ".")
+	return short, nil
+}
+
+// FQDN returns the fully qualified domain name of the machine as well as it can be told,
+// as "hostname -f" does: os.Hostname if it is qualified already, or else the name that
+// an address of the host name resolves back to, if that name resolves to the address
+// again and starts with the host name, or else the canonical name of the host name. If
+// none is found, it is the host name as is. The system resolver is used, and ctx bounds
+// the lookups.
+//
+// Returns:
+//   - string: The name, without the trailing dot (e.g., "host1.example.com")
+//   - error: An error if the system does not tell the host name, or ctx.Err()
+func FQDN(ctx context.Context) (string, error) {
+	name, err := os.Hostname()
+	if err != nil {
+		return "", err
+	}
+	name = strings.TrimSuffix(name, ".")
+	if name == "" {
+		return "", errors.New("no host name")
+	}
+	if strings.Contains(name, ".") {
+		return name, nil
+	}
+	addrs, _ := lookupHostname(ctx)
+	for _, addr := range addrs {
+		ptrs, _ := net.DefaultResolver.LookupAddr(ctx, addr.String())
+		for _, ptr := range ptrs {
+			ptr = strings.TrimSuffix(ptr, ".")
+			if !strings.HasPrefix(strings.ToLower(ptr), strings.ToLower(name)+".") {
+				continue
+			}
+			back, _ := net.DefaultResolver.LookupNetIP(ctx, "ip", ptr)
+			if slices.ContainsFunc(back, func(ip netip.Addr) bool { return ip.Unmap() == addr }) {
+				return ptr, nil
+			}
+		}
+	}
+	if cname, err := net.DefaultResolver.LookupCNAME(ctx, name); err == nil {
+		if cname = strings.TrimSuffix(cname, "."); strings.Contains(cname, ".") {
+			return cname, nil
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// ReverseLookup returns the names that the address selected as by Get resolves back to
+// (its PTR records), for logging and for services that must advertise a name rather than
+// an address. The same options as for Get apply, and the system resolver is used.
+//
+// Returns:
+//   - []string: The names, without the trailing dot (e.g., ["host1.example.com"])
+//   - error: An error if no address is selected or it has no PTR record
+func ReverseLookup(ctx context.Context, opts ...Option) ([]string, error) {
+	ip, err := firstAddr(newConfig(ipv4, opts))
+	if err != nil {
+		return nil, err
+	}
+	names, err := net.DefaultResolver.LookupAddr(ctx, ip.String())
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range names {
+		names[i] = strings.TrimSuffix(name, ".")
+	}
+	return names, nil
+}
@@ -0,0 +1,335 @@
+// Package dbus implements the subset of the D-Bus protocol needed to read properties
+// of system services: connecting to the system bus with EXTERNAL authentication, and
+// calling methods with string arguments. Signals are ignored.
+package dbus
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSystemBus is the address of the system bus without DBUS_SYSTEM_BUS_ADDRESS.
+const DefaultSystemBus = "unix:path=/var/run/dbus/system_bus_socket"
+
+// Message types and header fields.
+const (
+	typeMethodCall   = 1
+	typeMethodReturn = 2
+	typeError        = 3
+
+	fieldPath        = 1
+	fieldInterface   = 2
+	fieldMember      = 3
+	fieldErrorName   = 4
+	fieldReplySerial = 5
+	fieldDestination = 6
+	fieldSignature   = 8
+
+	maxMessage = 1 << 27 // the limit of the specification
+)
+
+// Error is an error reply of a method call.
+type Error struct {
+	Name    string // e.g. "org.freedesktop.DBus.Error.ServiceUnknown"
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return "dbus: " + e.Name
+	}
+	return "dbus: " + e.Name + ": " + e.Message
+}
+
+// ServiceUnknown reports whether err says that no service owns the destination name,
+// i.e. that the service is not running.
+func ServiceUnknown(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && (e.Name == "org.freedesktop.DBus.Error.ServiceUnknown" ||
+		e.Name == "org.freedesktop.DBus.Error.NameHasNoOwner")
+}
+
+// Conn is a connection to a message bus. Its methods may be called concurrently; the
+// calls are serialized.
+type Conn struct {
+	mu     sync.Mutex
+	conn   net.Conn
+	r      *bufio.Reader
+	serial uint32
+}
+
+// SystemBus connects to the system bus, at DBUS_SYSTEM_BUS_ADDRESS or DefaultSystemBus.
+func SystemBus(ctx context.Context) (*Conn, error) {
+	address := os.Getenv("DBUS_SYSTEM_BUS_ADDRESS")
+	if address == "" {
+		address = DefaultSystemBus
+	}
+	return Dial(ctx, address)
+}
+
+// Dial connects to the bus at address, in the form of DBUS_SYSTEM_BUS_ADDRESS, e.g.
+// "unix:path=/run/dbus/system_bus_socket". Only unix transports are supported.
+func Dial(ctx context.Context, address string) (*Conn, error) {
+	var errs []error
+	for _, a := range strings.Split(address, ";") {
+		transport, params, _ := strings.Cut(a, ":")
+		if transport != "unix" {
+			errs = append(errs, fmt.Errorf("dbus: unsupported transport %q", transport))
+			continue
+		}
+		var path string
+		for _, kv := range strings.Split(params, ",") {
+			k, v, _ := strings.Cut(kv, "=")
+			switch k {
+			case "path":
+				path = unescape(v)
+			case "abstract":
+				path = "@" + unescape(v)
+			}
+		}
+		if path == "" {
+			errs = append(errs, fmt.Errorf("dbus: no path in %q", a))
+			continue
+		}
+		c, err := dial(ctx, path)
+		if err == nil {
+			return c, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, ctxErr(ctx, errors.Join(errs...))
+}
+
+// unescape decodes the %xx escapes of an address value.
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '%' && i+2 < len(s) {
+			if v, err := strconv.ParseUint(s[i+1:i+3], 16, 8); err == nil {
+				b.WriteByte(byte(v))
+				i += 2
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// dial connects to the socket at path, authenticates, and says hello to the bus.
+func dial(ctx context.Context, path string) (*Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Conn{conn: conn, r: bufio.NewReader(conn)}
+	if err := c.auth(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if _, err := c.Call(ctx, "org.freedesktop.DBus", "/org/freedesktop/DBus", "org.freedesktop.DBus", "Hello", ""); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// auth runs the EXTERNAL authentication, with the user ID the server sees anyway.
+func (c *Conn) auth(ctx context.Context) error {
+	c.deadline(ctx)
+	uid := hex.EncodeToString([]byte(strconv.Itoa(os.Getuid())))
+	if _, err := io.WriteString(c.conn, "\x00AUTH EXTERNAL "+uid+"\r\n"); err != nil {
+		return err
+	}
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("dbus: authentication: %w", err)
+	}
+	if !strings.HasPrefix(line, "OK ") {
+		return fmt.Errorf("dbus: authentication rejected: %q", strings.TrimSpace(line))
+	}
+	_, err = io.WriteString(c.conn, "BEGIN\r\n")
+	return err
+}
+
+// Close closes the connection.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// deadline applies the deadline of ctx, if any, to the connection.
+func (c *Conn) deadline(ctx context.Context) {
+	t, _ := ctx.Deadline()
+	c.conn.SetDeadline(t)
+}
+
+// Call calls a method and returns the values of its reply. The arguments are of the
+// basic types given by sig, e.g. "ss" for two strings.
+func (c *Conn) Call(ctx context.Context, dest, path, iface, member, sig string, args ...any) ([]any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deadline(ctx)
+	if ctx.Done() != nil {
+		stop := context.AfterFunc(ctx, func() { c.conn.SetDeadline(time.Unix(1, 0)) })
+		defer stop()
+	}
+	c.serial++
+	msg, err := methodCall(c.serial, dest, path, iface, member, sig, args)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.conn.Write(msg); err != nil {
+		return nil, ctxErr(ctx, err)
+	}
+	for {
+		m, err := c.read()
+		if err != nil {
+			return nil, ctxErr(ctx, err)
+		}
+		if m.replySerial != c.serial || (m.typ != typeMethodReturn && m.typ != typeError) {
+			continue // a signal, e.g. NameAcquired after Hello
+		}
+		if m.typ == typeError {
+			e := &Error{Name: m.errorName}
+			if len(m.body) > 0 {
+				e.Message, _ = m.body[0].(string)
+			}
+			return nil, e
+		}
+		return m.body, nil
+	}
+}
+
+// Property returns the value of a property, read with org.freedesktop.DBus.Properties.Get.
+func (c *Conn) Property(ctx context.Context, dest, path, iface, name string) (any, error) {
+	body, err := c.Call(ctx, dest, path, "org.freedesktop.DBus.Properties", "Get", "ss", iface, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) != 1 {
+		return nil, fmt.Errorf("dbus: %s.%s: unexpected reply", iface, name)
+	}
+	return body[0], nil
+}
+
+// ctxErr prefers the error of ctx over err, which is then a consequence of it. The
+// connection may time out just before ctx is done.
+func ctxErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if t, ok := ctx.Deadline(); ok && !time.Now().Before(t) {
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+// methodCall encodes a method call message.
+func methodCall(serial uint32, dest, path, iface, member, sig string, args []any) ([]byte, error) {
+	var body encoder
+	if len(args) != len(sig) {
+		return nil, fmt.Errorf("dbus: %d arguments for signature %q", len(args), sig)
+	}
+	for i, arg := range args {
+		if err := body.value(sig[i], arg); err != nil {
+			return nil, err
+		}
+	}
+
+	e := encoder{b: []byte{'l', typeMethodCall, 0, 1}}
+	e.uint32(uint32(len(body.b)))
+	e.uint32(serial)
+	var fields encoder
+	field := func(code byte, typ byte, v string) {
+		fields.align(8)
+		fields.b = append(fields.b, code)
+		fields.signature(string(typ))
+		fields.value(typ, v)
+	}
+	// The fields are aligned relative to the message, which they start at offset 16 of.
+	fields.b = make([]byte, 16)
+	field(fieldPath, 'o', path)
+	if iface != "" {
+		field(fieldInterface, 's', iface)
+	}
+	field(fieldMember, 's', member)
+	field(fieldDestination, 's', dest)
+	if sig != "" {
+		field(fieldSignature, 'g', sig)
+	}
+	e.uint32(uint32(len(fields.b) - 16))
+	e.b = append(e.b, fields.b[16:]...)
+	e.align(8)
+	return append(e.b, body.b...), nil
+}
+
+// message is a received message, as far as this package looks at it.
+type message struct {
+	typ         byte
+	replySerial uint32
+	errorName   string
+	body        []any
+}
+
+// read reads the next message from the bus.
+func (c *Conn) read() (message, error) {
+	head := make([]byte, 16)
+	if _, err := io.ReadFull(c.r, head); err != nil {
+		return message{}, err
+	}
+	var order binary.ByteOrder
+	switch head[0] {
+	case 'l':
+		order = binary.LittleEndian
+	case 'B':
+		order = binary.BigEndian
+	default:
+		return message{}, fmt.Errorf("dbus: invalid byte order %q", head[0])
+	}
+	bodyLen, fieldsLen := order.Uint32(head[4:]), order.Uint32(head[12:])
+	headerLen := (16 + int(fieldsLen) + 7) &^ 7
+	if uint64(headerLen)+uint64(bodyLen) > maxMessage {
+		return message{}, errors.New("dbus: message too long")
+	}
+	buf := make([]byte, headerLen+int(bodyLen))
+	copy(buf, head)
+	if _, err := io.ReadFull(c.r, buf[16:]); err != nil {
+		return message{}, err
+	}
+
+	d := &decoder{b: buf[:16+fieldsLen], off: 12, order: order}
+	v, err := d.value("a(yv)")
+	if err != nil {
+		return message{}, err
+	}
+	m := message{typ: head[1]}
+	var sig string
+	for _, f := range v.([]any) {
+		f := f.([]any)
+		switch f[0].(byte) {
+		case fieldReplySerial:
+			m.replySerial, _ = f[1].(uint32)
+		case fieldErrorName:
+			m.errorName, _ = f[1].(string)
+		case fieldSignature:
+			sig, _ = f[1].(string)
+		}
+	}
+	d = &decoder{b: buf, off: headerLen, order: order}
+	if m.body, err = d.values(sig); err != nil {
+		return message{}, err
+	}
+	return m, nil
+}
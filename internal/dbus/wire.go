@@ -0,0 +1,311 @@
+package dbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// errShort is returned for messages that end in the middle of a value.
+var errShort = errors.New("dbus: truncated message")
+
+// alignment returns the alignment of the type that sig starts with.
+func alignment(c byte) int {
+	switch c {
+	case 'n', 'q':
+		return 2
+	case 'b', 'i', 'u', 'h', 's', 'o', 'a':
+		return 4
+	case 'x', 't', 'd', '(', '{':
+		return 8
+	}
+	return 1 // y, g, v
+}
+
+// next returns the length of the single complete type that sig starts with.
+func next(sig string) (int, error) {
+	if sig == "" {
+		return 0, errors.New("dbus: empty signature")
+	}
+	switch sig[0] {
+	case 'a':
+		n, err := next(sig[1:])
+		return n + 1, err
+	case '(', '{':
+		close := byte(')')
+		if sig[0] == '{' {
+			close = '}'
+		}
+		for i := 1; i < len(sig); {
+			if sig[i] == close {
+				return i + 1, nil
+			}
+			n, err := next(sig[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+		return 0, fmt.Errorf("dbus: unterminated signature %q", sig)
+	case 'y', 'b', 'n', 'q', 'i', 'u', 'x', 't', 'd', 'h', 's', 'o', 'g', 'v':
+		return 1, nil
+	}
+	return 0, fmt.Errorf("dbus: unsupported type %q", sig[0])
+}
+
+// encoder appends values in the little endian wire format.
+type encoder struct {
+	b []byte
+}
+
+func (e *encoder) align(n int) {
+	for len(e.b)%n != 0 {
+		e.b = append(e.b, 0)
+	}
+}
+
+func (e *encoder) uint32(v uint32) {
+	e.align(4)
+	e.b = binary.LittleEndian.AppendUint32(e.b, v)
+}
+
+func (e *encoder) string(s string) {
+	e.uint32(uint32(len(s)))
+	e.b = append(append(e.b, s...), 0)
+}
+
+func (e *encoder) signature(s string) {
+	e.b = append(append(append(e.b, byte(len(s))), s...), 0)
+}
+
+// value appends v as the basic type c. Only the types this package sends are supported.
+func (e *encoder) value(c byte, v any) error {
+	switch c {
+	case 'y':
+		b, ok := v.(byte)
+		if !ok {
+			return fmt.Errorf("dbus: %T is not a byte", v)
+		}
+		e.b = append(e.b, b)
+	case 'b':
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("dbus: %T is not a bool", v)
+		}
+		u := uint32(0)
+		if b {
+			u = 1
+		}
+		e.uint32(u)
+	case 'u':
+		u, ok := v.(uint32)
+		if !ok {
+			return fmt.Errorf("dbus: %T is not a uint32", v)
+		}
+		e.uint32(u)
+	case 's', 'o':
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("dbus: %T is not a string", v)
+		}
+		e.string(s)
+	case 'g':
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("dbus: %T is not a signature", v)
+		}
+		e.signature(s)
+	default:
+		return fmt.Errorf("dbus: cannot send type %q", c)
+	}
+	return nil
+}
+
+// decoder reads values of a message. Offsets count from the start of the message, which
+// alignment refers to.
+type decoder struct {
+	b     []byte
+	off   int
+	order binary.ByteOrder
+	depth int
+}
+
+func (d *decoder) align(n int) error {
+	for d.off%n != 0 {
+		d.off++
+	}
+	if d.off > len(d.b) {
+		return errShort
+	}
+	return nil
+}
+
+func (d *decoder) take(n int) ([]byte, error) {
+	if n < 0 || d.off+n > len(d.b) {
+		return nil, errShort
+	}
+	p := d.b[d.off : d.off+n]
+	d.off += n
+	return p, nil
+}
+
+func (d *decoder) uint32() (uint32, error) {
+	if err := d.align(4); err != nil {
+		return 0, err
+	}
+	p, err := d.take(4)
+	if err != nil {
+		return 0, err
+	}
+	return d.order.Uint32(p), nil
+}
+
+// values decodes the values of a whole signature, e.g. a message body.
+func (d *decoder) values(sig string) ([]any, error) {
+	var vs []any
+	for sig != "" {
+		n, err := next(sig)
+		if err != nil {
+			return nil, err
+		}
+		v, err := d.value(sig[:n])
+		if err != nil {
+			return nil, err
+		}
+		vs = append(vs, v)
+		sig = sig[n:]
+	}
+	return vs, nil
+}
+
+// value decodes a single complete type. Arrays become []any, dictionaries map[any]any,
+// structs []any, and variants their value.
+func (d *decoder) value(sig string) (any, error) {
+	if d.depth > 64 {
+		return nil, errors.New("dbus: values nested too deeply")
+	}
+	d.depth++
+	defer func() { d.depth-- }()
+	c := sig[0]
+	if err := d.align(alignment(c)); err != nil {
+		return nil, err
+	}
+	switch c {
+	case 'y':
+		p, err := d.take(1)
+		if err != nil {
+			return nil, err
+		}
+		return p[0], nil
+	case 'b':
+		u, err := d.uint32()
+		return u != 0, err
+	case 'n', 'q':
+		p, err := d.take(2)
+		if err != nil {
+			return nil, err
+		}
+		if c == 'n' {
+			return int16(d.order.Uint16(p)), nil
+		}
+		return d.order.Uint16(p), nil
+	case 'i':
+		u, err := d.uint32()
+		return int32(u), err
+	case 'u', 'h':
+		return d.uint32()
+	case 'x', 't', 'd':
+		p, err := d.take(8)
+		if err != nil {
+			return nil, err
+		}
+		u := d.order.Uint64(p)
+		switch c {
+		case 'x':
+			return int64(u), nil
+		case 'd':
+			return math.Float64frombits(u), nil
+		}
+		return u, nil
+	case 's', 'o':
+		n, err := d.uint32()
+		if err != nil {
+			return nil, err
+		}
+		p, err := d.take(int(n) + 1)
+		if err != nil {
+			return nil, err
+		}
+		return string(p[:n]), nil
+	case 'g':
+		return d.signature()
+	case 'v':
+		inner, err := d.signature()
+		if err != nil {
+			return nil, err
+		}
+		if n, err := next(inner); err != nil || n != len(inner) {
+			return nil, fmt.Errorf("dbus: invalid variant signature %q", inner)
+		}
+		return d.value(inner)
+	case 'a':
+		return d.array(sig[1:])
+	case '(':
+		return d.values(sig[1 : len(sig)-1])
+	}
+	return nil, fmt.Errorf("dbus: unsupported type %q", c)
+}
+
+func (d *decoder) signature() (string, error) {
+	p, err := d.take(1)
+	if err != nil {
+		return "", err
+	}
+	p, err = d.take(int(p[0]) + 1)
+	if err != nil {
+		return "", err
+	}
+	return string(p[:len(p)-1]), nil
+}
+
+// array decodes an array of elem, whose length in bytes comes first.
+func (d *decoder) array(elem string) (any, error) {
+	n, err := d.uint32()
+	if err != nil {
+		return nil, err
+	}
+	if err := d.align(alignment(elem[0])); err != nil {
+		return nil, err
+	}
+	end := d.off + int(n)
+	if n > 1<<26 || end > len(d.b) {
+		return nil, errShort
+	}
+	if elem[0] == '{' {
+		m := make(map[any]any)
+		for d.off < end {
+			if err := d.align(8); err != nil {
+				return nil, err
+			}
+			kv, err := d.values(elem[1 : len(elem)-1])
+			if err != nil {
+				return nil, err
+			}
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("dbus: invalid dictionary signature %q", elem)
+			}
+			m[kv[0]] = kv[1]
+		}
+		return m, nil
+	}
+	list := []any{}
+	for d.off < end {
+		v, err := d.value(elem)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, v)
+	}
+	return list, nil
+}
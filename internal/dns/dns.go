@@ -0,0 +1,376 @@
+// Package dns encodes and decodes DNS messages (RFC 1035), as far as the name protocols
+// of this module need them: multicast DNS (RFC 6762), DNS-based service discovery
+// (RFC 6763), LLMNR (RFC 4795), and plain queries to a resolver.
+//
+// Names are written without the trailing dot, e.g. "mybox.local". Dots and backslashes
+// inside a label, as in DNS-SD instance names, are escaped with a backslash.
+package dns
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+)
+
+// Record types and classes used by this module.
+const (
+	TypeA     = 1
+	TypeCNAME = 5
+	TypePTR   = 12
+	TypeTXT   = 16
+	TypeAAAA  = 28
+	TypeSRV   = 33
+	TypeANY   = 255
+
+	ClassINET = 1
+	// ClassUnique is the mDNS bit of the class that asks for a unicast response in a
+	// question, and marks a record that flushes older ones from caches ("cache flush").
+	ClassUnique = 0x8000
+)
+
+// Header flags.
+const (
+	FlagResponse         = 0x8000
+	FlagAuthoritative    = 0x0400
+	FlagTruncated        = 0x0200
+	FlagRecursionDesired = 0x0100
+	RCodeMask            = 0x000f
+)
+
+// ErrMalformed is returned by Parse for messages that cannot be decoded.
+var ErrMalformed = errors.New("dns: malformed message")
+
+// Message is a DNS message.
+type Message struct {
+	ID          uint16
+	Flags       uint16
+	Questions   []Question
+	Answers     []Resource
+	Authorities []Resource
+	Additionals []Resource
+}
+
+// Question is an entry of the question section.
+type Question struct {
+	Name  string
+	Type  uint16
+	Class uint16
+}
+
+// Resource is a resource record. Which of the data fields is used depends on Type.
+type Resource struct {
+	Name  string
+	Type  uint16
+	Class uint16
+	TTL   uint32
+
+	Addr     netip.Addr // A, AAAA
+	Target   string     // PTR, CNAME, SRV
+	Priority uint16     // SRV
+	Weight   uint16     // SRV
+	Port     uint16     // SRV
+	Text     []string   // TXT
+	Data     []byte     // other types, as they are on the wire
+}
+
+// Records returns all resource records of m: answers, authorities, and additionals.
+func (m *Message) Records() []Resource {
+	all := make([]Resource, 0, len(m.Answers)+len(m.Authorities)+len(m.Additionals))
+	all = append(all, m.Answers...)
+	all = append(all, m.Authorities...)
+	return append(all, m.Additionals...)
+}
+
+// EqualNames reports whether two names are the same, ignoring ASCII case.
+func EqualNames(a, b string) bool {
+	return strings.EqualFold(strings.TrimSuffix(a, "."), strings.TrimSuffix(b, "."))
+}
+
+// Escape escapes the dots and backslashes of label, e.g. of a DNS-SD instance name like
+// "Printer 2.0", so that it can be joined with other labels into a name.
+func Escape(label string) string {
+	return strings.NewReplacer(`\`, `\\`, `.`, `\.`).Replace(label)
+}
+
+// ReverseName returns the name of the PTR record of ip, e.g. "2.1.168.192.in-addr.arpa",
+// or the nibbles under "ip6.arpa" for IPv6 addresses.
+func ReverseName(ip netip.Addr) string {
+	ip = ip.Unmap()
+	var b strings.Builder
+	if ip.Is4() {
+		a := ip.As4()
+		fmt.Fprintf(&b, "%d.%d.%d.%d.in-addr.arpa", a[3], a[2], a[1], a[0])
+		return b.String()
+	}
+	a := ip.As16()
+	for i := len(a) - 1; i >= 0; i-- {
+		fmt.Fprintf(&b, "%x.%x.", a[i]&0xf, a[i]>>4)
+	}
+	b.WriteString("ip6.arpa")
+	return b.String()
+}
+
+// Pack encodes m. Names are not compressed.
+func (m *Message) Pack() ([]byte, error) {
+	b := make([]byte, 12, 512)
+	binary.BigEndian.PutUint16(b[0:], m.ID)
+	binary.BigEndian.PutUint16(b[2:], m.Flags)
+	binary.BigEndian.PutUint16(b[4:], uint16(len(m.Questions)))
+	binary.BigEndian.PutUint16(b[6:], uint16(len(m.Answers)))
+	binary.BigEndian.PutUint16(b[8:], uint16(len(m.Authorities)))
+	binary.BigEndian.PutUint16(b[10:], uint16(len(m.Additionals)))
+	var err error
+	for _, q := range m.Questions {
+		if b, err = appendName(b, q.Name); err != nil {
+			return nil, err
+		}
+		b = binary.BigEndian.AppendUint16(b, q.Type)
+		b = binary.BigEndian.AppendUint16(b, q.Class)
+	}
+	for _, section := range [][]Resource{m.Answers, m.Authorities, m.Additionals} {
+		for i := range section {
+			if b, err = section[i].append(b); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return b, nil
+}
+
+// append encodes r after b.
+func (r *Resource) append(b []byte) ([]byte, error) {
+	b, err := appendName(b, r.Name)
+	if err != nil {
+		return nil, err
+	}
+	b = binary.BigEndian.AppendUint16(b, r.Type)
+	b = binary.BigEndian.AppendUint16(b, r.Class)
+	b = binary.BigEndian.AppendUint32(b, r.TTL)
+	lenAt := len(b)
+	b = append(b, 0, 0) // data length, filled in below
+	switch r.Type {
+	case TypeA:
+		if !r.Addr.Is4() {
+			return nil, fmt.Errorf("dns: A record %s without IPv4 address", r.Name)
+		}
+		b = append(b, r.Addr.AsSlice()...)
+	case TypeAAAA:
+		if !r.Addr.Is6() {
+			return nil, fmt.Errorf("dns: AAAA record %s without IPv6 address", r.Name)
+		}
+		b = append(b, r.Addr.AsSlice()...)
+	case TypePTR, TypeCNAME:
+		if b, err = appendName(b, r.Target); err != nil {
+			return nil, err
+		}
+	case TypeSRV:
+		b = binary.BigEndian.AppendUint16(b, r.Priority)
+		b = binary.BigEndian.AppendUint16(b, r.Weight)
+		b = binary.BigEndian.AppendUint16(b, r.Port)
+		if b, err = appendName(b, r.Target); err != nil {
+			return nil, err
+		}
+	case TypeTXT:
+		if len(r.Text) == 0 {
+			b = append(b, 0) // RFC 6763 section 6.1: never empty
+		}
+		for _, s := range r.Text {
+			if len(s) > 255 {
+				return nil, fmt.Errorf("dns: TXT string of %d bytes", len(s))
+			}
+			b = append(b, byte(len(s)))
+			b = append(b, s...)
+		}
+	default:
+		b = append(b, r.Data...)
+	}
+	n := len(b) - lenAt - 2
+	if n > 0xffff {
+		return nil, fmt.Errorf("dns: record %s too long", r.Name)
+	}
+	binary.BigEndian.PutUint16(b[lenAt:], uint16(n))
+	return b, nil
+}
+
+// appendName encodes name as a sequence of labels after b.
+func appendName(b []byte, name string) ([]byte, error) {
+	labels, err := splitName(name)
+	if err != nil {
+		return nil, err
+	}
+	for _, label := range labels {
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0), nil
+}
+
+// splitName splits name into its unescaped labels.
+func splitName(name string) ([]string, error) {
+	name = strings.TrimSuffix(name, ".")
+	if name == "" {
+		return nil, nil
+	}
+	var labels []string
+	var label []byte
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; {
+		case c == '\\' && i+1 < len(name):
+			i++
+			label = append(label, name[i])
+		case c == '.':
+			labels = append(labels, string(label))
+			label = label[:0]
+		default:
+			label = append(label, c)
+		}
+	}
+	labels = append(labels, string(label))
+	total := 1
+	for _, l := range labels {
+		if len(l) == 0 || len(l) > 63 {
+			return nil, fmt.Errorf("dns: invalid name %q", name)
+		}
+		total += len(l) + 1
+	}
+	if total > 255 {
+		return nil, fmt.Errorf("dns: name %q too long", name)
+	}
+	return labels, nil
+}
+
+// Parse decodes a message.
+func Parse(msg []byte) (*Message, error) {
+	if len(msg) < 12 {
+		return nil, ErrMalformed
+	}
+	m := &Message{ID: binary.BigEndian.Uint16(msg[0:]), Flags: binary.BigEndian.Uint16(msg[2:])}
+	counts := [4]int{}
+	for i := range counts {
+		counts[i] = int(binary.BigEndian.Uint16(msg[4+2*i:]))
+	}
+	off := 12
+	for i := 0; i < counts[0]; i++ {
+		name, next, err := readName(msg, off)
+		if err != nil || next+4 > len(msg) {
+			return nil, ErrMalformed
+		}
+		m.Questions = append(m.Questions, Question{
+			Name:  name,
+			Type:  binary.BigEndian.Uint16(msg[next:]),
+			Class: binary.BigEndian.Uint16(msg[next+2:]),
+		})
+		off = next + 4
+	}
+	for s, section := range []*[]Resource{&m.Answers, &m.Authorities, &m.Additionals} {
+		for i := 0; i < counts[s+1]; i++ {
+			r, next, err := readResource(msg, off)
+			if err != nil {
+				return nil, err
+			}
+			*section = append(*section, r)
+			off = next
+		}
+	}
+	return m, nil
+}
+
+// readResource decodes the resource record at off, and returns the offset after it.
+func readResource(msg []byte, off int) (Resource, int, error) {
+	var r Resource
+	name, off, err := readName(msg, off)
+	if err != nil || off+10 > len(msg) {
+		return r, 0, ErrMalformed
+	}
+	r.Name = name
+	r.Type = binary.BigEndian.Uint16(msg[off:])
+	r.Class = binary.BigEndian.Uint16(msg[off+2:])
+	r.TTL = binary.BigEndian.Uint32(msg[off+4:])
+	n := int(binary.BigEndian.Uint16(msg[off+8:]))
+	start, end := off+10, off+10+n
+	if end > len(msg) {
+		return r, 0, ErrMalformed
+	}
+	data := msg[start:end]
+	switch r.Type {
+	case TypeA, TypeAAAA:
+		var ok bool
+		if r.Addr, ok = netip.AddrFromSlice(data); !ok || r.Addr.Is4() != (r.Type == TypeA) {
+			return r, 0, ErrMalformed
+		}
+	case TypePTR, TypeCNAME:
+		if r.Target, _, err = readName(msg, start); err != nil {
+			return r, 0, err
+		}
+	case TypeSRV:
+		if n < 7 {
+			return r, 0, ErrMalformed
+		}
+		r.Priority = binary.BigEndian.Uint16(data)
+		r.Weight = binary.BigEndian.Uint16(data[2:])
+		r.Port = binary.BigEndian.Uint16(data[4:])
+		if r.Target, _, err = readName(msg, start+6); err != nil {
+			return r, 0, err
+		}
+	case TypeTXT:
+		for len(data) > 0 {
+			l := int(data[0])
+			if 1+l > len(data) {
+				return r, 0, ErrMalformed
+			}
+			if l > 0 {
+				r.Text = append(r.Text, string(data[1:1+l]))
+			}
+			data = data[1+l:]
+		}
+	default:
+		r.Data = append([]byte(nil), data...)
+	}
+	return r, end, nil
+}
+
+// readName decodes the possibly compressed name at off, and returns the offset after it.
+func readName(msg []byte, off int) (string, int, error) {
+	var b strings.Builder
+	end := -1 // offset after the name where it started, before the first pointer
+	for jumps := 0; ; {
+		if off >= len(msg) {
+			return "", 0, ErrMalformed
+		}
+		l := int(msg[off])
+		switch {
+		case l == 0:
+			if end < 0 {
+				end = off + 1
+			}
+			return b.String(), end, nil
+		case l&0xc0 == 0xc0:
+			jumps++
+			if off+1 >= len(msg) || jumps > 16 {
+				return "", 0, ErrMalformed // truncated, or a loop
+			}
+			if end < 0 {
+				end = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+		case l&0xc0 != 0:
+			return "", 0, ErrMalformed
+		default:
+			if off+1+l > len(msg) {
+				return "", 0, ErrMalformed
+			}
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			for _, c := range msg[off+1 : off+1+l] {
+				if c == '.' || c == '\\' {
+					b.WriteByte('\\')
+				}
+				b.WriteByte(c)
+			}
+			off += 1 + l
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Package icmp sends ICMP echo requests over IPv4 and matches the replies, for the
+// host scan and the gateway probes of this module.
+//
+// It prefers the unprivileged datagram ICMP sockets of Linux and macOS, which Linux only
+// allows to the groups in net.ipv4.ping_group_range, and falls back to a raw socket,
+// which needs root or administrator rights.
+package icmp
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+)
+
+// Message types of echo requests and replies, from RFC 792.
+const (
+	typeEchoReply   = 0
+	typeEchoRequest = 8
+	headerLen       = 8
+)
+
+// Conn sends echo requests from one socket. Ping may be called concurrently.
+type Conn struct {
+	conn net.PacketConn
+	raw  bool   // whether the socket is raw and sees the replies of other processes
+	id   uint16 // identifier of the requests; the kernel rewrites it on datagram sockets
+
+	mu      sync.Mutex
+	seq     uint16
+	waiting map[key]chan struct{}
+}
+
+// key identifies an outstanding request.
+type key struct {
+	addr netip.Addr
+	seq  uint16
+}
+
+// Listen opens a socket on the IPv4 address laddr, which may be unspecified.
+func Listen(laddr netip.Addr) (*Conn, error) {
+	if !laddr.IsValid() {
+		laddr = netip.IPv4Unspecified()
+	}
+	if !laddr.Is4() {
+		return nil, fmt.Errorf("icmp: %s is not an IPv4 address", laddr)
+	}
+	c := &Conn{waiting: make(map[key]chan struct{})}
+	conn, derr := listenDatagram(laddr)
+	if derr != nil {
+		var rerr error
+		conn, rerr = net.ListenPacket("ip4:icmp", laddr.String())
+		if rerr != nil {
+			return nil, fmt.Errorf("icmp: %w", errors.Join(derr, rerr))
+		}
+		c.raw = true
+	}
+	c.conn = conn
+	c.id = uint16(time.Now().UnixNano())
+	go c.read()
+	return c, nil
+}
+
+// Close closes the socket. Pending calls of Ping return when their contexts end.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// Ping sends an echo request to dst and waits for the reply until ctx is done.
+//
+// Returns:
+//   - time.Duration: The round trip time
+//   - error: ctx.Err() if no reply came in time, or an error if the request cannot be sent
+func (c *Conn) Ping(ctx context.Context, dst netip.Addr) (time.Duration, error) {
+	dst = dst.Unmap()
+	c.mu.Lock()
+	c.seq++
+	k := key{dst, c.seq}
+	done := make(chan struct{})
+	c.waiting[k] = done
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.waiting, k)
+		c.mu.Unlock()
+	}()
+
+	msg := make([]byte, headerLen+8)
+	msg[0] = typeEchoRequest
+	binary.BigEndian.PutUint16(msg[4:], c.id)
+	binary.BigEndian.PutUint16(msg[6:], k.seq)
+	copy(msg[headerLen:], "localadr")
+	binary.BigEndian.PutUint16(msg[2:], checksum(msg))
+	var to net.Addr = &net.IPAddr{IP: dst.AsSlice()}
+	if !c.raw {
+		to = &net.UDPAddr{IP: dst.AsSlice()}
+	}
+	start := time.Now()
+	if _, err := c.conn.WriteTo(msg, to); err != nil {
+		return 0, err
+	}
+	select {
+	case <-done:
+		return time.Since(start), nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// read hands the replies to the calls of Ping waiting for them, until the socket is closed.
+func (c *Conn) read() {
+	buf := make([]byte, 1500)
+	for {
+		n, from, err := c.conn.ReadFrom(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return
+		}
+		b, ok := stripIPHeader(buf[:n])
+		if !ok || len(b) < headerLen || b[0] != typeEchoReply {
+			continue
+		}
+		if c.raw && binary.BigEndian.Uint16(b[4:]) != c.id {
+			continue // a reply to another process
+		}
+		var src netip.Addr
+		switch a := from.(type) {
+		case *net.IPAddr:
+			src, _ = netip.AddrFromSlice(a.IP)
+		case *net.UDPAddr:
+			src, _ = netip.AddrFromSlice(a.IP)
+		}
+		k := key{src.Unmap(), binary.BigEndian.Uint16(b[6:])}
+		c.mu.Lock()
+		if done, ok := c.waiting[k]; ok {
+			close(done)
+			delete(c.waiting, k)
+		}
+		c.mu.Unlock()
+	}
+}
+
+// stripIPHeader returns the ICMP message of b without the IPv4 header that macOS puts
+// in front of it on datagram sockets. It reports false if the header claims more bytes
+// than b has.
+func stripIPHeader(b []byte) ([]byte, bool) {
+	if len(b) < 20 || b[0]>>4 != 4 {
+		return b, true
+	}
+	ihl := int(b[0]&0x0f) * 4
+	if ihl < 20 || ihl > len(b) {
+		return nil, false
+	}
+	return b[ihl:], true
+}
+
+// checksum computes the Internet checksum of RFC 1071 over b, whose checksum field is zero.
+func checksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(b[i:]))
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum > 0xffff {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
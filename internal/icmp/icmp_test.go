@@ -0,0 +1,32 @@
+package icmp
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStripIPHeader(t *testing.T) {
+	reply := []byte{typeEchoReply, 0, 0, 0, 0, 1, 0, 2}
+	header := append([]byte{0x45}, make([]byte, 19)...)
+
+	tests := []struct {
+		name string
+		b    []byte
+		want []byte
+		ok   bool
+	}{
+		{"without header", reply, reply, true},
+		{"with header", append(header, reply...), reply, true},
+		{"with options", append(append([]byte{0x46}, make([]byte, 23)...), reply...), reply, true},
+		{"length beyond the packet", append([]byte{0x4f}, make([]byte, 27)...), nil, false},
+		{"length below the minimum", append([]byte{0x44}, make([]byte, 27)...), nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := stripIPHeader(tt.b)
+			if ok != tt.ok || !bytes.Equal(got, tt.want) {
+				t.Errorf("stripIPHeader() = %v, %v, want %v, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !darwin
+
+package icmp
+
+import (
+	"errors"
+	"net"
+	"net/netip"
+)
+
+// listenDatagram fails: unprivileged ICMP sockets only exist on Linux and macOS.
+func listenDatagram(netip.Addr) (net.PacketConn, error) {
+	return nil, errors.New("datagram ICMP sockets are not supported")
+}
@@ -0,0 +1,25 @@
+//go:build linux || darwin
+
+package icmp
+
+import (
+	"net"
+	"net/netip"
+	"os"
+	"syscall"
+)
+
+// listenDatagram opens an unprivileged datagram ICMP socket bound to laddr.
+func listenDatagram(laddr netip.Addr) (net.PacketConn, error) {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM, syscall.IPPROTO_ICMP)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrInet4{Addr: laddr.As4()}); err != nil {
+		syscall.Close(fd)
+		return nil, os.NewSyscallError("bind", err)
+	}
+	f := os.NewFile(uintptr(fd), "icmp")
+	defer f.Close() // FilePacketConn dups the descriptor
+	return net.FilePacketConn(f)
+}
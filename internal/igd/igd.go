@@ -0,0 +1,298 @@
+// Package igd talks to routers with the UPnP Internet Gateway Device protocol: it finds
+// the router with SSDP, reads its device description, and calls the actions of its WAN
+// connection service over SOAP.
+package igd
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golovingreg/localaddr"
+	"github.com/golovingreg/localaddr/ssdp"
+)
+
+// ErrNoGateway is returned by Discover when no router on the LAN speaks UPnP IGD, or
+// UPnP is turned off on it, as many routers ship.
+var ErrNoGateway = errors.New("igd: no UPnP internet gateway found")
+
+// searchWait bounds the SSDP search of Discover; routers answer within a second.
+const searchWait = 2 * time.Second
+
+// services are the WAN services that carry the actions, in the order of preference.
+var services = []string{
+	"urn:schemas-upnp-org:service:WANIPConnection:2",
+	"urn:schemas-upnp-org:service:WANIPConnection:1",
+	"urn:schemas-upnp-org:service:WANPPPConnection:1",
+}
+
+// Client calls the actions of the WAN connection service of a router.
+type Client struct {
+	Addr       netip.Addr // address of the router
+	Service    string     // service type, one of services
+	ControlURL string     // where the actions are posted
+	Local      netip.Addr // local address the router was found from
+	HTTP       *http.Client
+}
+
+// Discover finds the router on the LAN of the address that localaddr.Get(opts...)
+// selects. If several devices answer, the one of the default gateway is preferred.
+func Discover(ctx context.Context, opts ...localaddr.Option) (*Client, error) {
+	local, err := localaddr.GetAddr(opts...)
+	if err != nil {
+		return nil, err
+	}
+	gateway, _, _ := localaddr.Gateway(opts...)
+	search, cancel := context.WithTimeout(ctx, searchWait)
+	defer cancel()
+	devices, err := ssdp.Search(search, ssdp.InternetGateway, opts...) // IGD:2 routers answer too
+	if err != nil {
+		return nil, err
+	}
+	for i, d := range devices {
+		if d.Addr == gateway {
+			devices[0], devices[i] = devices[i], devices[0]
+			break
+		}
+	}
+	var errs []error
+	for _, d := range devices {
+		c, err := describe(ctx, d)
+		if err == nil {
+			c.Local = local
+			return c, nil
+		}
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%w: %w", ErrNoGateway, errors.Join(errs...))
+	}
+	return nil, ErrNoGateway
+}
+
+// device is a device of a description document, with its embedded devices.
+type device struct {
+	Type     string `xml:"deviceType"`
+	Services []struct {
+		Type       string `xml:"serviceType"`
+		ControlURL string `xml:"controlURL"`
+	} `xml:"serviceList>service"`
+	Devices []device `xml:"deviceList>device"`
+}
+
+// describe reads the description of d and returns a client for its WAN service.
+func describe(ctx context.Context, d ssdp.Device) (*Client, error) {
+	base, err := url.Parse(d.Location)
+	if err != nil {
+		return nil, err
+	}
+	// A device may only point to itself; anything else on the LAN could otherwise make
+	// the caller send requests to arbitrary hosts.
+	if host, err := netip.ParseAddr(base.Hostname()); err != nil || host.WithZone("") != d.Addr {
+		return nil, fmt.Errorf("igd: %s: location %s is not on the device", d.Addr, d.Location)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.Location, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("igd: %s: %s", d.Location, resp.Status)
+	}
+	var root struct {
+		URLBase string `xml:"URLBase"`
+		Device  device `xml:"device"`
+	}
+	if err := xml.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&root); err != nil {
+		return nil, fmt.Errorf("igd: %s: %w", d.Location, err)
+	}
+	if root.URLBase != "" {
+		if u, err := url.Parse(root.URLBase); err == nil && u.Host == base.Host {
+			base = u
+		}
+	}
+	for _, want := range services {
+		if control := findService(&root.Device, want); control != "" {
+			u, err := base.Parse(control)
+			if err != nil || u.Host != base.Host {
+				return nil, fmt.Errorf("igd: %s: invalid control URL %q", d.Location, control)
+			}
+			return &Client{Addr: d.Addr, Service: want, ControlURL: u.String(), HTTP: http.DefaultClient}, nil
+		}
+	}
+	return nil, fmt.Errorf("igd: %s: no WAN connection service", d.Location)
+}
+
+// findService returns the control URL of the service of type typ in d or its embedded
+// devices, or "" if there is none.
+func findService(d *device, typ string) string {
+	for _, s := range d.Services {
+		if s.Type == typ {
+			return s.ControlURL
+		}
+	}
+	for i := range d.Devices {
+		if u := findService(&d.Devices[i], typ); u != "" {
+			return u
+		}
+	}
+	return ""
+}
+
+// ExternalIP returns the WAN address of the router.
+func (c *Client) ExternalIP(ctx context.Context) (netip.Addr, error) {
+	out, err := c.Call(ctx, "GetExternalIPAddress", nil)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	addr, err := netip.ParseAddr(strings.TrimSpace(out["NewExternalIPAddress"]))
+	if err != nil {
+		// Routers without a WAN connection answer with an empty address.
+		return netip.Addr{}, fmt.Errorf("igd: router %s reports no external address", c.Addr)
+	}
+	return addr, nil
+}
+
+// Error is a UPnP error returned by an action, e.g. 718 ConflictInMappingEntry.
+type Error struct {
+	Action      string
+	Code        int
+	Description string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("igd: %s: error %d %s", e.Action, e.Code, e.Description)
+}
+
+// Call invokes action with the arguments in args, given as name and value pairs in
+// the order the action defines them, and returns the output arguments by name.
+func (c *Client) Call(ctx context.Context, action string, args [][2]string) (map[string]string, error) {
+	var body bytes.Buffer
+	fmt.Fprintf(&body, `<?xml version="1.0"?>`+
+		`<s:Envelope xmlns:s="http://schemas.xmlsoap.org/soap/envelope/" s:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">`+
+		`<s:Body><u:%s xmlns:u="%s">`, action, c.Service)
+	for _, a := range args {
+		fmt.Fprintf(&body, "<%s>%s</%s>", a[0], html.EscapeString(a[1]), a[0])
+	}
+	fmt.Fprintf(&body, "</u:%s></s:Body></s:Envelope>", action)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.ControlURL, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", `text/xml; charset="utf-8"`)
+	req.Header.Set("SOAPAction", `"`+c.Service+"#"+action+`"`)
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	values, err := leaves(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		e := &Error{Action: action, Description: values["errorDescription"]}
+		if _, err := fmt.Sscan(values["errorCode"], &e.Code); err != nil {
+			return nil, fmt.Errorf("igd: %s: %s", action, resp.Status)
+		}
+		return nil, e
+	}
+	if err != nil {
+		return nil, fmt.Errorf("igd: %s: %w", action, err)
+	}
+	return values, nil
+}
+
+// leaves returns the text of the elements without children of an XML document, by
+// local name. SOAP wraps the interesting values in several layers that differ between
+// routers; their names do not collide.
+func leaves(r io.Reader) (map[string]string, error) {
+	values := map[string]string{}
+	dec := xml.NewDecoder(r)
+	var name string
+	var text strings.Builder
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name = t.Name.Local
+			text.Reset()
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if name == t.Name.Local {
+				values[name] = text.String()
+			}
+			name = ""
+		}
+	}
+}
+
+// AddPortMapping forwards external, a port of the WAN address, to the internal port of
+// client for lease, or until deleted if lease is zero. protocol is "TCP" or "UDP". If
+// external is zero, the router picks the port; that takes IGD version 2.
+//
+// Routers that keep only permanent mappings (error 725) are asked again without a lease.
+// It returns the external port and the lease granted.
+func (c *Client) AddPortMapping(ctx context.Context, protocol string, external, internal int, client netip.Addr, lease time.Duration, description string) (int, time.Duration, error) {
+	for {
+		args := [][2]string{
+			{"NewRemoteHost", ""},
+			{"NewExternalPort", strconv.Itoa(external)},
+			{"NewProtocol", protocol},
+			{"NewInternalPort", strconv.Itoa(internal)},
+			{"NewInternalClient", client.String()},
+			{"NewEnabled", "1"},
+			{"NewPortMappingDescription", description},
+			{"NewLeaseDuration", strconv.Itoa(int(lease / time.Second))},
+		}
+		var err error
+		if external == 0 {
+			if !strings.HasSuffix(c.Service, ":2") {
+				return 0, 0, fmt.Errorf("igd: router %s cannot pick an external port, give one", c.Addr)
+			}
+			var out map[string]string
+			if out, err = c.Call(ctx, "AddAnyPortMapping", args); err == nil {
+				port, err := strconv.Atoi(strings.TrimSpace(out["NewReservedPort"]))
+				if err != nil {
+					return 0, 0, fmt.Errorf("igd: AddAnyPortMapping: invalid port %q", out["NewReservedPort"])
+				}
+				return port, lease, nil
+			}
+		} else if _, err = c.Call(ctx, "AddPortMapping", args); err == nil {
+			return external, lease, nil
+		}
+		var e *Error
+		if lease == 0 || !errors.As(err, &e) || e.Code != 725 {
+			return 0, 0, err
+		}
+		lease = 0 // OnlyPermanentLeasesSupported
+	}
+}
+
+// DeletePortMapping removes the mapping of the external port.
+func (c *Client) DeletePortMapping(ctx context.Context, protocol string, external int) error {
+	_, err := c.Call(ctx, "DeletePortMapping", [][2]string{
+		{"NewRemoteHost", ""},
+		{"NewExternalPort", strconv.Itoa(external)},
+		{"NewProtocol", protocol},
+	})
+	return err
+}
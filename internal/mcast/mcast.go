@@ -0,0 +1,90 @@
+// Package mcast opens UDP sockets for the link-local multicast protocols of this module,
+// such as mDNS and SSDP, on a given interface.
+package mcast
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+// Listen joins group on iface and returns a socket bound to the group's port, from which
+// packets to the group go out on iface.
+//
+// Unlike with net.ListenMulticastUDP, which it builds on, the socket's packets are also
+// delivered to the machine itself, so that a query reaches a responder running on the
+// same machine, such as Avahi answering for the host's own name.
+func Listen(iface *net.Interface, group netip.AddrPort) (*net.UDPConn, error) {
+	network := "udp4"
+	if group.Addr().Is6() {
+		network = "udp6"
+	}
+	conn, err := net.ListenMulticastUDP(network, iface, net.UDPAddrFromAddrPort(group))
+	if err != nil {
+		return nil, err
+	}
+	if err := control(conn, func(fd uintptr) error { return setLoopback(fd, group.Addr().Is6(), true) }); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("enabling multicast loopback: %w", err)
+	}
+	return conn, nil
+}
+
+// Sender returns a socket bound to an ephemeral port of laddr, an address of iface, from
+// which multicasts go out on iface, including to the machine itself. Unlike Listen, it
+// receives only the unicast answers to what it sends, as SSDP searches need.
+func Sender(iface *net.Interface, laddr netip.Addr) (*net.UDPConn, error) {
+	network := "udp4"
+	if laddr.Is6() {
+		network = "udp6"
+	}
+	conn, err := net.ListenUDP(network, net.UDPAddrFromAddrPort(netip.AddrPortFrom(laddr, 0)))
+	if err != nil {
+		return nil, err
+	}
+	var ip4 [4]byte
+	if laddr.Is4() {
+		ip4 = laddr.As4()
+	}
+	err = control(conn, func(fd uintptr) error {
+		if err := setInterface(fd, iface.Index, ip4, laddr.Is6()); err != nil {
+			return err
+		}
+		return setLoopback(fd, laddr.Is6(), true)
+	})
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("selecting multicast interface %s: %w", iface.Name, err)
+	}
+	return conn, nil
+}
+
+// SetLoopback turns the delivery of the multicasts that conn sends to the machine itself
+// on or off. Listen and Sender turn it on.
+func SetLoopback(conn *net.UDPConn, on bool) error {
+	v6 := conn.LocalAddr().(*net.UDPAddr).IP.To4() == nil
+	return control(conn, func(fd uintptr) error { return setLoopback(fd, v6, on) })
+}
+
+// control runs f on the file descriptor of conn.
+func control(conn *net.UDPConn, f func(fd uintptr) error) error {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var ferr error
+	if err := raw.Control(func(fd uintptr) { ferr = f(fd) }); err != nil {
+		return err
+	}
+	return ferr
+}
+
+// Dest returns the address to send to group on iface. IPv6 link-local groups need the
+// interface as zone.
+func Dest(iface *net.Interface, group netip.AddrPort) *net.UDPAddr {
+	addr := net.UDPAddrFromAddrPort(group)
+	if group.Addr().Is6() {
+		addr.Zone = iface.Name
+	}
+	return addr
+}
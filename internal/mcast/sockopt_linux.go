@@ -0,0 +1,24 @@
+package mcast
+
+import "syscall"
+
+// setLoopback turns multicast loopback on the socket fd on or off.
+func setLoopback(fd uintptr, v6, on bool) error {
+	v := 0
+	if on {
+		v = 1
+	}
+	if v6 {
+		return syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IPV6, syscall.IPV6_MULTICAST_LOOP, v)
+	}
+	return syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_MULTICAST_LOOP, v)
+}
+
+// setInterface makes the socket fd send multicasts through the interface with index,
+// whose IPv4 address is ip.
+func setInterface(fd uintptr, index int, ip [4]byte, v6 bool) error {
+	if v6 {
+		return syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IPV6, syscall.IPV6_MULTICAST_IF, index)
+	}
+	return syscall.SetsockoptInet4Addr(int(fd), syscall.IPPROTO_IP, syscall.IP_MULTICAST_IF, ip)
+}
@@ -0,0 +1,14 @@
+//go:build !linux && !windows && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package mcast
+
+// setLoopback leaves the socket as it is; this platform's socket options are unknown.
+func setLoopback(fd uintptr, v6, on bool) error {
+	return nil
+}
+
+// setInterface leaves the socket as it is; the interface follows from the bound address
+// if at all.
+func setInterface(fd uintptr, index int, ip [4]byte, v6 bool) error {
+	return nil
+}
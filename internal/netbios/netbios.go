@@ -0,0 +1,116 @@
+// Package netbios implements the node status request of the NetBIOS name service
+// (RFC 1002), which asks a machine for the names it has registered, such as the
+// computer name of a Windows machine. Its messages have the format of DNS messages, so
+// requests are packed with package dns; the answers are parsed here, since their record
+// type is that of DNS SRV records, which package dns would decode as such.
+package netbios
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+
+	"github.com/golovingreg/localaddr/internal/dns"
+)
+
+// Port is the UDP port of the name service.
+const Port = 137
+
+// TypeNBSTAT is the record type of node status requests and their answers.
+const TypeNBSTAT = 0x21
+
+// SuffixWorkstation is the suffix, the 16th byte, of the computer name, and of the
+// workgroup among the group names.
+const SuffixWorkstation = 0x00
+
+// Flags of answers, and of the entries of their name table.
+const (
+	flagResponse = 0x8000
+	flagGroup    = 0x8000 // a group name, such as the workgroup
+)
+
+// ErrMalformed is returned by ParseNodeStatus for answers that cannot be decoded.
+var ErrMalformed = errors.New("netbios: malformed node status")
+
+// Name is an entry of the name table of a node status answer.
+type Name struct {
+	Name   string // without the padding, e.g. "DESKTOP-1234"
+	Suffix byte   // e.g. SuffixWorkstation
+	Group  bool   // a group name, such as the workgroup, rather than a name of the machine
+}
+
+// EncodeName returns the first-level encoding (RFC 1001, section 14.1) of name padded
+// to 15 bytes with suffix as the 16th, the 32 letters that go into a DNS label. The
+// name "*" is padded with zeros, as node status requests need it.
+func EncodeName(name string, suffix byte) string {
+	pad := byte(' ')
+	if name == "*" {
+		pad = 0
+	}
+	var raw [16]byte
+	for i := range raw[:15] {
+		raw[i] = pad
+	}
+	copy(raw[:15], strings.ToUpper(name))
+	raw[15] = suffix
+	var b strings.Builder
+	for _, c := range raw {
+		b.WriteByte('A' + c>>4)
+		b.WriteByte('A' + c&0xf)
+	}
+	return b.String()
+}
+
+// NodeStatus returns the node status request with the given ID, asking for the names
+// of the machine it is sent to.
+func NodeStatus(id uint16) ([]byte, error) {
+	return (&dns.Message{
+		ID:        id,
+		Questions: []dns.Question{{Name: EncodeName("*", 0), Type: TypeNBSTAT, Class: dns.ClassINET}},
+	}).Pack()
+}
+
+// ParseNodeStatus decodes the name table of the answer msg to the node status request
+// with the given ID. It returns ErrMalformed also for messages that
+// are not such an answer, e.g. requests or answers to other requests.
+func ParseNodeStatus(msg []byte, id uint16) ([]Name, error) {
+	if len(msg) < 12 || binary.BigEndian.Uint16(msg) != id || binary.BigEndian.Uint16(msg[2:])&flagResponse == 0 {
+		return nil, ErrMalformed
+	}
+	if binary.BigEndian.Uint16(msg[4:]) != 0 || binary.BigEndian.Uint16(msg[6:]) == 0 {
+		return nil, ErrMalformed // answers have no questions, and one record
+	}
+	off := 12
+	for off < len(msg) && msg[off] != 0 && msg[off]&0xc0 == 0 {
+		off += 1 + int(msg[off])
+	}
+	switch {
+	case off >= len(msg):
+		return nil, ErrMalformed
+	case msg[off] == 0:
+		off++
+	default:
+		off += 2 // a pointer, which ends the name
+	}
+	if off+10 > len(msg) || binary.BigEndian.Uint16(msg[off:]) != TypeNBSTAT {
+		return nil, ErrMalformed
+	}
+	size, data := int(binary.BigEndian.Uint16(msg[off+8:])), msg[off+10:]
+	if size < 1 || size > len(data) {
+		return nil, ErrMalformed
+	}
+	n, data := int(data[0]), data[1:size]
+	if len(data) < 18*n {
+		return nil, ErrMalformed
+	}
+	names := make([]Name, n)
+	for i := range names {
+		e := data[18*i : 18*i+18]
+		names[i] = Name{
+			Name:   strings.TrimRight(string(e[:15]), " \x00"),
+			Suffix: e[15],
+			Group:  binary.BigEndian.Uint16(e[16:])&flagGroup != 0,
+		}
+	}
+	return names, nil
+}
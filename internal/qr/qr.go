@@ -0,0 +1,395 @@
+// Package qr encodes short texts, such as URLs, as QR codes (ISO/IEC 18004).
+//
+// Only what the command line tool needs is implemented: byte mode, error
+// correction level L, and versions 1 to 10, which hold up to 271 bytes.
+package qr
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrTooLong is returned for texts that do not fit in a version 10 code.
+var ErrTooLong = errors.New("qr: text too long")
+
+// version describes the codeword layout of one version at error correction level L.
+type version struct {
+	ecLen     int   // error correction codewords per block
+	blocks    []int // data codewords of each block
+	alignment []int // alignment pattern centers
+}
+
+var versions = [...]version{
+	1:  {7, []int{19}, nil},
+	2:  {10, []int{34}, []int{6, 18}},
+	3:  {15, []int{55}, []int{6, 22}},
+	4:  {20, []int{80}, []int{6, 26}},
+	5:  {26, []int{108}, []int{6, 30}},
+	6:  {18, []int{68, 68}, []int{6, 34}},
+	7:  {20, []int{78, 78}, []int{6, 22, 38}},
+	8:  {24, []int{97, 97}, []int{6, 24, 42}},
+	9:  {30, []int{116, 116}, []int{6, 26, 46}},
+	10: {18, []int{68, 68, 69, 69}, []int{6, 28, 50}},
+}
+
+// Code is an encoded QR code.
+type Code struct {
+	Size    int // width and height in modules, without a quiet zone
+	modules []bool
+	fixed   []bool // function patterns, which are not masked
+}
+
+// Black reports whether the module at column x and row y is dark.
+// Coordinates outside the code are light, so callers can draw a quiet zone.
+func (c *Code) Black(x, y int) bool {
+	return x >= 0 && y >= 0 && x < c.Size && y < c.Size && c.modules[y*c.Size+x]
+}
+
+// Encode returns the smallest QR code holding text.
+func Encode(text string) (*Code, error) {
+	for v := 1; v < len(versions); v++ {
+		countBits := 8
+		if v >= 10 {
+			countBits = 16
+		}
+		capacity := 0
+		for _, n := range versions[v].blocks {
+			capacity += n
+		}
+		if 4+countBits+8*len(text) > 8*capacity {
+			continue
+		}
+		var b bitBuffer
+		b.write(0b0100, 4) // byte mode
+		b.write(len(text), countBits)
+		for i := 0; i < len(text); i++ {
+			b.write(int(text[i]), 8)
+		}
+		return build(v, b.finish(capacity)), nil
+	}
+	return nil, ErrTooLong
+}
+
+// build lays out data, padded to the capacity of version v, into a masked code.
+func build(v int, data []byte) *Code {
+	size := 17 + 4*v
+	c := &Code{Size: size, modules: make([]bool, size*size), fixed: make([]bool, size*size)}
+	c.drawFunctionPatterns(v)
+	c.drawCodewords(interleave(versions[v], data))
+
+	best, bestPenalty := 0, math.MaxInt
+	for mask := 0; mask < 8; mask++ {
+		c.applyMask(mask)
+		c.drawFormat(mask)
+		if p := c.penalty(); p < bestPenalty {
+			best, bestPenalty = mask, p
+		}
+		c.applyMask(mask) // masking is its own inverse
+	}
+	c.applyMask(best)
+	c.drawFormat(best)
+	return c
+}
+
+// set sets a function module.
+func (c *Code) set(x, y int, dark bool) {
+	c.modules[y*c.Size+x] = dark
+	c.fixed[y*c.Size+x] = true
+}
+
+// drawFunctionPatterns draws the timing, finder, and alignment patterns, reserves the
+// format area, and draws the version information.
+func (c *Code) drawFunctionPatterns(v int) {
+	size := c.Size
+	for i := 0; i < size; i++ {
+		c.set(6, i, i%2 == 0)
+		c.set(i, 6, i%2 == 0)
+	}
+	for _, p := range [][2]int{{3, 3}, {size - 4, 3}, {3, size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := p[0]+dx, p[1]+dy
+				if x >= 0 && y >= 0 && x < size && y < size {
+					d := max(abs(dx), abs(dy))
+					c.set(x, y, d != 2 && d != 4) // the ring at 4 is the separator
+				}
+			}
+		}
+	}
+	align := versions[v].alignment
+	last := len(align) - 1
+	for i, y := range align {
+		for j, x := range align {
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue // overlaps a finder pattern
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					c.set(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	c.drawFormat(0)
+	if v >= 7 {
+		bits := v<<12 | bch(v, 12, 0x1f25)
+		for i := 0; i < 18; i++ {
+			dark := bits>>i&1 != 0
+			a, b := size-11+i%3, i/3
+			c.set(a, b, dark)
+			c.set(b, a, dark)
+		}
+	}
+}
+
+// drawFormat draws both copies of the format information for mask, and the dark module.
+func (c *Code) drawFormat(mask int) {
+	data := 0b01<<3 | mask // level L
+	bits := (data<<10 | bch(data, 10, 0x537)) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 != 0 }
+	size := c.Size
+	for i := 0; i < 6; i++ {
+		c.set(8, i, bit(i))
+	}
+	c.set(8, 7, bit(6))
+	c.set(8, 8, bit(7))
+	c.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		c.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		c.set(size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		c.set(8, size-15+i, bit(i))
+	}
+	c.set(8, size-8, true)
+}
+
+// drawCodewords places data in the zigzag order of the standard, skipping function modules.
+func (c *Code) drawCodewords(data []byte) {
+	size := c.Size
+	i := 0
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5 // skip the vertical timing pattern
+		}
+		upward := (right+1)&2 == 0
+		for vert := 0; vert < size; vert++ {
+			y := vert
+			if upward {
+				y = size - 1 - vert
+			}
+			for j := 0; j < 2; j++ {
+				x := right - j
+				if c.fixed[y*size+x] || i >= 8*len(data) {
+					continue // remainder bits stay light
+				}
+				c.modules[y*size+x] = data[i>>3]>>(7-i&7)&1 != 0
+				i++
+			}
+		}
+	}
+}
+
+// applyMask inverts the data modules selected by mask.
+func (c *Code) applyMask(mask int) {
+	for y := 0; y < c.Size; y++ {
+		for x := 0; x < c.Size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !c.fixed[y*c.Size+x] {
+				c.modules[y*c.Size+x] = !c.modules[y*c.Size+x]
+			}
+		}
+	}
+}
+
+// penalty scores the code with the four rules of the standard; lower is better.
+func (c *Code) penalty() int {
+	size := c.Size
+	p, dark := 0, 0
+	for pass := 0; pass < 2; pass++ { // rows, then columns
+		at := func(i, j int) bool {
+			if pass == 0 {
+				return c.Black(j, i)
+			}
+			return c.Black(i, j)
+		}
+		for i := 0; i < size; i++ {
+			run := 0
+			for j := 0; j < size; j++ {
+				if j > 0 && at(i, j) == at(i, j-1) {
+					run++
+				} else {
+					run = 1
+				}
+				if run == 5 {
+					p += 3
+				} else if run > 5 {
+					p++
+				}
+				if j+11 <= size && finderLike(func(k int) bool { return at(i, j+k) }) {
+					p += 40
+				}
+			}
+		}
+	}
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			b := c.Black(x, y)
+			if b {
+				dark++
+			}
+			if x+1 < size && y+1 < size && b == c.Black(x+1, y) && b == c.Black(x, y+1) && b == c.Black(x+1, y+1) {
+				p += 3
+			}
+		}
+	}
+	total := size * size
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return p + k*10
+}
+
+// finderLike reports whether the 11 modules from at(0) look like a finder pattern
+// next to four light modules, in either direction.
+func finderLike(at func(int) bool) bool {
+	const pattern = 0b10111010000
+	var bits int
+	for k := 0; k < 11; k++ {
+		bits <<= 1
+		if at(k) {
+			bits |= 1
+		}
+	}
+	return bits == pattern || bits == 0b00001011101
+}
+
+// interleave splits data into the blocks of v, appends their error correction
+// codewords, and interleaves the result.
+func interleave(v version, data []byte) []byte {
+	var blocks, ecs [][]byte
+	for _, n := range v.blocks {
+		blocks = append(blocks, data[:n])
+		ecs = append(ecs, reedSolomon(data[:n], v.ecLen))
+		data = data[n:]
+	}
+	var out []byte
+	for i := 0; i < v.blocks[len(v.blocks)-1]; i++ {
+		for _, b := range blocks {
+			if i < len(b) {
+				out = append(out, b[i])
+			}
+		}
+	}
+	for i := 0; i < v.ecLen; i++ {
+		for _, ec := range ecs {
+			out = append(out, ec[i])
+		}
+	}
+	return out
+}
+
+// reedSolomon returns the n error correction codewords of data.
+func reedSolomon(data []byte, n int) []byte {
+	gen := []byte{1} // coefficients of the generator, highest degree first
+	for i := 0; i < n; i++ {
+		next := make([]byte, len(gen)+1)
+		for j, g := range gen {
+			next[j] ^= g
+			next[j+1] ^= gfMul(g, gfExp[i])
+		}
+		gen = next
+	}
+	rem := make([]byte, n)
+	for _, d := range data {
+		factor := d ^ rem[0]
+		copy(rem, rem[1:])
+		rem[n-1] = 0
+		for j := range rem {
+			rem[j] ^= gfMul(gen[j+1], factor)
+		}
+	}
+	return rem
+}
+
+// gfExp holds the powers of 2 in GF(256) with the QR polynomial 0x11d, gfLog their inverse.
+var gfExp, gfLog = func() (exp [256]byte, log [256]byte) {
+	x := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = byte(x)
+		log[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= 0x11d
+		}
+	}
+	return
+}()
+
+func gfMul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return gfExp[(int(gfLog[a])+int(gfLog[b]))%255]
+}
+
+// bch returns the remainder of data shifted left by n bits, divided by poly.
+func bch(data, n, poly int) int {
+	rem := data
+	for i := 0; i < n; i++ {
+		rem = rem<<1 ^ (rem>>(n-1))*poly
+	}
+	return rem & (1<<n - 1)
+}
+
+// bitBuffer accumulates the data bit stream.
+type bitBuffer struct {
+	bytes []byte
+	n     int // bits written
+}
+
+func (b *bitBuffer) write(v, bits int) {
+	for i := bits - 1; i >= 0; i-- {
+		if b.n%8 == 0 {
+			b.bytes = append(b.bytes, 0)
+		}
+		if v>>i&1 != 0 {
+			b.bytes[b.n/8] |= 0x80 >> (b.n % 8)
+		}
+		b.n++
+	}
+}
+
+// finish adds the terminator and pad codewords up to capacity bytes.
+func (b *bitBuffer) finish(capacity int) []byte {
+	b.write(0, min(4, 8*capacity-b.n))
+	for i := 0; len(b.bytes) < capacity; i++ {
+		b.bytes = append(b.bytes, [2]byte{0xec, 0x11}[i%2])
+	}
+	return b.bytes
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,205 @@
+// Package stun implements the subset of STUN (RFC 5389) needed to learn the
+// public address of a socket: Binding requests and their responses, optionally with
+// the CHANGE-REQUEST of RFC 5780 to classify the NAT.
+package stun
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// Message types and attributes used by this package.
+const (
+	bindingRequest  = 0x0001
+	bindingResponse = 0x0101
+	bindingError    = 0x0111
+
+	attrMappedAddress    = 0x0001
+	attrChangeRequest    = 0x0003
+	attrChangedAddress   = 0x0005 // RFC 3489 name of OTHER-ADDRESS
+	attrErrorCode        = 0x0009
+	attrXORMappedAddress = 0x0020
+	attrXORMappedOld     = 0x8020 // pre-RFC 5389 servers
+	attrOtherAddress     = 0x802c
+
+	magicCookie = 0x2112a442
+	headerLen   = 20
+)
+
+// Flags of a CHANGE-REQUEST, asking the server to answer from its other address or port.
+const (
+	ChangeIP   = 0x04
+	ChangePort = 0x02
+)
+
+// Response is the decoded answer to a Binding request.
+type Response struct {
+	Mapped netip.AddrPort // reflexive address as seen by the server
+	Other  netip.AddrPort // alternate address of the server, invalid if it has none
+	Source netip.AddrPort // address the response came from
+}
+
+// Do sends a Binding request to server over conn and waits for the matching response,
+// retransmitting with exponential backoff as described in RFC 5389 section 7.2.1 until
+// ctx is done.
+func Do(ctx context.Context, conn net.PacketConn, server net.Addr) (Response, error) {
+	return DoChange(ctx, conn, server, 0)
+}
+
+// DoChange is like Do, but asks the server to send the response from the address or port
+// given by change, a combination of ChangeIP and ChangePort. Servers that cannot do so
+// answer from their usual address, or not at all.
+func DoChange(ctx context.Context, conn net.PacketConn, server net.Addr, change uint32) (Response, error) {
+	var id [12]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return Response{}, err
+	}
+	packet := encode(id, change)
+	buf := make([]byte, 1500)
+	rto := 500 * time.Millisecond
+	for {
+		if _, err := conn.WriteTo(packet, server); err != nil {
+			return Response{}, err
+		}
+		deadline := time.Now().Add(rto)
+		if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+			deadline = d
+		}
+		if err := conn.SetReadDeadline(deadline); err != nil {
+			return Response{}, err
+		}
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					break // retransmit
+				}
+				return Response{}, err
+			}
+			resp, ok, err := decode(buf[:n], id)
+			if err != nil {
+				return Response{}, err
+			}
+			if ok {
+				if u, isUDP := from.(*net.UDPAddr); isUDP {
+					resp.Source = u.AddrPort()
+				}
+				return resp, nil
+			}
+			// unrelated packet, keep waiting
+		}
+		if err := ctx.Err(); err != nil {
+			return Response{}, err
+		}
+		if rto < 4*time.Second {
+			rto *= 2
+		}
+	}
+}
+
+// encode builds a Binding request with transaction ID id, and a CHANGE-REQUEST if
+// change is not zero.
+func encode(id [12]byte, change uint32) []byte {
+	b := make([]byte, 0, headerLen+8)
+	b = binary.BigEndian.AppendUint16(b, bindingRequest)
+	if change == 0 {
+		b = binary.BigEndian.AppendUint16(b, 0) // no attributes
+	} else {
+		b = binary.BigEndian.AppendUint16(b, 8)
+	}
+	b = binary.BigEndian.AppendUint32(b, magicCookie)
+	b = append(b, id[:]...)
+	if change != 0 {
+		b = binary.BigEndian.AppendUint16(b, attrChangeRequest)
+		b = binary.BigEndian.AppendUint16(b, 4)
+		b = binary.BigEndian.AppendUint32(b, change)
+	}
+	return b
+}
+
+// decode parses a response to the request with transaction ID id. It reports false
+// if b is not such a response.
+func decode(b []byte, id [12]byte) (Response, bool, error) {
+	if len(b) < headerLen || binary.BigEndian.Uint32(b[4:]) != magicCookie || [12]byte(b[8:20]) != id {
+		return Response{}, false, nil
+	}
+	typ := binary.BigEndian.Uint16(b)
+	length := int(binary.BigEndian.Uint16(b[2:]))
+	if typ != bindingResponse && typ != bindingError {
+		return Response{}, false, nil
+	}
+	if headerLen+length > len(b) {
+		return Response{}, false, fmt.Errorf("stun: truncated message")
+	}
+	var resp Response
+	var xorMapped, mapped netip.AddrPort
+	attrs := b[headerLen : headerLen+length]
+	for len(attrs) >= 4 {
+		at := binary.BigEndian.Uint16(attrs)
+		al := int(binary.BigEndian.Uint16(attrs[2:]))
+		if 4+al > len(attrs) {
+			return Response{}, false, fmt.Errorf("stun: truncated attribute")
+		}
+		v := attrs[4 : 4+al]
+		switch at {
+		case attrXORMappedAddress, attrXORMappedOld:
+			xorMapped = parseAddr(v, b[4:20])
+		case attrMappedAddress:
+			mapped = parseAddr(v, nil)
+		case attrOtherAddress, attrChangedAddress:
+			resp.Other = parseAddr(v, nil)
+		case attrErrorCode:
+			if typ == bindingError && len(v) >= 4 {
+				return Response{}, false, fmt.Errorf("stun: error %d: %s", int(v[2]&7)*100+int(v[3]), v[4:])
+			}
+		}
+		next := 4 + (al+3)&^3 // attributes are padded to 4 bytes
+		if next > len(attrs) {
+			break // an unpadded last attribute
+		}
+		attrs = attrs[next:]
+	}
+	if typ == bindingError {
+		return Response{}, false, fmt.Errorf("stun: error response")
+	}
+	resp.Mapped = xorMapped
+	if !resp.Mapped.IsValid() {
+		resp.Mapped = mapped
+	}
+	if !resp.Mapped.IsValid() {
+		return Response{}, false, fmt.Errorf("stun: response without mapped address")
+	}
+	return resp, true, nil
+}
+
+// parseAddr decodes a (XOR-)MAPPED-ADDRESS value. If key is not nil, the address is
+// XORed with it (the magic cookie followed by the transaction ID).
+func parseAddr(v, key []byte) netip.AddrPort {
+	if len(v) < 4 {
+		return netip.AddrPort{}
+	}
+	port := binary.BigEndian.Uint16(v[2:])
+	ip := v[4:]
+	switch {
+	case v[1] == 1 && len(ip) == 4:
+	case v[1] == 2 && len(ip) == 16:
+	default:
+		return netip.AddrPort{}
+	}
+	ip = append([]byte(nil), ip...)
+	if key != nil {
+		port ^= uint16(magicCookie >> 16)
+		for i := range ip {
+			ip[i] ^= key[i]
+		}
+	}
+	addr, _ := netip.AddrFromSlice(ip)
+	return netip.AddrPortFrom(addr, port)
+}
@@ -0,0 +1,80 @@
+package stun
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"testing"
+)
+
+var testID = [12]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+
+// message returns a message of type typ for testID with the given attribute bytes and
+// length field.
+func message(typ uint16, length int, attrs ...byte) []byte {
+	b := binary.BigEndian.AppendUint16(nil, typ)
+	b = binary.BigEndian.AppendUint16(b, uint16(length))
+	b = binary.BigEndian.AppendUint32(b, magicCookie)
+	b = append(b, testID[:]...)
+	return append(b, attrs...)
+}
+
+// attr returns an attribute with value v, padded to 4 bytes unless unpadded.
+func attr(typ uint16, v []byte, unpadded bool) []byte {
+	b := binary.BigEndian.AppendUint16(nil, typ)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(v)))
+	b = append(b, v...)
+	for !unpadded && len(b)%4 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+func TestDecode(t *testing.T) {
+	mapped := attr(attrMappedAddress, []byte{0, 1, 0x1f, 0x90, 203, 0, 113, 7}, false)
+	want := netip.MustParseAddrPort("203.0.113.7:8080")
+	xorValue := []byte{0, 1, 0x1f ^ 0x21, 0x90 ^ 0x12, 203 ^ 0x21, 0 ^ 0x12, 113 ^ 0xa4, 7 ^ 0x42}
+	join := func(parts ...[]byte) []byte {
+		var b []byte
+		for _, p := range parts {
+			b = append(b, p...)
+		}
+		return b
+	}
+	software := attr(0x8022, []byte("x"), true)
+
+	tests := []struct {
+		name string
+		msg  []byte
+		ok   bool
+		err  bool
+	}{
+		{"mapped", message(bindingResponse, len(mapped), mapped...), true, false},
+		{"xor mapped", message(bindingResponse, 12, attr(attrXORMappedAddress, xorValue, false)...), true, false},
+		{"unpadded last attribute", message(bindingResponse, len(mapped)+5, join(mapped, software)...), true, false},
+		{"only an unpadded attribute", message(bindingResponse, 5, software...), false, true},
+		{"truncated attribute", message(bindingResponse, 8, attr(attrMappedAddress, []byte{0, 1, 0x1f, 0x90, 203, 0, 113, 7}, false)[:8]...), false, true},
+		{"truncated message", message(bindingResponse, 12, mapped[:6]...), false, true},
+		{"attribute header cut short", message(bindingResponse, len(mapped)+2, join(mapped, []byte{0x80, 0x22})...), true, false},
+		{"error response", message(bindingError, 8, attr(attrErrorCode, []byte{0, 0, 4, 20}, false)...), false, true},
+		{"request", message(bindingRequest, 0), false, false},
+		{"short", message(bindingResponse, 0)[:12], false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, ok, err := decode(tt.msg, testID)
+			if ok != tt.ok || (err != nil) != tt.err {
+				t.Fatalf("decode() = %v, %v, %v; want ok %v, error %v", resp, ok, err, tt.ok, tt.err)
+			}
+			if ok && resp.Mapped != want {
+				t.Errorf("decode() mapped = %v, want %v", resp.Mapped, want)
+			}
+		})
+	}
+}
+
+func TestDecodeOtherID(t *testing.T) {
+	mapped := attr(attrMappedAddress, []byte{0, 1, 0x1f, 0x90, 203, 0, 113, 7}, false)
+	if _, ok, err := decode(message(bindingResponse, len(mapped), mapped...), [12]byte{}); ok || err != nil {
+		t.Errorf("decode() of another transaction = %v, %v, want false, nil", ok, err)
+	}
+}
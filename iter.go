@@ -0,0 +1,84 @@
+package localaddr
+
+import (
+	"fmt"
+	"iter"
+)
+
+// Addresses returns an iterator over the addresses that GetAll considers, with their
+// interface details, for use with range:
+//
+//	for addr, err := range localaddr.Addresses() {
+//		if err != nil {
+//			return err
+//		}
+//		if ok(addr) {
+//			break
+//		}
+//	}
+//
+// Addresses are yielded while the interfaces are gone through, ordered by interface
+// index and name (or as the operating system reports them, with WithOSOrder), and not
+// ranked: ranking needs all of them first. Stopping early saves the work for the
+// remaining interfaces, such as detecting their kind.
+//
+// An error is yielded, with the zero Address, if the interfaces cannot be enumerated, for
+// each interface whose addresses cannot be read (an *InterfaceError; iteration goes on),
+// and, as for GetAll, at the end if no address was found at all.
+func Addresses(opts ...Option) iter.Seq2[Address, error] {
+	return func(yield func(Address, error) bool) {
+		cfg := newConfig(anyFamily, opts)
+		if cfg.err == nil {
+			for _, c := range cfg.overridden() {
+				if !yield(cfg.address(&c), nil) {
+					return
+				}
+			}
+			if len(cfg.override) > 0 {
+				return
+			}
+		}
+		links, err := cfg.walk()
+		if err != nil {
+			yield(Address{}, err)
+			return
+		}
+		if cfg.iface != "" {
+			l, err := cfg.named(links)
+			if err != nil {
+				yield(Address{}, err)
+				return
+			}
+			links = []link{*l}
+		}
+		yielded, connected := false, false
+		var found []candidate
+		for i := range links {
+			l := &links[i]
+			if cfg.iface == "" && cfg.skip(&l.iface) {
+				continue // an interface asked for by name is always scanned
+			}
+			if l.err != nil {
+				if !yield(Address{}, &InterfaceError{Interface: l.iface.Name, Err: l.err}) {
+					return
+				}
+				continue
+			}
+			found = cfg.collect(found[:0], l)
+			for _, c := range found {
+				yielded = true
+				if !yield(cfg.address(&c), nil) {
+					return
+				}
+			}
+			connected = connected || cfg.hasUsable(l)
+		}
+		if !yielded {
+			err := cfg.notFound(connected)
+			if cfg.iface != "" {
+				err = fmt.Errorf("interface %q: %w", cfg.iface, err)
+			}
+			yield(Address{}, err)
+		}
+	}
+}
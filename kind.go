@@ -0,0 +1,121 @@
+package localaddr
+
+import (
+	"net"
+	"strings"
+)
+
+// Kind is the type of a network interface.
+type Kind int
+
+const (
+	KindUnknown  Kind = iota
+	KindEthernet      // wired Ethernet, including USB and Thunderbolt adapters
+	KindWiFi          // IEEE 802.11 wireless
+	KindCellular      // mobile broadband modem
+	KindLoopback
+	KindTunnel  // VPN and other tunnels: tun, tap, WireGuard, PPP, IPsec, ...
+	KindBridge  // software bridge, e.g. docker0 or virbr0
+	KindVirtual // other software interfaces: veth pairs, VM adapters, dummies, ...
+)
+
+var kindNames = [...]string{
+	KindUnknown:  "unknown",
+	KindEthernet: "ethernet",
+	KindWiFi:     "wifi",
+	KindCellular: "cellular",
+	KindLoopback: "loopback",
+	KindTunnel:   "tunnel",
+	KindBridge:   "bridge",
+	KindVirtual:  "virtual",
+}
+
+// String returns the lower case name of k, e.g. "wifi".
+func (k Kind) String() string {
+	if k < 0 || int(k) >= len(kindNames) {
+		return "unknown"
+	}
+	return kindNames[k]
+}
+
+// InterfaceKind returns the type of iface.
+//
+// It is read from sysfs on Linux, from the interface media on macOS, and from the adapter
+// type reported by GetAdaptersAddresses on Windows. Where that does not settle it, and on
+// other platforms, the type is guessed from the interface name, e.g. wg0 is a tunnel.
+func InterfaceKind(iface net.Interface) Kind {
+	return kindOf(&iface)
+}
+
+// kindOf implements InterfaceKind.
+func kindOf(iface *net.Interface) Kind {
+	if iface.Flags&net.FlagLoopback != 0 {
+		return KindLoopback
+	}
+	if k := detectKind(iface); k != KindUnknown {
+		return k
+	}
+	return kindByName(iface.Name)
+}
+
+// kind returns the type of iface, which is one of the interfaces that cfg selects from.
+// Those of a Provider are not looked up in the operating system, and EnvOverride
+// addresses have none.
+func (cfg *config) kind(iface *net.Interface) Kind {
+	if iface.Name == "" {
+		return KindUnknown
+	}
+	if cfg.provider == nil {
+		return kindOf(iface)
+	}
+	if iface.Flags&net.FlagLoopback != 0 {
+		return KindLoopback
+	}
+	if k := kindByName(iface.Name); k != KindUnknown {
+		return k
+	}
+	if len(iface.HardwareAddr) == 6 && iface.Flags&net.FlagBroadcast != 0 {
+		return KindEthernet // looks like one, and there is nothing to ask
+	}
+	return KindUnknown
+}
+
+// kindPrefixes maps interface name prefixes to kinds, for platforms that cannot tell.
+var kindPrefixes = []struct {
+	prefix string
+	kind   Kind
+}{
+	{"wl", KindWiFi}, // Linux wlan0, wlp2s0, wlx...
+	{"Wi-Fi", KindWiFi},
+	{"WiFi", KindWiFi},
+	{"wwan", KindCellular},
+	{"rmnet", KindCellular},
+	{"pdp_ip", KindCellular}, // iOS
+	{"Cellular", KindCellular},
+	{"tun", KindTunnel},
+	{"tap", KindTunnel},
+	{"utun", KindTunnel},
+	{"wg", KindTunnel},
+	{"ppp", KindTunnel},
+	{"ipsec", KindTunnel},
+	{"docker", KindBridge},
+	{"br-", KindBridge},
+	{"bridge", KindBridge},
+	{"virbr", KindBridge},
+	{"lxcbr", KindBridge},
+	{"lxdbr", KindBridge},
+	{"cni", KindBridge},
+}
+
+// kindByName guesses the kind of an interface from its name.
+func kindByName(name string) Kind {
+	for _, p := range kindPrefixes {
+		if strings.HasPrefix(name, p.prefix) {
+			return p.kind
+		}
+	}
+	if IsVirtual(name) {
+		return KindVirtual
+	}
+	return KindUnknown
+}
@@ -0,0 +1,53 @@
+package localaddr
+
+import (
+	"net"
+	"syscall"
+	"unsafe"
+)
+
+// Media types of struct ifmediareq, from <net/if_media.h>.
+const (
+	ifmNMask     = 0xe0
+	ifmEther     = 0x20
+	ifmIEEE80211 = 0x80
+)
+
+// ifmediareq mirrors struct ifmediareq, which <net/if.h> packs to 4 bytes.
+type ifmediareq struct {
+	name    [syscall.IFNAMSIZ]byte
+	current int32
+	mask    int32
+	status  int32
+	active  int32
+	count   int32
+	ulist   [2]uint32 // int *, unaligned
+}
+
+// detectKind asks the driver of iface for its media type with SIOCGIFMEDIA. Wi-Fi adapters
+// report IEEE 802.11 media; Ethernet ports, including Thunderbolt and USB adapters, report
+// Ethernet. Software interfaces such as bridges also report Ethernet and are told apart
+// by name.
+func detectKind(iface *net.Interface) Kind {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_DGRAM, 0)
+	if err != nil {
+		return KindUnknown
+	}
+	defer syscall.Close(fd)
+	var req ifmediareq
+	copy(req.name[:len(req.name)-1], iface.Name)
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), syscall.SIOCGIFMEDIA, uintptr(unsafe.Pointer(&req)))
+	if errno != 0 {
+		return KindUnknown // e.g. utun and bridge interfaces have no media, see kindByName
+	}
+	switch req.active & ifmNMask {
+	case ifmEther:
+		if k := kindByName(iface.Name); k != KindUnknown {
+			return k // bridge0, vmnet1, ...
+		}
+		return KindEthernet
+	case ifmIEEE80211:
+		return KindWiFi
+	}
+	return KindUnknown
+}
@@ -0,0 +1,85 @@
+package localaddr
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"strings"
+)
+
+// Interface types of sysfs, ARPHRD_* in <linux/if_arp.h>.
+const (
+	arphrdEther    = "1"
+	arphrdPPP      = "512"
+	arphrdTunnel   = "768"
+	arphrdTunnel6  = "769"
+	arphrdSit      = "776"
+	arphrdIPGRE    = "778"
+	arphrdIP6GRE   = "823"
+	arphrdNone     = "65534" // tun devices and WireGuard
+	arphrdRawIP    = "519"   // some cellular modems
+	arphrdLoopback = "772"
+)
+
+// detectKind reads the type of iface from sysfs: the DEVTYPE of its uevent names bridges,
+// Wi-Fi, and cellular modems, the ARP hardware type tells tunnels from Ethernet, and only
+// Ethernet interfaces backed by a device are physical.
+func detectKind(iface *net.Interface) Kind {
+	dir := "/sys/class/net/" + iface.Name + "/"
+	switch devType(dir + "uevent") {
+	case "wlan":
+		return KindWiFi
+	case "wwan":
+		return KindCellular
+	case "bridge":
+		return KindBridge
+	case "wireguard", "ppp", "l2tp":
+		return KindTunnel
+	}
+	if exists(dir+"wireless") || exists(dir+"phy80211") {
+		return KindWiFi
+	}
+	if exists(dir + "tun_flags") {
+		return KindTunnel // tun and tap
+	}
+	typ, err := os.ReadFile(dir + "type")
+	if err != nil {
+		return KindUnknown
+	}
+	switch strings.TrimSpace(string(typ)) {
+	case arphrdEther:
+		if !exists(dir + "device") {
+			return KindVirtual // veth, macvlan, dummy, vlan, ...
+		}
+		return KindEthernet
+	case arphrdPPP, arphrdTunnel, arphrdTunnel6, arphrdSit, arphrdIPGRE, arphrdIP6GRE, arphrdNone:
+		return KindTunnel
+	case arphrdRawIP:
+		return KindCellular
+	case arphrdLoopback:
+		return KindLoopback
+	}
+	return KindUnknown
+}
+
+// devType returns the DEVTYPE of a sysfs uevent file, or "" if it has none.
+func devType(name string) string {
+	f, err := os.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if v, ok := strings.CutPrefix(scanner.Text(), "DEVTYPE="); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+// exists reports whether the file name exists.
+func exists(name string) bool {
+	_, err := os.Stat(name)
+	return err == nil
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin && !windows
+
+package localaddr
+
+import "net"
+
+// detectKind cannot tell the type of an interface on this platform.
+func detectKind(*net.Interface) Kind {
+	return KindUnknown
+}
@@ -0,0 +1,38 @@
+package localaddr
+
+import (
+	"net"
+
+	"golang.org/x/sys/windows"
+)
+
+// Interface types missing from x/sys/windows, from ipifcons.h.
+const (
+	ifTypeWWANPP  = 243 // GSM based mobile broadband
+	ifTypeWWANPP2 = 244 // CDMA based mobile broadband
+)
+
+// detectKind looks up the interface type of iface with GetAdaptersAddresses. Hyper-V and
+// other VM adapters pose as Ethernet and are told apart by name.
+func detectKind(iface *net.Interface) Kind {
+	aa, err := adapter(iface.Index)
+	if err != nil {
+		return KindUnknown
+	}
+	switch aa.IfType {
+	case windows.IF_TYPE_ETHERNET_CSMACD:
+		if IsVirtual(iface.Name) {
+			return KindVirtual // vEthernet (Default Switch), ...
+		}
+		return KindEthernet
+	case windows.IF_TYPE_IEEE80211:
+		return KindWiFi
+	case ifTypeWWANPP, ifTypeWWANPP2:
+		return KindCellular
+	case windows.IF_TYPE_PPP, windows.IF_TYPE_TUNNEL:
+		return KindTunnel
+	case windows.IF_TYPE_SOFTWARE_LOOPBACK:
+		return KindLoopback
+	}
+	return KindUnknown
+}
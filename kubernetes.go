@@ -0,0 +1,79 @@
+package localaddr
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// Environment variables that WithKubernetes reads. Kubernetes does not set them by itself;
+// they are the names commonly given to the pod IP in a pod spec through the downward API:
+//
+//	env:
+//	- name: POD_IP
+//	  valueFrom:
+//	    fieldRef:
+//	      fieldPath: status.podIP
+const (
+	EnvPodIP  = "POD_IP"  // status.podIP, e.g. "10.244.1.7"
+	EnvPodIPs = "POD_IPS" // status.podIPs, separated by commas, for dual-stack pods
+)
+
+// kubernetesInterfaces are the name patterns of the interfaces that CNI plugins and
+// kube-proxy create on nodes and in host network pods. kube-ipvs0 carries service IPs,
+// which are never the address of the machine.
+var kubernetesInterfaces = []string{
+	"cni*", "cbr*", "kube-*", "nodelocaldns", "docker*", "veth*", "flannel*", "cali*",
+	"tunl*", "vxlan.calico", "weave*", "cilium_*", "lxc*", "antrea-*", "genev_sys_*",
+}
+
+// WithKubernetes selects the pod IP in Kubernetes. EnvPodIPs or EnvPodIP are returned
+// if set, as EnvOverride would be, which takes precedence over them. Otherwise the pod's
+// eth0 wins, as in any container (see InContainer), and on nodes and in host network pods
+// the bridges, overlay and tunnel devices, and host side veth pairs of the common CNI
+// plugins are skipped even with WithIncludeVirtual, as is kube-ipvs0.
+// WithIgnoreEnv makes it ignore the pod IP variables too.
+func WithKubernetes() Option {
+	return func(c *config) {
+		c.kubernetes = true
+	}
+}
+
+// InKubernetes reports whether the process runs in a Kubernetes pod, which it tells by
+// the environment variable that the kubelet sets in every container, or by the mounted
+// service account.
+func InKubernetes() bool {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return true
+	}
+	_, err := os.Stat("/var/run/secrets/kubernetes.io/serviceaccount")
+	return err == nil
+}
+
+// applyKubernetes reads the pod IP into c for WithKubernetes, after applyEnv.
+func (c *config) applyKubernetes() {
+	if !c.kubernetes {
+		return
+	}
+	c.exclude = append(c.exclude, kubernetesInterfaces...)
+	if c.ignoreEnv || len(c.override) > 0 {
+		return // EnvOverride wins
+	}
+	for _, env := range []string{EnvPodIPs, EnvPodIP} {
+		list := os.Getenv(env)
+		if list == "" {
+			continue
+		}
+		for _, s := range strings.Split(list, ",") {
+			ip, err := netip.ParseAddr(strings.TrimSpace(s))
+			if err != nil {
+				c.setErr(fmt.Errorf("invalid %s: %w", env, err))
+				return
+			}
+			c.override = append(c.override, ip.Unmap())
+		}
+		c.overrideEnv = env
+		return
+	}
+}
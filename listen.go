@@ -0,0 +1,59 @@
+package localaddr
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+)
+
+// Listen announces on port of the local address, instead of on all addresses as
+// net.Listen(network, ":port") would. It blocks until there is an address, as
+// WaitForNetwork does; use ListenContext to bound the wait.
+//
+// The network is "tcp", "tcp4", or "tcp6"; "tcp6" selects an IPv6 address as WithIPv6
+// does. A port of 0 picks a free one, which the listener's Addr reports.
+//
+// Returns:
+//   - net.Listener: The listener (e.g., on "192.168.1.42:8080")
+//   - error: An error if the network is not a TCP one, the selection fails for good, or
+//     the port cannot be bound
+func Listen(network string, port int, opts ...Option) (net.Listener, error) {
+	return ListenContext(context.Background(), network, port, opts...)
+}
+
+// ListenContext is like Listen, but gives up waiting for an address when ctx is done.
+// Listening is also retried every poll interval while the address is still being set
+// up, e.g. while an IPv6 address is tentative and cannot be bound yet.
+func ListenContext(ctx context.Context, network string, port int, opts ...Option) (net.Listener, error) {
+	switch network {
+	case "tcp", "tcp4":
+	case "tcp6":
+		opts = append(opts[:len(opts):len(opts)], WithIPv6())
+	default:
+		return nil, fmt.Errorf("listening on %s: not a TCP network", network)
+	}
+	if port < 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid port %d", port)
+	}
+	cfg := newConfig(ipv4, opts)
+	var lc net.ListenConfig
+	for {
+		addr, err := WaitForNetwork(ctx, opts...)
+		if err != nil {
+			return nil, err
+		}
+		l, err := lc.Listen(ctx, network, netip.AddrPortFrom(addr.IP, uint16(port)).String())
+		if err == nil || !unavailable(err) {
+			return l, err
+		}
+		timer := time.NewTimer(cfg.pollInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
@@ -1,55 +1,458 @@
-// Package localaddr provides a simple way to get the local IPv4 address of the machine.
+// Package localaddr provides a simple way to get the local IP address of the machine.
 //
 // This package is designed as a utility for personal projects where you need to quickly
-// retrieve the machine's non-loopback IPv4 address.
+// retrieve the machine's non-loopback IPv4 or IPv6 address. The selection can be tuned
+// with options, e.g.:
+//
+//	ip, err := localaddr.Get(localaddr.WithInterface("eth0"), localaddr.WithIPv6())
 package localaddr
 
 import (
+	"cmp"
+	"errors"
 	"fmt"
 	"net"
+	"net/netip"
+	"slices"
+	"strings"
 )
 
-// Get returns the first non-loopback IPv4 address of an up interface.
+// family selects which IP version a scan is looking for.
+type family int
+
+const (
+	anyFamily family = iota
+	ipv4
+	ipv6
+)
+
+// Get returns the best ranked non-loopback IPv4 address of an up interface, see Rank.
 //
-// It iterates through all network interfaces, skipping those that are down or loopback.
-// For each interface, it looks for the first valid IPv4 address and returns it as a string.
+// It iterates through all network interfaces, skipping those that are down, loopback,
+// virtual (see IsVirtual), so a Docker bridge never wins over the LAN address, or
+// without a link (see HasCarrier), such as an unplugged Ethernet port. If several
+// addresses remain, the best ranked one is returned, e.g. the one on the interface of
+// the default route; see Rank for the criteria. Ties are broken by route metric, then by
+// interface index, so the result is the same on every platform and after reboots.
+// Link-local addresses such as the 169.254.x.x an interface falls back to when DHCP
+// fails are skipped, see WithAllowLinkLocal. Options can change what is looked for, e.g.
+// WithIPv6 or WithInterface.
 //
 // Returns:
 //   - string: The IPv4 address as a string (e.g., "192.168.1.2")
+//   - error: An error if no suitable address is found (wrapping ErrNotConnected, ErrNoIPv4,
+//     or ErrInterfaceNotFound) or if there's an issue accessing network interfaces
+func Get(opts ...Option) (string, error) {
+	return str(firstAddr(newConfig(ipv4, opts)))
+}
+
+// GetIPv6 returns the best ranked non-loopback, non-link-local IPv6 address of an up
+// interface, see Rank.
+//
+// It works like Get, but only considers IPv6 addresses. Link-local addresses (fe80::/10)
+// are skipped unless WithAllowLinkLocal is given; they are then returned with the
+// interface as their zone, e.g. "fe80::1%eth0", which is needed to dial them. Teredo
+// and 6to4 addresses are skipped too, see WithTransition.
+//
+// Returns:
+//   - string: The IPv6 address as a string (e.g., "2001:db8::2")
 //   - error: An error if no suitable address is found or if there's an issue accessing network interfaces
-func Get() (string, error) {
-	interfaces, err := net.Interfaces()
+func GetIPv6(opts ...Option) (string, error) {
+	return str(firstAddr(newConfig(ipv6, opts)))
+}
+
+// GetAll returns every non-loopback address of all up interfaces.
+//
+// Both IPv4 and IPv6 addresses are included, best first as Rank orders them. Virtual
+// interfaces are skipped unless WithIncludeVirtual is given. Link-local addresses are
+// skipped, as in Get and GetIPv6. WithIPv6 restricts the result to IPv6 addresses.
+//
+// Returns:
+//   - []string: The addresses as strings (e.g., ["192.168.1.2", "10.8.0.3", "2001:db8::2"])
+//   - error: An error if no suitable address is found or if there's an issue accessing network interfaces
+func GetAll(opts ...Option) ([]string, error) {
+	found, err := scan(newConfig(anyFamily, opts))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	for _, v := range interfaces {
-		if v.Flags&net.FlagUp == 0 {
-			continue // interface down
-		}
-		if v.Flags&net.FlagLoopback != 0 {
-			continue // loopback interface
-		}
-		addrs, err := v.Addrs()
-		if err != nil {
-			return "", err
-		}
-		for _, addr := range addrs {
-			var ip net.IP
-			switch v := addr.(type) {
-			case *net.IPNet:
-				ip = v.IP
-			case *net.IPAddr:
-				ip = v.IP
-			}
-			if ip == nil || ip.IsLoopback() {
+	all := make([]string, len(found))
+	for i, c := range found {
+		all[i] = c.ip.String()
+	}
+	return all, nil
+}
+
+// GetN returns the n best addresses, best first, e.g. for the host candidates that a
+// WebRTC or ICE agent offers to its peer. They are the first n of GetAll, ranked as Rank
+// describes, or fewer if there are no more. WithIPv6 restricts the result to IPv6
+// addresses.
+//
+// Returns:
+//   - []string: The addresses as strings (e.g., ["192.168.1.2", "2001:db8::2"] for n = 2)
+//   - error: An error if n is not positive, if no suitable address is found, or if
+//     there's an issue accessing network interfaces
+func GetN(n int, opts ...Option) ([]string, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid address count %d", n)
+	}
+	all, err := GetAll(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return all[:min(n, len(all))], nil
+}
+
+// GetAddr is like Get, but returns the address as a netip.Addr.
+//
+// The returned address is always a plain IPv4 address (never IPv4-mapped IPv6),
+// so it can be compared directly with values such as netip.MustParseAddr("192.168.1.2").
+func GetAddr(opts ...Option) (netip.Addr, error) {
+	return firstAddr(newConfig(ipv4, opts))
+}
+
+// GetIPv6Addr is like GetIPv6, but returns the address as a netip.Addr.
+func GetIPv6Addr(opts ...Option) (netip.Addr, error) {
+	return firstAddr(newConfig(ipv6, opts))
+}
+
+// GetAllAddrs is like GetAll, but returns the addresses as netip.Addr values.
+func GetAllAddrs(opts ...Option) ([]netip.Addr, error) {
+	found, err := scan(newConfig(anyFamily, opts))
+	if err != nil {
+		return nil, err
+	}
+	all := make([]netip.Addr, len(found))
+	for i, c := range found {
+		all[i] = c.ip
+	}
+	return all, nil
+}
+
+// GetBoth returns the best IPv4 and the best IPv6 address, for dual-stack servers that
+// bind or advertise both. Both come from the interface of the best address if it has
+// both families; otherwise the best address of the missing family is taken from any
+// other interface.
+//
+// Either address may be absent, in which case it is the zero netip.Addr. An error is
+// returned only if neither family has a usable address.
+//
+// Returns:
+//   - netip.Addr: The IPv4 address, or the zero Addr if there is none
+//   - netip.Addr: The IPv6 address, or the zero Addr if there is none
+//   - error: An error if no suitable address is found or if there's an issue accessing network interfaces
+func GetBoth(opts ...Option) (v4, v6 netip.Addr, err error) {
+	found, err := scan(newConfig(anyFamily, opts))
+	if err != nil {
+		return netip.Addr{}, netip.Addr{}, err
+	}
+	best := found[0].iface.Name
+	pick := func(is6 bool) netip.Addr {
+		var other netip.Addr
+		for _, c := range found {
+			if c.ip.Is6() != is6 {
 				continue
 			}
-			ip = ip.To4()
-			if ip == nil {
-				continue // not an ipv4 address
+			if c.iface.Name == best {
+				return c.ip
 			}
-			return ip.String(), nil
+			if !other.IsValid() {
+				other = c.ip
+			}
+		}
+		return other
+	}
+	return pick(false), pick(true), nil
+}
+
+// GetByInterface returns the best ranked usable IPv4 address of the named interface
+// (e.g. "eth0"). Pass WithIPv6 to look for an IPv6 address instead.
+//
+// Unlike Get, it does not skip the interface because of name based filters such as
+// WithExcludeVirtual or WithExcludeInterfaces: asking for an interface by name always
+// scans it.
+//
+// Returns:
+//   - string: The address as a string (e.g., "192.168.1.2")
+//   - error: An error if the interface does not exist, is down, or has no suitable address
+func GetByInterface(name string, opts ...Option) (string, error) {
+	return Get(append(opts, WithInterface(name))...)
+}
+
+// GetInSubnet returns the best ranked usable address inside prefix (e.g.
+// 192.168.0.0/16).
+//
+// The address family follows the prefix: an IPv6 prefix such as fd00::/8 yields an
+// IPv6 address. It is useful when a deployment has a known management subnet and
+// the address in it is wanted, not whatever interface enumerates first.
+//
+// Returns:
+//   - string: The address as a string (e.g., "192.168.1.2")
+//   - error: An error if no address inside prefix is found or if there's an issue accessing network interfaces
+func GetInSubnet(prefix netip.Prefix, opts ...Option) (string, error) {
+	f := ipv4
+	if prefix.Addr().Is6() {
+		f = ipv6
+	}
+	return str(firstAddr(newConfig(f, append(opts, WithSubnet(prefix)))))
+}
+
+// candidate is a usable address found by a scan, along with its interface.
+type candidate struct {
+	ip     netip.Addr
+	prefix netip.Prefix // ip with the length of its on-link network
+	iface  net.Interface
+	flags  addrFlags
+}
+
+// first returns the best candidate for cfg.
+func first(cfg *config) (candidate, error) {
+	found, err := scan(cfg)
+	if err != nil {
+		return candidate{}, err
+	}
+	return found[0], nil
+}
+
+// firstAddr returns the address of the best candidate for cfg.
+func firstAddr(cfg *config) (netip.Addr, error) {
+	c, err := first(cfg)
+	return c.ip, err
+}
+
+// str adapts a netip.Addr result to the string based API.
+func str(ip netip.Addr, err error) (string, error) {
+	if err != nil {
+		return "", err
+	}
+	return ip.String(), nil
+}
+
+// scan collects the usable addresses for cfg from all up, non-loopback interfaces
+// that pass the name filters.
+// Addresses are returned best first, see Rank. It never returns an empty slice without
+// an error.
+func scan(cfg *config) ([]candidate, error) {
+	if cfg.err == nil {
+		if found := cfg.overridden(); len(found) > 0 {
+			cfg.trace("selected address", "address", found[0].ip, "reason", cfg.overrideEnv+" is set")
+			return found, nil
+		}
+	}
+	links, err := cfg.walk()
+	if err != nil && (restricted(err) || cfg.dial != nil && errors.Is(err, ErrUnsupportedPlatform)) {
+		return cfg.unlisted(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	found, err := cfg.choose(links)
+	if err == nil && cfg.verify && cfg.provider == nil {
+		found, err = cfg.verified(found)
+	}
+	if err == nil && cfg.reach && cfg.provider == nil {
+		found, err = cfg.raced(found)
+	}
+	if err != nil {
+		cfg.trace("no address selected", "error", err)
+		return nil, err
+	}
+	if cfg.store != nil && cfg.family != anyFamily {
+		cfg.stick(found)
+	}
+	cfg.trace("selected address", "address", found[0].ip, "interface", found[0].iface.Name)
+	return found, nil
+}
+
+// walk returns the interfaces for cfg, after resolving WithDefaultRoute to its interface.
+func (cfg *config) walk() ([]link, error) {
+	if cfg.err != nil {
+		return nil, cfg.err
+	}
+	if cfg.defaultRoute {
+		r, err := defaultRoute(cfg)
+		if err != nil {
+			return nil, err
+		}
+		cfg.iface = r.iface
+	}
+	var links []link
+	var err error
+	if cfg.provider != nil {
+		links, err = walkProvider(cfg.provider)
+	} else {
+		links, err = walk()
+	}
+	if err == nil && !cfg.osOrder {
+		sortLinks(links)
+	}
+	return links, err
+}
+
+// sortLinks orders links by interface index, then name, so that candidates of equal
+// score are chosen the same way on every platform and after every reboot. The addresses
+// of each interface keep their order, which puts the primary address first on Linux.
+func sortLinks(links []link) {
+	slices.SortStableFunc(links, func(a, b link) int {
+		if c := cmp.Compare(a.iface.Index, b.iface.Index); c != 0 {
+			return c
+		}
+		return strings.Compare(a.iface.Name, b.iface.Name)
+	})
+}
+
+// choose selects the usable addresses for cfg from the result of a walk.
+func (cfg *config) choose(links []link) ([]candidate, error) {
+	if cfg.iface != "" {
+		return cfg.chooseInterface(links)
+	}
+	var found []candidate
+	var failed []error
+	connected := false
+	for i := range links {
+		v := &links[i].iface
+		if cfg.skip(v) {
+			continue
 		}
+		if err := links[i].err; err != nil {
+			failed = append(failed, &InterfaceError{Interface: v.Name, Err: err})
+			continue
+		}
+		found = cfg.collect(found, &links[i])
+		connected = connected || cfg.hasUsable(&links[i])
+	}
+	if len(found) == 0 {
+		// Errors of single interfaces only matter if nothing else worked.
+		return nil, errors.Join(append([]error{cfg.notFound(connected)}, failed...)...)
+	}
+	cfg.sort(found)
+	return found, nil
+}
+
+// skip reports whether the scan of all interfaces passes over v.
+func (cfg *config) skip(v *net.Interface) bool {
+	var why string
+	switch {
+	case v.Flags&net.FlagUp == 0:
+		why = "interface down"
+	case v.Flags&net.FlagLoopback != 0:
+		why = "loopback interface"
+	case !cfg.allowed(v.Name):
+		why = "filtered out by name patterns"
+	case !cfg.includeVirtual && IsVirtual(v.Name):
+		why = "virtual interface"
+	case !cfg.includeNoCarrier && !cfg.carrier(v):
+		why = "no carrier"
+	case cfg.routable && !cfg.isRoutable(v):
+		why = "not routable"
+	case cfg.multicast && v.Flags&net.FlagMulticast == 0:
+		why = "no multicast"
+	default:
+		return false
+	}
+	cfg.trace("skipping interface", "interface", v.Name, "reason", why)
+	return true
+}
+
+// chooseInterface is choose restricted to the interface named by cfg.iface. Its errors
+// name the interface, so callers can tell a typo from a disconnected cable.
+func (cfg *config) chooseInterface(links []link) ([]candidate, error) {
+	l, err := cfg.named(links)
+	if err != nil {
+		return nil, err
+	}
+	found := cfg.collect(nil, l)
+	if len(found) == 0 {
+		return nil, fmt.Errorf("interface %q: %w", cfg.iface, cfg.notFound(cfg.hasUsable(l)))
+	}
+	cfg.sort(found)
+	return found, nil
+}
+
+// named returns the link of the interface named by cfg.iface, or an error if it does not
+// exist, is down, or its addresses cannot be read.
+func (cfg *config) named(links []link) (*link, error) {
+	i := slices.IndexFunc(links, func(l link) bool { return l.iface.Name == cfg.iface })
+	if i < 0 {
+		return nil, fmt.Errorf("interface %q: %w", cfg.iface, ErrInterfaceNotFound)
+	}
+	if links[i].iface.Flags&net.FlagUp == 0 {
+		return nil, fmt.Errorf("interface %q is down: %w", cfg.iface, ErrNotConnected)
+	}
+	if cfg.routable && !cfg.isRoutable(&links[i].iface) {
+		return nil, fmt.Errorf("interface %q is not routable: %w", cfg.iface, ErrNotConnected)
+	}
+	if cfg.multicast && links[i].iface.Flags&net.FlagMulticast == 0 {
+		return nil, fmt.Errorf("interface %q does not support multicast", cfg.iface)
+	}
+	if err := links[i].err; err != nil {
+		return nil, &InterfaceError{Interface: cfg.iface, Err: err}
+	}
+	return &links[i], nil
+}
+
+// collect appends the usable addresses of a single interface to found.
+func (cfg *config) collect(found []candidate, l *link) []candidate {
+	// Only exclusion matters here; detecting tunnels costs system calls, so it is left
+	// to the ranking unless a group may be excluded. Whether an address is on a tunnel
+	// then decides as in the ranking, e.g. WithVPN(Prefer) keeps a CGNAT address on a
+	// tunnel that WithCGNAT(Exclude) would drop.
+	excludes := cfg.vpn == Exclude || cfg.vmAdapters == Exclude || cfg.cgnat == Exclude
+	tunnel := excludes && len(l.addrs) > 0 && cfg.kind(&l.iface) == KindTunnel
+	for i, prefix := range l.addrs {
+		ip, flags := prefix.Addr(), l.flagsOf(i)
+		if why := cfg.reject(&l.iface, ip, flags, tunnel); why != "" {
+			cfg.trace("skipping address", "interface", l.iface.Name, "address", ip, "reason", why)
+			continue
+		}
+		if ip.Is6() && ip.IsLinkLocalUnicast() {
+			ip = ip.WithZone(l.iface.Name) // dialing needs to know the link
+		}
+		found = append(found, candidate{ip: ip, prefix: prefix, iface: l.iface, flags: flags})
+	}
+	return found
+}
+
+// reject returns why ip, an address of iface, is not a candidate for cfg, or "" if it is one.
+func (cfg *config) reject(iface *net.Interface, ip netip.Addr, flags addrFlags, tunnel bool) string {
+	switch {
+	case ip.IsLoopback():
+		return "loopback address"
+	case !cfg.usable(ip, cfg.family) && ip.IsLinkLocalUnicast():
+		return "link-local address"
+	case !cfg.usable(ip, cfg.family):
+		return "other address family"
+	case !cfg.inSubnet(ip):
+		return "outside the required subnets"
+	case !cfg.inScope(ip):
+		return "outside the IPv6 scope"
+	case !cfg.accepts(iface, ip):
+		return "rejected by a filter"
+	case cfg.temporary == Exclude && flags&addrTemporary != 0:
+		return "temporary address excluded"
+	case cfg.primaryOnly && flags&addrSecondary != 0:
+		return "secondary address"
+	}
+	if p, group := cfg.preference(iface, ip, tunnel); p == Exclude {
+		return group + " excluded"
+	}
+	return ""
+}
+
+// hasUsable reports whether l has a usable address of any family.
+func (cfg *config) hasUsable(l *link) bool {
+	return slices.ContainsFunc(l.addrs, func(p netip.Prefix) bool { return cfg.usable(p.Addr(), anyFamily) })
+}
+
+// usable reports whether ip is a valid candidate of the given family.
+func (cfg *config) usable(ip netip.Addr, f family) bool {
+	if ip.IsLoopback() {
+		return false
+	}
+	if ip.IsLinkLocalUnicast() && !cfg.allowLinkLocal {
+		return false // APIPA address or IPv6 link-local, only reachable on the link
+	}
+	if ip.Is4() {
+		return f != ipv6
 	}
-	return "", fmt.Errorf("not connected to the network")
+	return f != ipv4
 }
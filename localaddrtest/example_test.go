@@ -0,0 +1,31 @@
+package localaddrtest_test
+
+import (
+	"fmt"
+
+	"github.com/golovingreg/localaddr"
+	"github.com/golovingreg/localaddr/localaddrtest"
+)
+
+func Example() {
+	network := localaddrtest.DockerHeavy()
+	addr, _ := localaddr.Get(network.Option())
+	fmt.Println(addr)
+
+	network.Down("wlan0")
+	_, err := localaddr.Get(network.Option())
+	fmt.Println(err)
+	// Output:
+	// 192.168.1.10
+	// not connected to the network
+}
+
+func ExampleNew() {
+	network := localaddrtest.New().Loopback().
+		Interface("eth0", "192.168.1.10/24", "2001:db8::10/64").
+		Tunnel("wg0", "10.66.0.2/32").
+		Gateway("10.66.0.1", "wg0")
+	addrs, _ := localaddr.GetAll(network.Option())
+	fmt.Println(addrs)
+	// Output: [192.168.1.10 2001:db8::10 10.66.0.2]
+}
@@ -0,0 +1,58 @@
+package localaddrtest
+
+// SingleNIC is a machine with one wired interface on a home network: eth0 with
+// 192.168.1.10/24, and the default route through 192.168.1.1.
+func SingleNIC() *Network {
+	return New().Loopback().
+		Interface("eth0", "192.168.1.10/24").
+		Gateway("192.168.1.1", "eth0")
+}
+
+// DualStack is SingleNIC with IPv6: eth0 also has the global address 2001:db8:1::10/64
+// and the unique local address fd00:1::10/64, with an IPv6 default route.
+func DualStack() *Network {
+	return New().Loopback().
+		Interface("eth0", "192.168.1.10/24", "2001:db8:1::10/64", "fd00:1::10/64").
+		Gateway("192.168.1.1", "eth0").
+		Gateway("fe80::1", "eth0")
+}
+
+// DockerHeavy is a development machine whose LAN address on wlan0 (192.168.1.10/24)
+// is outnumbered by the bridges and veth pairs of Docker, and by a libvirt bridge,
+// all with private addresses that come first in enumeration order.
+func DockerHeavy() *Network {
+	return New().Loopback().
+		Interface("docker0", "172.17.0.1/16").
+		Interface("br-3f2a1b4c5d6e", "172.18.0.1/16").
+		Interface("br-9a8b7c6d5e4f", "172.19.0.1/16").
+		Interface("veth1a2b3c4").
+		Interface("veth5d6e7f8").
+		Interface("virbr0", "192.168.122.1/24").
+		Interface("wlan0", "192.168.1.10/24").
+		Gateway("192.168.1.1", "wlan0")
+}
+
+// AirplaneMode is a laptop with all radios off: wlan0 and wwan0 exist but are down, and
+// only the loopback interface is left. The getters report localaddr.ErrNotConnected.
+func AirplaneMode() *Network {
+	return New().Loopback().
+		Interface("wlan0").Down("wlan0").
+		Interface("wwan0").Down("wwan0")
+}
+
+// VPNOnly is a machine whose only connection is a WireGuard tunnel, wg0 with
+// 10.66.0.2/32, which carries the default route.
+func VPNOnly() *Network {
+	return New().Loopback().
+		Tunnel("wg0", "10.66.0.2/32").
+		Gateway("10.66.0.1", "wg0")
+}
+
+// MultiHomed is a server with two uplinks, eth0 (192.168.1.10/24) and eth1
+// (10.0.0.10/24), where the default route goes through eth1.
+func MultiHomed() *Network {
+	return New().Loopback().
+		Interface("eth0", "192.168.1.10/24").
+		Interface("eth1", "10.0.0.10/24").
+		Gateway("10.0.0.1", "eth1")
+}
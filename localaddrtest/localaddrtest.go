@@ -0,0 +1,208 @@
+// Package localaddrtest provides fake network topologies for testing code that uses
+// localaddr, without depending on the interfaces of the machine running the tests.
+//
+// A Network is built interface by interface, or taken from one of the canned fixtures,
+// and passed to the getters with its Option:
+//
+//	net := localaddrtest.DualStack()
+//	addr, err := localaddr.Get(net.Option()) // 192.168.1.10
+package localaddrtest
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"sync"
+
+	"github.com/golovingreg/localaddr"
+)
+
+// Network is a fake topology. It implements localaddr.GatewayProvider; its methods may
+// be called concurrently, so a test can change the topology while a watcher polls it.
+//
+// The builder methods panic on malformed addresses and unknown interface names, as
+// they are meant for test fixtures.
+type Network struct {
+	mu         sync.Mutex
+	interfaces []net.Interface
+	addrs      map[string][]netip.Prefix
+	failures   map[string]error
+	gateways   [2]gateway // IPv4, IPv6
+}
+
+type gateway struct {
+	addr  netip.Addr
+	iface string
+}
+
+// New returns a Network without any interfaces, not even a loopback one.
+func New() *Network {
+	return &Network{addrs: make(map[string][]netip.Prefix), failures: make(map[string]error)}
+}
+
+// Interface adds an interface that is up and running, with the given addresses in CIDR
+// notation, e.g. "192.168.1.10/24". It gets the next free index and a locally
+// administered MAC address derived from it.
+func (n *Network) Interface(name string, addrs ...string) *Network {
+	index := n.nextIndex()
+	return n.Add(net.Interface{
+		Index:        index,
+		MTU:          1500,
+		Name:         name,
+		HardwareAddr: net.HardwareAddr{0x02, 0, 0, 0, byte(index >> 8), byte(index)},
+		Flags:        net.FlagUp | net.FlagBroadcast | net.FlagMulticast | net.FlagRunning,
+	}, addrs...)
+}
+
+// Loopback adds the loopback interface lo with 127.0.0.1/8 and ::1/128.
+func (n *Network) Loopback() *Network {
+	return n.Add(net.Interface{Index: n.nextIndex(), MTU: 65536, Name: "lo", Flags: net.FlagUp | net.FlagLoopback | net.FlagRunning},
+		"127.0.0.1/8", "::1/128")
+}
+
+// Tunnel adds a point-to-point interface without a MAC address, such as a VPN tunnel,
+// that is up and running, with the given addresses in CIDR notation.
+func (n *Network) Tunnel(name string, addrs ...string) *Network {
+	return n.Add(net.Interface{Index: n.nextIndex(), MTU: 1420, Name: name, Flags: net.FlagUp | net.FlagPointToPoint | net.FlagRunning},
+		addrs...)
+}
+
+// Add adds iface as given, with the given addresses in CIDR notation. It replaces an
+// interface of the same name.
+func (n *Network) Add(iface net.Interface, addrs ...string) *Network {
+	prefixes := make([]netip.Prefix, len(addrs))
+	for i, a := range addrs {
+		prefixes[i] = netip.MustParsePrefix(a)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if i := n.index(iface.Name); i >= 0 {
+		n.interfaces[i] = iface
+	} else {
+		n.interfaces = append(n.interfaces, iface)
+	}
+	n.addrs[iface.Name] = prefixes
+	return n
+}
+
+// Down takes the named interface down, as when it is disabled.
+func (n *Network) Down(name string) *Network {
+	return n.setFlags(name, 0, net.FlagUp|net.FlagRunning)
+}
+
+// Unplugged leaves the named interface up but without a link, as with no cable plugged in.
+func (n *Network) Unplugged(name string) *Network {
+	return n.setFlags(name, 0, net.FlagRunning)
+}
+
+// Up brings the named interface back up and running.
+func (n *Network) Up(name string) *Network {
+	return n.setFlags(name, net.FlagUp|net.FlagRunning, 0)
+}
+
+// Fail makes reading the addresses of the named interface fail with err, or succeed
+// again if err is nil.
+func (n *Network) Fail(name string, err error) *Network {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.mustIndex(name)
+	if err == nil {
+		delete(n.failures, name)
+	} else {
+		n.failures[name] = err
+	}
+	return n
+}
+
+// Gateway sets the default route of the family of gw, through the named interface.
+func (n *Network) Gateway(gw, iface string) *Network {
+	addr := netip.MustParseAddr(gw)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.mustIndex(iface)
+	n.gateways[family(addr.Is6())] = gateway{addr, iface}
+	return n
+}
+
+// Option returns the option that makes the getters of localaddr use n.
+func (n *Network) Option() localaddr.Option {
+	return localaddr.WithProvider(n)
+}
+
+// Interfaces returns the interfaces of n, as net.Interfaces does.
+func (n *Network) Interfaces() ([]net.Interface, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]net.Interface(nil), n.interfaces...), nil
+}
+
+// Addrs returns the addresses of iface, as net.Interface.Addrs does.
+func (n *Network) Addrs(iface net.Interface) ([]net.Addr, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.index(iface.Name) < 0 {
+		return nil, fmt.Errorf("no such interface %q", iface.Name)
+	}
+	if err := n.failures[iface.Name]; err != nil {
+		return nil, err
+	}
+	var addrs []net.Addr
+	for _, p := range n.addrs[iface.Name] {
+		addrs = append(addrs, &net.IPNet{IP: p.Addr().AsSlice(), Mask: net.CIDRMask(p.Bits(), p.Addr().BitLen())})
+	}
+	return addrs, nil
+}
+
+// DefaultGateway returns the gateway set with Gateway.
+func (n *Network) DefaultGateway(ipv6 bool) (netip.Addr, string, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	gw := n.gateways[family(ipv6)]
+	if !gw.addr.IsValid() {
+		return netip.Addr{}, "", fmt.Errorf("no default gateway")
+	}
+	return gw.addr, gw.iface, nil
+}
+
+// setFlags sets and clears flags of the named interface.
+func (n *Network) setFlags(name string, set, clear net.Flags) *Network {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	i := n.mustIndex(name)
+	n.interfaces[i].Flags = n.interfaces[i].Flags&^clear | set
+	return n
+}
+
+// nextIndex returns the index of an interface added next.
+func (n *Network) nextIndex() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return len(n.interfaces) + 1
+}
+
+// index returns the position of the named interface, or -1.
+func (n *Network) index(name string) int {
+	for i, iface := range n.interfaces {
+		if iface.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// mustIndex is index for names that must exist.
+func (n *Network) mustIndex(name string) int {
+	i := n.index(name)
+	if i < 0 {
+		panic(fmt.Sprintf("localaddrtest: no interface %q", name))
+	}
+	return i
+}
+
+// family returns the index of gateways for the family.
+func family(ipv6 bool) int {
+	if ipv6 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,123 @@
+package localaddrtest_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golovingreg/localaddr"
+	"github.com/golovingreg/localaddr/localaddrtest"
+)
+
+func TestFixtures(t *testing.T) {
+	tests := []struct {
+		name    string
+		network *localaddrtest.Network
+		ipv4    string
+		ipv6    string
+		all     []string
+		err     error // of Get, GetIPv6 if ipv6 is empty, and GetAll if all is nil
+	}{
+		{"SingleNIC", localaddrtest.SingleNIC(), "192.168.1.10", "", []string{"192.168.1.10"}, localaddr.ErrNoIPv6},
+		{"DualStack", localaddrtest.DualStack(), "192.168.1.10", "2001:db8:1::10", []string{"192.168.1.10", "2001:db8:1::10", "fd00:1::10"}, nil},
+		{"DockerHeavy", localaddrtest.DockerHeavy(), "192.168.1.10", "", []string{"192.168.1.10"}, localaddr.ErrNoIPv6},
+		{"AirplaneMode", localaddrtest.AirplaneMode(), "", "", nil, localaddr.ErrNotConnected},
+		{"VPNOnly", localaddrtest.VPNOnly(), "10.66.0.2", "", []string{"10.66.0.2"}, localaddr.ErrNoIPv6},
+		{"MultiHomed", localaddrtest.MultiHomed(), "10.0.0.10", "", []string{"10.0.0.10", "192.168.1.10"}, localaddr.ErrNoIPv6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opt := tt.network.Option()
+			got, err := localaddr.Get(opt)
+			if tt.ipv4 == "" {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("Get() error = %v, want %v", err, tt.err)
+				}
+			} else if err != nil || got != tt.ipv4 {
+				t.Errorf("Get() = %q, %v, want %q", got, err, tt.ipv4)
+			}
+			got, err = localaddr.GetIPv6(opt)
+			if tt.ipv6 == "" {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("GetIPv6() error = %v, want %v", err, tt.err)
+				}
+			} else if err != nil || got != tt.ipv6 {
+				t.Errorf("GetIPv6() = %q, %v, want %q", got, err, tt.ipv6)
+			}
+			all, err := localaddr.GetAll(opt)
+			if tt.all == nil {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("GetAll() error = %v, want %v", err, tt.err)
+				}
+			} else if err != nil || !slices.Equal(all, tt.all) {
+				t.Errorf("GetAll() = %q, %v, want %q", all, err, tt.all)
+			}
+		})
+	}
+}
+
+func TestNetworkChanges(t *testing.T) {
+	network := localaddrtest.New().Loopback().
+		Interface("eth0", "192.168.1.10/24").
+		Interface("wlan0", "192.168.2.10/24").
+		Gateway("192.168.2.1", "wlan0")
+	opt := network.Option()
+	get := func(want string) {
+		t.Helper()
+		if got, err := localaddr.Get(opt); err != nil || got != want {
+			t.Errorf("Get() = %q, %v, want %q", got, err, want)
+		}
+	}
+
+	get("192.168.2.10") // the interface of the default route wins
+	network.Unplugged("wlan0")
+	get("192.168.1.10")
+	network.Fail("eth0", errors.New("permission denied"))
+	if _, err := localaddr.Get(opt); err == nil {
+		t.Error("Get() succeeded without readable addresses")
+	}
+	network.Fail("eth0", nil).Down("eth0")
+	if _, err := localaddr.Get(opt); !errors.Is(err, localaddr.ErrNotConnected) {
+		t.Errorf("Get() error = %v, want %v", err, localaddr.ErrNotConnected)
+	}
+	network.Up("eth0").Up("wlan0")
+	get("192.168.2.10")
+	network.Interface("eth0", "10.0.0.10/8")
+	if got, err := localaddr.GetByInterface("eth0", opt); err != nil || got != "10.0.0.10" {
+		t.Errorf("GetByInterface() = %q, %v, want %q", got, err, "10.0.0.10")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	network := localaddrtest.SingleNIC()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	events, err := localaddr.NewWatcher(network.Option(), localaddr.WithPollInterval(10*time.Millisecond)).Watch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := func() localaddr.Event {
+		t.Helper()
+		select {
+		case ev := <-events:
+			return ev
+		case <-ctx.Done():
+			t.Fatal("no event")
+			return localaddr.Event{}
+		}
+	}
+
+	network.Down("eth0")
+	if ev := next(); ev.Old.String() != "192.168.1.10" || ev.New.IsValid() {
+		t.Errorf("losing the address: event from %v to %v", ev.Old, ev.New)
+	}
+	network.Interface("eth1", "192.168.1.20/24")
+	if ev := next(); ev.Old.IsValid() || ev.New.String() != "192.168.1.20" || ev.Interface != "eth1" {
+		t.Errorf("getting an address: event from %v to %v on %q", ev.Old, ev.New, ev.Interface)
+	}
+	cancel()
+	for range events {
+	}
+}
@@ -0,0 +1,25 @@
+package localaddr
+
+import (
+	"fmt"
+	"net"
+)
+
+// GetMAC returns the hardware address of the interface that Get selects.
+//
+// The same options as for Get apply, so GetMAC(WithDefaultRoute()) returns the MAC
+// address of the default-route interface. It is handy as a stable machine identifier.
+//
+// Returns:
+//   - net.HardwareAddr: The hardware address (e.g., "00:1a:2b:3c:4d:5e")
+//   - error: An error if no address is selected or its interface has no hardware address (e.g. a tunnel)
+func GetMAC(opts ...Option) (net.HardwareAddr, error) {
+	c, err := first(newConfig(ipv4, opts))
+	if err != nil {
+		return nil, err
+	}
+	if len(c.iface.HardwareAddr) == 0 {
+		return nil, fmt.Errorf("interface %q has no hardware address", c.iface.Name)
+	}
+	return c.iface.HardwareAddr, nil
+}
@@ -0,0 +1,122 @@
+package localaddr
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golovingreg/localaddr/internal/dns"
+	"github.com/golovingreg/localaddr/internal/mcast"
+)
+
+// mDNS groups and port (RFC 6762).
+var (
+	mdnsIPv4 = netip.MustParseAddrPort("224.0.0.251:5353")
+	mdnsIPv6 = netip.MustParseAddrPort("[ff02::fb]:5353")
+)
+
+// mdnsTimeout bounds MDNSName if ctx has no deadline.
+const mdnsTimeout = 2 * time.Second
+
+// MDNSName returns the multicast DNS name of the machine, e.g. "mybox.local", together
+// with the address selected as by Get, for printing URLs that people on the LAN can type.
+//
+// The name is derived from the host name, and it is verified on the LAN: the Bonjour
+// or Avahi responder of the machine must answer a query for it, sent on the interface of
+// the selected address, with that address. The address is selected as by
+// GetMulticastInterface, with the same options; WithIPv6 verifies the AAAA record
+// instead of the A record. Without a deadline on ctx, the answer is awaited for two
+// seconds.
+//
+// Returns:
+//   - string: The mDNS name (e.g., "mybox.local")
+//   - netip.Addr: The address it resolves to (e.g., "192.168.1.2")
+//   - error: An error if no address is selected, nothing answers for the name, or it
+//     resolves to another address, e.g. because another machine has the same name
+func MDNSName(ctx context.Context, opts ...Option) (string, netip.Addr, error) {
+	cfg := newConfig(ipv4, opts)
+	cfg.multicast = true
+	c, err := first(cfg)
+	if err != nil {
+		return "", netip.Addr{}, err
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		return "", netip.Addr{}, err
+	}
+	host, _, _ = strings.Cut(host, ".") // macOS reports "mybox.local", others may add a domain
+	name := host + ".local"
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, mdnsTimeout)
+		defer cancel()
+	}
+	found, err := mdnsResolve(ctx, &c.iface, name, c.ip.Is6())
+	if err != nil {
+		return "", netip.Addr{}, fmt.Errorf("resolving %s on %s: %w", name, c.iface.Name, err)
+	}
+	for _, ip := range found {
+		if ip == c.ip.WithZone("") {
+			return name, c.ip, nil
+		}
+	}
+	return "", netip.Addr{}, fmt.Errorf("%s resolves to %s on the LAN, not to %s", name, found[0], c.ip)
+}
+
+// mdnsResolve queries the addresses of name on the LAN of iface, until the first answer
+// or until ctx is done.
+func mdnsResolve(ctx context.Context, iface *net.Interface, name string, v6 bool) ([]netip.Addr, error) {
+	group, qtype := mdnsIPv4, uint16(dns.TypeA)
+	if v6 {
+		group, qtype = mdnsIPv6, dns.TypeAAAA
+	}
+	conn, err := mcast.Listen(iface, group)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.SetReadDeadline(time.Now()) })
+	defer stop()
+
+	var id [2]byte
+	rand.Read(id[:])
+	query, err := (&dns.Message{
+		ID:        binary.BigEndian.Uint16(id[:]),
+		Questions: []dns.Question{{Name: name, Type: qtype, Class: dns.ClassINET}},
+	}).Pack()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.WriteToUDP(query, mcast.Dest(iface, group)); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, 9000)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("no mDNS responder answered")
+			}
+			return nil, err
+		}
+		msg, err := dns.Parse(buf[:n])
+		if err != nil || msg.Flags&dns.FlagResponse == 0 {
+			continue // our own query, or another machine's
+		}
+		var found []netip.Addr
+		for _, r := range msg.Records() {
+			if r.Type == qtype && dns.EqualNames(r.Name, name) {
+				found = append(found, r.Addr)
+			}
+		}
+		if len(found) > 0 {
+			return found, nil
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Package metrics exports the local address as Prometheus metrics, so that a fleet of
+// machines can be watched for address changes and lost connectivity.
+//
+// The metrics are written in the Prometheus text exposition format by the Collector's
+// ServeHTTP method, without depending on the Prometheus client library; mount it at
+// "/metrics" or at a path of its own next to the metrics of another registry:
+//
+//	# HELP localaddr_info The selected local addresses, as labels.
+//	# TYPE localaddr_info gauge
+//	localaddr_info{interface="eth0",ipv4="192.168.1.42",ipv6="fd00::42"} 1
+//	# HELP localaddr_interfaces Network interfaces by state, without loopback ones.
+//	# TYPE localaddr_interfaces gauge
+//	localaddr_interfaces{state="up"} 2
+//	localaddr_interfaces{state="down"} 1
+//	# HELP localaddr_address_changes_total Changes of the selected IPv4 address.
+//	# TYPE localaddr_address_changes_total counter
+//	localaddr_address_changes_total 3
+//	# HELP localaddr_seconds_since_last_change Seconds since the IPv4 address last changed, or since the collector started.
+//	# TYPE localaddr_seconds_since_last_change gauge
+//	localaddr_seconds_since_last_change 5400.2
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golovingreg/localaddr"
+)
+
+// Collector tracks the local address and serves it as metrics.
+type Collector struct {
+	opts []localaddr.Option
+
+	mu      sync.Mutex
+	changes uint64
+	last    time.Time // of the last change, the start if there was none
+}
+
+// NewCollector returns a collector for the addresses that localaddr.Get and
+// localaddr.GetIPv6 select with opts. The address metrics are read on every scrape;
+// changes are only counted while Run is running. The interfaces are counted as
+// localaddr.Interfaces lists them with opts.
+func NewCollector(opts ...localaddr.Option) *Collector {
+	return &Collector{opts: opts, last: time.Now()}
+}
+
+// Run counts the address changes reported by a localaddr.Watcher until ctx is done.
+//
+// Returns:
+//   - error: An error if watching cannot be started, nil once ctx is done
+func (c *Collector) Run(ctx context.Context) error {
+	events, err := localaddr.NewWatcher(c.opts...).Watch(ctx)
+	if err != nil {
+		return err
+	}
+	for ev := range events {
+		c.mu.Lock()
+		c.changes++
+		c.last = ev.Time
+		c.mu.Unlock()
+	}
+	return nil
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var b bytes.Buffer
+	c.write(&b)
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(b.Bytes())
+}
+
+// write appends the metrics to b.
+func (c *Collector) write(b *bytes.Buffer) {
+	labels := [][2]string{{"interface", ""}, {"ipv4", ""}, {"ipv6", ""}}
+	if a, err := localaddr.GetDetailed(c.opts...); err == nil {
+		labels[0][1], labels[1][1] = a.Interface, a.IP.String()
+	}
+	if a, err := localaddr.GetIPv6Detailed(c.opts...); err == nil {
+		labels[2][1] = a.IP.String()
+		if labels[0][1] == "" {
+			labels[0][1] = a.Interface
+		}
+	}
+	metric(b, "localaddr_info", "gauge", "The selected local addresses, as labels.")
+	sample(b, "localaddr_info", labels, 1)
+
+	up, down := 0, 0
+	if ifaces, err := localaddr.Interfaces(c.opts...); err == nil {
+		for _, iface := range ifaces {
+			switch {
+			case iface.Flags&net.FlagLoopback != 0:
+			case iface.Flags&net.FlagUp != 0:
+				up++
+			default:
+				down++
+			}
+		}
+	}
+	metric(b, "localaddr_interfaces", "gauge", "Network interfaces by state, without loopback ones.")
+	sample(b, "localaddr_interfaces", [][2]string{{"state", "up"}}, float64(up))
+	sample(b, "localaddr_interfaces", [][2]string{{"state", "down"}}, float64(down))
+
+	c.mu.Lock()
+	changes, last := c.changes, c.last
+	c.mu.Unlock()
+	metric(b, "localaddr_address_changes_total", "counter", "Changes of the selected IPv4 address.")
+	sample(b, "localaddr_address_changes_total", nil, float64(changes))
+	metric(b, "localaddr_seconds_since_last_change", "gauge", "Seconds since the IPv4 address last changed, or since the collector started.")
+	sample(b, "localaddr_seconds_since_last_change", nil, time.Since(last).Seconds())
+}
+
+// metric writes the HELP and TYPE lines of a metric.
+func metric(b *bytes.Buffer, name, typ, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample writes a sample line with the given labels.
+func sample(b *bytes.Buffer, name string, labels [][2]string, value float64) {
+	b.WriteString(name)
+	for i, l := range labels {
+		if i == 0 {
+			b.WriteByte('{')
+		} else {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(b, "%s=\"%s\"", l[0], labelEscaper.Replace(l[1]))
+	}
+	if len(labels) > 0 {
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	b.WriteByte('\n')
+}
+
+// labelEscaper escapes label values as the exposition format requires.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
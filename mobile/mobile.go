@@ -0,0 +1,66 @@
+// Package mobile is the API of localaddr for Android and iOS apps, to be bound with
+// gomobile:
+//
+//	gomobile bind -target=android github.com/golovingreg/localaddr/mobile
+//
+// gobind only translates basic types, so the functions take no options and return
+// strings: addresses in their text form, and lists as JSON. Apps that need more can
+// bind a package of their own on top of localaddr.
+//
+// On Android 11 and later apps may not list the network interfaces; the functions then
+// return the address of the interface that outbound traffic leaves through, and the
+// interface details are empty. Reading addresses needs the INTERNET permission on Android.
+package mobile
+
+import (
+	"encoding/json"
+
+	"github.com/golovingreg/localaddr"
+)
+
+// IPv4 returns the local IPv4 address, as localaddr.Get does, e.g. "192.168.1.2".
+func IPv4() (string, error) {
+	return localaddr.Get()
+}
+
+// IPv6 returns the local IPv6 address, as localaddr.GetIPv6 does, e.g. "fd00::2".
+func IPv6() (string, error) {
+	return localaddr.GetIPv6()
+}
+
+// Outbound returns the address that outbound traffic is sent from, as
+// localaddr.GetOutbound does. It works on every platform, without listing interfaces.
+func Outbound() (string, error) {
+	return localaddr.GetOutbound()
+}
+
+// Interface returns the name of the interface of the IPv4 address, e.g. "wlan0", or ""
+// if the platform does not tell.
+func Interface() (string, error) {
+	a, err := localaddr.GetDetailed()
+	if err != nil {
+		return "", err
+	}
+	return a.Interface, nil
+}
+
+// WiFi reports whether the IPv4 address is on a Wi-Fi interface, e.g. to hold back
+// large transfers on cellular data. It is false if the platform does not tell.
+func WiFi() (bool, error) {
+	a, err := localaddr.GetDetailed()
+	if err != nil {
+		return false, err
+	}
+	return a.Kind == localaddr.KindWiFi, nil
+}
+
+// All returns the candidate addresses of both families, best first, as a JSON array of
+// the objects of localaddr.Address.MarshalJSON.
+func All() (string, error) {
+	all, err := localaddr.GetAllDetailed()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(all)
+	return string(data), err
+}
@@ -0,0 +1,100 @@
+package localaddr
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+
+	"github.com/golovingreg/localaddr/internal/mcast"
+)
+
+// GetMulticastInterface returns the interface to send and receive multicast on, such as
+// mDNS, SSDP, or a protocol of your own, together with its address. It is selected as by
+// Get, with the same options, among the interfaces that are up, not loopback, and
+// support multicast; point-to-point tunnels such as WireGuard do not. WithIPv6 selects
+// an interface with an IPv6 address, for the IPv6 groups. An address from EnvOverride
+// must belong to such an interface.
+//
+// Returns:
+//   - *net.Interface: The interface (e.g., "eth0"), e.g. for net.ListenMulticastUDP
+//   - netip.Addr: Its address (e.g., 192.168.1.2), to send the packets from
+//   - error: An error if no multicast capable interface has a suitable address
+func GetMulticastInterface(opts ...Option) (*net.Interface, netip.Addr, error) {
+	return multicastInterface(newConfig(ipv4, opts))
+}
+
+// WithMulticastLoopback sets whether JoinMulticast's socket delivers what it sends to
+// the group to the machine itself, including to that socket. The default is on, so that
+// programs on the same machine hear each other as they would over the LAN; turn it off
+// for protocols that would otherwise answer their own packets.
+func WithMulticastLoopback(on bool) Option {
+	return func(c *config) {
+		c.noMulticastLoop = !on
+	}
+}
+
+// JoinMulticast joins group on the interface that GetMulticastInterface selects, and
+// returns a socket bound to port of the group, which receives the group's packets and
+// sends what is written to the group out of that interface. The family of group decides
+// that of the interface's address; the other options are those of Get, and
+// WithMulticastLoopback.
+//
+// It does what net.ListenMulticastUDP does with the interface selected, but keeps the
+// multicast loopback on by default, which net.ListenMulticastUDP turns off.
+//
+// Returns:
+//   - *net.UDPConn: The socket (e.g., on 239.255.0.1:9999 on "eth0"), a net.PacketConn
+//   - error: An error if group is not a multicast address, no interface is found, or
+//     the group cannot be joined
+func JoinMulticast(group netip.Addr, port int, opts ...Option) (*net.UDPConn, error) {
+	group = group.Unmap().WithZone("")
+	if !group.IsMulticast() {
+		return nil, fmt.Errorf("%s is not a multicast group", group)
+	}
+	if port < 1 || port > 65535 {
+		return nil, fmt.Errorf("invalid port %d", port)
+	}
+	f := ipv4
+	if group.Is6() {
+		f = ipv6
+	}
+	cfg := newConfig(f, opts)
+	cfg.family = f // the group decides, also against WithIPv6
+	iface, _, err := multicastInterface(cfg)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := mcast.Listen(iface, netip.AddrPortFrom(group, uint16(port)))
+	if err != nil {
+		return nil, fmt.Errorf("joining %s on %s: %w", group, iface.Name, err)
+	}
+	if cfg.noMulticastLoop {
+		if err := mcast.SetLoopback(conn, false); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("disabling multicast loopback: %w", err)
+		}
+	}
+	return conn, nil
+}
+
+// multicastInterface selects the multicast interface for cfg.
+func multicastInterface(cfg *config) (*net.Interface, netip.Addr, error) {
+	cfg.multicast = true
+	c, err := first(cfg)
+	if err != nil {
+		return nil, netip.Addr{}, err
+	}
+	iface := c.iface
+	if iface.Index == 0 {
+		// An override, or the outbound address where the interfaces cannot be listed.
+		a := cfg.owner(c.ip)
+		if a.Interface == "" {
+			return nil, netip.Addr{}, fmt.Errorf("no interface has the address %s", c.ip)
+		}
+		iface = net.Interface{Index: a.Index, MTU: a.MTU, Name: a.Interface, HardwareAddr: a.MAC, Flags: a.Flags}
+		if iface.Flags&net.FlagMulticast == 0 {
+			return nil, netip.Addr{}, fmt.Errorf("interface %q of %s does not support multicast", iface.Name, c.ip)
+		}
+	}
+	return &iface, c.ip, nil
+}
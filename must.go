@@ -0,0 +1,54 @@
+package localaddr
+
+import "net/netip"
+
+// MustGet is like Get, but panics if no address is found. It is meant for small programs
+// that need an address at initialization and cannot do anything useful without one:
+//
+//	var listenAddr = localaddr.MustGet() + ":8080"
+func MustGet(opts ...Option) string {
+	return must(Get(opts...))
+}
+
+// MustGetIPv6 is like GetIPv6, but panics if no address is found.
+func MustGetIPv6(opts ...Option) string {
+	return must(GetIPv6(opts...))
+}
+
+// MustGetAll is like GetAll, but panics if no address is found.
+func MustGetAll(opts ...Option) []string {
+	return must(GetAll(opts...))
+}
+
+// MustGetAddr is like GetAddr, but panics if no address is found.
+func MustGetAddr(opts ...Option) netip.Addr {
+	return must(GetAddr(opts...))
+}
+
+// MustGetIPv6Addr is like GetIPv6Addr, but panics if no address is found.
+func MustGetIPv6Addr(opts ...Option) netip.Addr {
+	return must(GetIPv6Addr(opts...))
+}
+
+// MustGetAllAddrs is like GetAllAddrs, but panics if no address is found.
+func MustGetAllAddrs(opts ...Option) []netip.Addr {
+	return must(GetAllAddrs(opts...))
+}
+
+// MustGetDetailed is like GetDetailed, but panics if no address is found.
+func MustGetDetailed(opts ...Option) Address {
+	return must(GetDetailed(opts...))
+}
+
+// MustGetOutbound is like GetOutbound, but panics if there is no route.
+func MustGetOutbound(opts ...Option) string {
+	return must(GetOutbound(opts...))
+}
+
+// must returns v, or panics with err.
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic("localaddr: " + err.Error())
+	}
+	return v
+}
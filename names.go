@@ -0,0 +1,35 @@
+package localaddr
+
+import (
+	"net"
+	"strings"
+)
+
+// resolveAliases reads the aliases of the interfaces if the options name interfaces, and
+// replaces an alias given to WithInterface with the name of its interface.
+func (c *config) resolveAliases() {
+	if c.provider != nil || (c.iface == "" && len(c.include) == 0 && len(c.exclude) == 0) {
+		return
+	}
+	c.aliases = readAliases()
+	if _, ok := c.aliases[c.iface]; ok || c.iface == "" {
+		return
+	}
+	for name, aliases := range c.aliases {
+		for _, alias := range aliases {
+			if strings.EqualFold(alias, c.iface) {
+				c.iface = name
+				return
+			}
+		}
+	}
+}
+
+// description returns the description of iface, or "" if it has none or comes from a
+// Provider.
+func (cfg *config) description(iface *net.Interface) string {
+	if cfg.provider != nil {
+		return ""
+	}
+	return readDescription(iface.Name, iface.Index)
+}
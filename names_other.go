@@ -0,0 +1,13 @@
+//go:build !windows
+
+package localaddr
+
+// readAliases returns nil: interfaces have no other names on this platform.
+func readAliases() map[string][]string {
+	return nil
+}
+
+// readDescription returns "": interfaces have no descriptions on this platform.
+func readDescription(string, int) string {
+	return ""
+}
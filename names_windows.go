@@ -0,0 +1,34 @@
+package localaddr
+
+import (
+	"strings"
+
+	"golang.org/x/sys/windows"
+)
+
+// readAliases returns the descriptions and GUIDs of the adapters, by their friendly
+// names, which are the names of their interfaces. Each GUID is listed with and without
+// its braces.
+func readAliases() map[string][]string {
+	list, err := adapters(windows.GAA_FLAG_SKIP_UNICAST | windows.GAA_FLAG_SKIP_ANYCAST | windows.GAA_FLAG_SKIP_MULTICAST | windows.GAA_FLAG_SKIP_DNS_SERVER)
+	if err != nil {
+		return nil
+	}
+	aliases := make(map[string][]string)
+	for aa := list; aa != nil; aa = aa.Next {
+		guid := windows.BytePtrToString(aa.AdapterName) // e.g. "{4D36E972-E325-11CE-BFC1-08002BE10318}"
+		aliases[windows.UTF16PtrToString(aa.FriendlyName)] = []string{
+			windows.UTF16PtrToString(aa.Description), guid, strings.Trim(guid, "{}"),
+		}
+	}
+	return aliases
+}
+
+// readDescription returns the description of the MIB_IF_ROW2 of the interface.
+func readDescription(_ string, index int) string {
+	row := windows.MibIfRow2{InterfaceIndex: uint32(index)}
+	if err := windows.GetIfEntry2Ex(windows.MibIfEntryNormalWithoutStatistics, &row); err != nil {
+		return ""
+	}
+	return windows.UTF16ToString(row.Description[:])
+}
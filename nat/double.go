@@ -0,0 +1,44 @@
+package nat
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+
+	"github.com/golovingreg/localaddr"
+	"github.com/golovingreg/localaddr/portmap"
+	"github.com/golovingreg/localaddr/publicaddr"
+)
+
+// DoubleNAT reports whether the router is itself behind another NAT, e.g. a provider's
+// carrier-grade NAT or a second router in front of it. Port forwarding on the router
+// then has no effect from the internet.
+//
+// The router of the address that localaddr.Get(opts...) selects is asked for its WAN
+// address with PCP, NAT-PMP, or UPnP, as portmap.Discover does. If that address is not
+// globally routable, there is another NAT; if it is, it is compared with the public
+// address that publicaddr.HTTP sees, which differs behind another NAT.
+//
+// Returns:
+//   - bool: Whether there is a second NAT
+//   - netip.Addr: The WAN address of the router (e.g., "100.64.12.3")
+//   - error: An error if the router tells no WAN address, e.g. because it supports none
+//     of the protocols; a failed public lookup is not an error, the WAN address decides alone
+func DoubleNAT(ctx context.Context, opts ...localaddr.Option) (bool, netip.Addr, error) {
+	c, err := portmap.Discover(ctx, opts...)
+	if err != nil {
+		return false, netip.Addr{}, err
+	}
+	wan, err := c.ExternalIP(ctx)
+	if err != nil {
+		return false, netip.Addr{}, fmt.Errorf("asking the router for its WAN address: %w", err)
+	}
+	if localaddr.Classify(wan) != localaddr.ClassGlobal {
+		return true, wan, nil
+	}
+	public, err := publicaddr.HTTP(ctx)
+	if err != nil {
+		return false, wan, nil
+	}
+	return public != wan, wan, nil
+}
@@ -0,0 +1,221 @@
+// Package nat classifies the NAT between the machine and the internet, following the
+// STUN tests of RFC 3489 and RFC 5780, so peer-to-peer programs can tell whether a
+// direct connection to another peer can work or traffic has to go through a relay. It
+// also checks whether the internet reaches a port on the machine at all.
+package nat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/golovingreg/localaddr"
+	"github.com/golovingreg/localaddr/internal/stun"
+)
+
+// Type is the kind of NAT, from the most to the least permissive.
+type Type int
+
+const (
+	Unknown Type = iota
+	// Open means no NAT: the address the internet sees is on an interface.
+	Open
+	// FullCone maps a socket to one public port that anyone can send to.
+	FullCone
+	// RestrictedCone maps a socket to one public port that hosts the socket sent to
+	// can send to, from any port.
+	RestrictedCone
+	// PortRestrictedCone maps a socket to one public port that only the addresses and
+	// ports the socket sent to can send to.
+	PortRestrictedCone
+	// Cone maps a socket to one public port, but who can send to it is unknown, since
+	// no STUN server supported the test for it.
+	Cone
+	// Symmetric maps a socket to a new public port for every destination, so the port
+	// a STUN server reports is useless to other peers.
+	Symmetric
+)
+
+var typeNames = [...]string{
+	Unknown:            "unknown",
+	Open:               "open",
+	FullCone:           "full cone",
+	RestrictedCone:     "restricted cone",
+	PortRestrictedCone: "port-restricted cone",
+	Cone:               "cone",
+	Symmetric:          "symmetric",
+}
+
+// String returns the name of t, e.g. "full cone".
+func (t Type) String() string {
+	if t < 0 || int(t) >= len(typeNames) {
+		return fmt.Sprintf("Type(%d)", int(t))
+	}
+	return typeNames[t]
+}
+
+// Traversable reports whether peers behind a NAT of type t can usually reach each
+// other directly with UDP hole punching, given the other side is not symmetric either.
+func (t Type) Traversable() bool {
+	return t >= Open && t <= Cone
+}
+
+// DefaultServers are the STUN servers used when none are given. The first supports the
+// filtering tests of RFC 5780; the others only tell the mapped address.
+var DefaultServers = []string{
+	"stun.stunprotocol.org:3478",
+	"stun.l.google.com:19302",
+	"stun.cloudflare.com:3478",
+}
+
+// DefaultTimeout bounds Detect if ctx has no deadline.
+const DefaultTimeout = 10 * time.Second
+
+// queryTimeout bounds a single test. A filtering test that gets no answer within it
+// counts as blocked.
+const queryTimeout = 2 * time.Second
+
+// Result is the outcome of Detect.
+type Result struct {
+	Type   Type
+	Public netip.AddrPort // public address and port of the test socket, as the first server saw it
+}
+
+// Detect classifies the NAT with STUN requests to servers (host:port), or
+// DefaultServers if none are given, over IPv4 UDP:
+//
+//   - a server reports the public address of a socket; if it is local, there is no NAT
+//   - a second server, or the alternate address of the first, reports it again; if
+//     the port differs, the NAT is symmetric
+//   - a server that supports it is asked to answer from another address, and then
+//     from another port; which answers arrive tells the cone types apart
+//
+// At least two servers, or one with an alternate address, have to answer.
+//
+// Returns:
+//   - Result: The NAT type, and the public address the first server saw
+//   - error: An error if too few servers answered, joining their errors
+func Detect(ctx context.Context, servers ...string) (Result, error) {
+	if len(servers) == 0 {
+		servers = DefaultServers
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return Result{}, err
+	}
+	defer conn.Close()
+
+	type answer struct {
+		server netip.AddrPort
+		resp   stun.Response
+	}
+	var answers []answer
+	var errs []error
+	for _, s := range servers {
+		server, err := resolve(ctx, s)
+		if err == nil {
+			var resp stun.Response
+			if resp, err = query(ctx, conn, server, 0); err == nil {
+				answers = append(answers, answer{server, resp})
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", s, err))
+		}
+		if len(answers) == 2 || len(answers) == 1 && answers[0].resp.Other.IsValid() || ctx.Err() != nil {
+			break
+		}
+	}
+	if len(answers) == 0 {
+		return Result{}, errors.Join(errs...)
+	}
+	first := answers[0]
+	result := Result{Type: Unknown, Public: first.resp.Mapped}
+	if local, _ := localaddr.IsLocal(first.resp.Mapped.Addr()); local {
+		result.Type = Open
+		return result, nil
+	}
+
+	// Mapping: does another destination see the same public port?
+	var second stun.Response
+	if other := first.resp.Other; other.IsValid() {
+		second, err = query(ctx, conn, netip.AddrPortFrom(other.Addr(), first.server.Port()), 0)
+		if err != nil {
+			return result, fmt.Errorf("alternate address of %s: %w", first.server, err)
+		}
+	} else if len(answers) > 1 {
+		second = answers[1].resp
+	} else {
+		return result, fmt.Errorf("nat: need a second STUN server: %w", errors.Join(errs...))
+	}
+	if second.Mapped != first.resp.Mapped {
+		result.Type = Symmetric
+		return result, nil
+	}
+
+	// Filtering: which answers from elsewhere get through?
+	result.Type = Cone
+	var tester *answer
+	for i := range answers {
+		if answers[i].resp.Other.IsValid() {
+			tester = &answers[i]
+			break
+		}
+	}
+	if tester == nil {
+		return result, nil
+	}
+	resp, err := query(ctx, conn, tester.server, stun.ChangeIP|stun.ChangePort)
+	switch {
+	case err == nil && resp.Source.Addr().Unmap() != tester.server.Addr():
+		result.Type = FullCone
+		return result, nil
+	case err == nil:
+		return result, nil // the server ignored the request
+	case ctx.Err() != nil:
+		return result, ctx.Err()
+	}
+	resp, err = query(ctx, conn, tester.server, stun.ChangePort)
+	switch {
+	case err == nil && resp.Source.Port() != tester.server.Port():
+		result.Type = RestrictedCone
+	case err == nil:
+	case ctx.Err() != nil:
+		return result, ctx.Err()
+	default:
+		result.Type = PortRestrictedCone
+	}
+	return result, nil
+}
+
+// query sends a Binding request to server, bounded by queryTimeout.
+func query(ctx context.Context, conn *net.UDPConn, server netip.AddrPort, change uint32) (stun.Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+	return stun.DoChange(ctx, conn, net.UDPAddrFromAddrPort(server), change)
+}
+
+// resolve looks up the IPv4 address of a host:port.
+func resolve(ctx context.Context, hostport string) (netip.AddrPort, error) {
+	host, service, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	port, err := net.DefaultResolver.LookupPort(ctx, "udp", service)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip4", host)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	return netip.AddrPortFrom(ips[0].Unmap(), uint16(port)), nil
+}
@@ -0,0 +1,199 @@
+package nat
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golovingreg/localaddr"
+	"github.com/golovingreg/localaddr/internal/stun"
+)
+
+// connectGrace is how long CheckReachable waits for the connection of an echo endpoint
+// after its answer, since the two can arrive in either order.
+const connectGrace = time.Second
+
+// Reachability is the outcome of CheckReachable.
+type Reachability struct {
+	Reachable bool
+	Protocol  string         // "udp" when tested with STUN, "tcp" with an echo endpoint
+	Public    netip.AddrPort // address and port the internet sees for the listener
+}
+
+// CheckReachable binds port on the address that localaddr.Get selects, or an
+// ephemeral port if it is zero, and finds out whether packets from the internet that
+// nobody asked for arrive there, e.g. because the router forwards the port or the NAT
+// is full cone. servers are tried in order, DefaultServers if none are given:
+//
+//   - a STUN server (host:port) that supports the tests of RFC 5780 is asked to answer
+//     a UDP Binding request from another address and port; servers that do not are
+//     skipped
+//   - an echo endpoint (an http:// or https:// URL) is sent a GET request with the
+//     port and a token in the query, "?port=8080&token=...", and has to open a TCP
+//     connection to the caller's address and that port, write the token, and answer
+//     with the address and port it connected to, e.g. "203.0.113.7:8080"
+//
+// Returns:
+//   - Reachability: Whether the listener was reached, and its public address
+//   - error: An error if no server could do the test, joining their errors
+func CheckReachable(ctx context.Context, port int, servers ...string) (Reachability, error) {
+	if port < 0 || port > 65535 {
+		return Reachability{}, fmt.Errorf("nat: invalid port %d", port)
+	}
+	if len(servers) == 0 {
+		servers = DefaultServers
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultTimeout)
+		defer cancel()
+	}
+	local, err := localaddr.GetAddr()
+	if err != nil {
+		return Reachability{}, err
+	}
+	laddr := netip.AddrPortFrom(local.WithZone(""), uint16(port))
+
+	var errs []error
+	for _, s := range servers {
+		var r Reachability
+		var err error
+		if strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://") {
+			r, err = checkTCP(ctx, laddr, s)
+		} else {
+			r, err = checkUDP(ctx, laddr, s)
+		}
+		if err == nil {
+			return r, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", s, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return Reachability{}, errors.Join(errs...)
+}
+
+// checkUDP tests laddr with the STUN server at hostport.
+func checkUDP(ctx context.Context, laddr netip.AddrPort, hostport string) (Reachability, error) {
+	server, err := resolve(ctx, hostport)
+	if err != nil {
+		return Reachability{}, err
+	}
+	conn, err := net.ListenUDP("udp4", net.UDPAddrFromAddrPort(laddr))
+	if err != nil {
+		return Reachability{}, err
+	}
+	defer conn.Close()
+	resp, err := query(ctx, conn, server, 0)
+	if err != nil {
+		return Reachability{}, err
+	}
+	if !resp.Other.IsValid() {
+		return Reachability{}, errors.New("nat: the server cannot answer from another address")
+	}
+	r := Reachability{Protocol: "udp", Public: resp.Mapped}
+	resp, err = query(ctx, conn, server, stun.ChangeIP|stun.ChangePort)
+	switch {
+	case err == nil && resp.Source.Addr().Unmap() == server.Addr():
+		return Reachability{}, errors.New("nat: the server ignored the change request")
+	case err == nil:
+		r.Reachable = true
+	case ctx.Err() != nil:
+		return Reachability{}, ctx.Err()
+	}
+	return r, nil
+}
+
+// checkTCP tests laddr with the echo endpoint at rawURL.
+func checkTCP(ctx context.Context, laddr netip.AddrPort, rawURL string) (Reachability, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Reachability{}, err
+	}
+	ln, err := net.ListenTCP("tcp4", net.TCPAddrFromAddrPort(laddr))
+	if err != nil {
+		return Reachability{}, err
+	}
+	defer ln.Close()
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return Reachability{}, err
+	}
+	token := hex.EncodeToString(b[:])
+	reached := make(chan struct{}, 1)
+	go accept(ln, token, reached)
+
+	q := u.Query()
+	q.Set("port", strconv.Itoa(ln.Addr().(*net.TCPAddr).Port))
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return Reachability{}, err
+	}
+	// The request leaves from the listener's address, so the router that forwards the
+	// connection back is the one it went out through.
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{LocalAddr: net.TCPAddrFromAddrPort(netip.AddrPortFrom(laddr.Addr(), 0))}).DialContext
+	defer transport.CloseIdleConnections()
+	resp, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		return Reachability{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return Reachability{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Reachability{}, fmt.Errorf("nat: echo endpoint: %s", resp.Status)
+	}
+	public, err := netip.ParseAddrPort(strings.TrimSpace(string(body)))
+	if err != nil {
+		return Reachability{}, fmt.Errorf("nat: echo endpoint answered %q, want an address and port", body)
+	}
+	r := Reachability{Protocol: "tcp", Public: netip.AddrPortFrom(public.Addr().Unmap(), public.Port())}
+	timer := time.NewTimer(connectGrace)
+	defer timer.Stop()
+	select {
+	case <-reached:
+		r.Reachable = true
+	case <-timer.C:
+	case <-ctx.Done():
+		return Reachability{}, ctx.Err()
+	}
+	return r, nil
+}
+
+// accept takes connections on ln until it is closed, and signals reached once one of
+// them sends token.
+func accept(ln *net.TCPListener, token string, reached chan<- struct{}) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			conn.SetReadDeadline(time.Now().Add(connectGrace))
+			buf := make([]byte, len(token))
+			if _, err := io.ReadFull(conn, buf); err == nil && string(buf) == token {
+				select {
+				case reached <- struct{}{}:
+				default:
+				}
+			}
+		}()
+	}
+}
@@ -0,0 +1,79 @@
+package localaddr
+
+import (
+	"net"
+	"net/netip"
+)
+
+// NeighborState tells how sure the system is of the hardware address of a neighbor.
+type NeighborState int
+
+const (
+	NeighborUnknown NeighborState = iota
+	// NeighborIncomplete means address resolution is in progress; there is no MAC yet.
+	NeighborIncomplete
+	// NeighborReachable means the neighbor answered recently.
+	NeighborReachable
+	// NeighborStale means the neighbor has not been confirmed for a while; it is checked
+	// again the next time a packet is sent to it.
+	NeighborStale
+	// NeighborFailed means address resolution got no answer.
+	NeighborFailed
+	// NeighborPermanent means the entry was configured statically and does not expire.
+	NeighborPermanent
+)
+
+var neighborStateNames = [...]string{
+	NeighborUnknown:    "unknown",
+	NeighborIncomplete: "incomplete",
+	NeighborReachable:  "reachable",
+	NeighborStale:      "stale",
+	NeighborFailed:     "failed",
+	NeighborPermanent:  "permanent",
+}
+
+// String returns the lower case name of s, e.g. "reachable".
+func (s NeighborState) String() string {
+	if s < 0 || int(s) >= len(neighborStateNames) {
+		return "unknown"
+	}
+	return neighborStateNames[s]
+}
+
+// Neighbor is an entry of the ARP table, or of the NDP table for IPv6.
+type Neighbor struct {
+	IP        netip.Addr
+	MAC       net.HardwareAddr // empty while the state is NeighborIncomplete or NeighborFailed
+	Interface string
+	State     NeighborState
+}
+
+// Neighbors returns the ARP table, the hosts on the local networks whose hardware
+// addresses the system has looked up, or the NDP table with WithIPv6. WithInterface
+// restricts it to the entries of that interface.
+//
+// The tables are read over netlink on Linux, from a route socket dump on macOS and the
+// BSDs, and with GetIpNetTable2 on Windows. The BSDs only tell incomplete and permanent
+// entries from the others, which are all NeighborReachable there. Other platforms return
+// an error wrapping errors.ErrUnsupported.
+//
+// Returns:
+//   - []Neighbor: The entries (e.g., "192.168.1.1" at "00:1a:2b:3c:4d:5e" on "eth0")
+//   - error: An error if the table cannot be read
+func Neighbors(opts ...Option) ([]Neighbor, error) {
+	cfg := newConfig(ipv4, opts)
+	if cfg.err != nil {
+		return nil, cfg.err
+	}
+	all, err := readNeighbors(cfg.family)
+	if err != nil {
+		return nil, err
+	}
+	neighbors := all[:0]
+	for _, n := range all {
+		if cfg.iface == "" || n.Interface == cfg.iface {
+			neighbors = append(neighbors, n)
+		}
+	}
+	return neighbors, nil
+}
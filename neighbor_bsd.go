@@ -0,0 +1,60 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package localaddr
+
+import (
+	"net"
+	"syscall"
+)
+
+// readNeighbors dumps the routing table entries that carry link-layer information,
+// which is how the BSD stacks keep the ARP and NDP tables, and keeps those of the given
+// family.
+func readNeighbors(f family) ([]Neighbor, error) {
+	rib, err := syscall.RouteRIB(syscall.NET_RT_FLAGS, syscall.RTF_LLINFO)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := syscall.ParseRoutingMessage(rib)
+	if err != nil {
+		return nil, err
+	}
+	var neighbors []Neighbor
+	for _, msg := range msgs {
+		m, ok := msg.(*syscall.RouteMessage)
+		if !ok || m.Header.Flags&syscall.RTF_LLINFO == 0 {
+			continue
+		}
+		sas, err := syscall.ParseRoutingSockaddr(m)
+		if err != nil || len(sas) <= syscall.RTAX_GATEWAY {
+			continue
+		}
+		ip := sockaddrIP(sas[syscall.RTAX_DST])
+		if !ip.IsValid() || ip.Is4() != (f == ipv4) || ip.IsMulticast() {
+			continue
+		}
+		n := Neighbor{IP: ip, Interface: ifaceName(int(m.Header.Index)), State: NeighborReachable}
+		if dl, ok := sas[syscall.RTAX_GATEWAY].(*syscall.SockaddrDatalink); ok {
+			// The data holds the interface name, then the address.
+			start, end := int(dl.Nlen), int(dl.Nlen)+int(dl.Alen)
+			if end <= len(dl.Data) {
+				mac := make(net.HardwareAddr, 0, dl.Alen)
+				for _, b := range dl.Data[start:end] {
+					mac = append(mac, byte(b))
+				}
+				n.MAC = mac
+			}
+			if n.Interface == "" {
+				n.Interface = ifaceName(int(dl.Index))
+			}
+		}
+		switch {
+		case len(n.MAC) == 0:
+			n.State = NeighborIncomplete
+		case m.Header.Flags&syscall.RTF_STATIC != 0 || m.Header.Rmx.Expire == 0:
+			n.State = NeighborPermanent
+		}
+		neighbors = append(neighbors, n)
+	}
+	return neighbors, nil
+}
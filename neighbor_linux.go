@@ -0,0 +1,94 @@
+package localaddr
+
+import (
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"syscall"
+)
+
+// Neighbor table constants of <linux/neighbour.h>.
+const (
+	sizeofNdmsg = 12
+	ndaDst      = 1 // NDA_DST
+	ndaLLAddr   = 2 // NDA_LLADDR
+
+	nudIncomplete = 0x01
+	nudReachable  = 0x02
+	nudStale      = 0x04
+	nudDelay      = 0x08
+	nudProbe      = 0x10
+	nudFailed     = 0x20
+	nudNoARP      = 0x40
+	nudPermanent  = 0x80
+)
+
+// readNeighbors dumps the neighbor table of the given family over netlink. Entries of
+// addresses that need no resolution, such as multicast groups, are left out.
+func readNeighbors(f family) ([]Neighbor, error) {
+	af := syscall.AF_INET
+	if f == ipv6 {
+		af = syscall.AF_INET6
+	}
+	rib, err := syscall.NetlinkRIB(syscall.RTM_GETNEIGH, af)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := syscall.ParseNetlinkMessage(rib)
+	if err != nil {
+		return nil, err
+	}
+	var neighbors []Neighbor
+	for _, m := range msgs {
+		// struct ndmsg: family, 3 bytes padding, ifindex, state, flags, type
+		if m.Header.Type != syscall.RTM_NEWNEIGH || len(m.Data) < sizeofNdmsg || int(m.Data[0]) != af {
+			continue
+		}
+		index := int(int32(binary.NativeEndian.Uint32(m.Data[4:])))
+		state := binary.NativeEndian.Uint16(m.Data[8:])
+		if state&nudNoARP != 0 {
+			continue
+		}
+		n := Neighbor{Interface: ifaceName(index), State: nudState(state)}
+		for attrs := m.Data[sizeofNdmsg:]; len(attrs) >= syscall.SizeofRtAttr; {
+			l := int(binary.NativeEndian.Uint16(attrs))
+			if l < syscall.SizeofRtAttr || l > len(attrs) {
+				break
+			}
+			value := attrs[syscall.SizeofRtAttr:l]
+			switch binary.NativeEndian.Uint16(attrs[2:]) {
+			case ndaDst:
+				n.IP, _ = netip.AddrFromSlice(value)
+			case ndaLLAddr:
+				n.MAC = net.HardwareAddr(append([]byte(nil), value...))
+			}
+			l = (l + syscall.RTA_ALIGNTO - 1) &^ (syscall.RTA_ALIGNTO - 1)
+			if l > len(attrs) {
+				break
+			}
+			attrs = attrs[l:]
+		}
+		if n.IP.IsValid() {
+			neighbors = append(neighbors, n)
+		}
+	}
+	return neighbors, nil
+}
+
+// nudState maps a NUD_* state to a NeighborState. Entries in the delay and probe
+// states are being confirmed after going stale.
+func nudState(state uint16) NeighborState {
+	switch {
+	case state&nudPermanent != 0:
+		return NeighborPermanent
+	case state&nudReachable != 0:
+		return NeighborReachable
+	case state&(nudStale|nudDelay|nudProbe) != 0:
+		return NeighborStale
+	case state&nudIncomplete != 0:
+		return NeighborIncomplete
+	case state&nudFailed != 0:
+		return NeighborFailed
+	}
+	return NeighborUnknown
+}
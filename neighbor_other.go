@@ -0,0 +1,13 @@
+//go:build !linux && !windows && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package localaddr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// readNeighbors is not implemented on this platform.
+func readNeighbors(family) ([]Neighbor, error) {
+	return nil, fmt.Errorf("reading the neighbor table: %w", errors.ErrUnsupported)
+}
@@ -0,0 +1,109 @@
+package localaddr
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/netip"
+	"syscall"
+	"unsafe"
+)
+
+var procGetIpNetTable2 = iphlpapi.NewProc("GetIpNetTable2")
+
+// Layout of MIB_IPNET_TABLE2 and its MIB_IPNET_ROW2 entries, which start 8 bytes in, as
+// in MIB_IPFORWARD_TABLE2.
+const (
+	netRow2Size    = 88
+	netRow2Index   = 28 // InterfaceIndex
+	netRow2MAC     = 40 // PhysicalAddress
+	netRow2MACLen  = 72 // PhysicalAddressLength
+	netRow2State   = 76
+	sockaddrInAddr = 4 // sin_addr in SOCKADDR_IN
+)
+
+// NL_NEIGHBOR_STATE values.
+const (
+	nlnsUnreachable = 0
+	nlnsIncomplete  = 1
+	nlnsProbe       = 2
+	nlnsDelay       = 3
+	nlnsStale       = 4
+	nlnsReachable   = 5
+	nlnsPermanent   = 6
+)
+
+// readNeighbors reads the neighbor table of the given family with GetIpNetTable2.
+// Entries of multicast and broadcast addresses, which Windows lists as permanent, are
+// left out.
+func readNeighbors(f family) ([]Neighbor, error) {
+	af := syscall.AF_INET
+	if f == ipv6 {
+		af = syscall.AF_INET6
+	}
+	var table unsafe.Pointer
+	r, _, _ := procGetIpNetTable2.Call(uintptr(af), uintptr(unsafe.Pointer(&table)))
+	if r != 0 {
+		return nil, fmt.Errorf("GetIpNetTable2: %w", syscall.Errno(r))
+	}
+	defer procFreeMibTable.Call(uintptr(table))
+	n := *(*uint32)(table)
+	rows := unsafe.Slice((*byte)(unsafe.Add(table, 8)), uintptr(n)*netRow2Size)
+	var neighbors []Neighbor
+	for i := 0; i < int(n); i++ {
+		row := rows[i*netRow2Size : (i+1)*netRow2Size]
+		var ip netip.Addr
+		if f == ipv6 {
+			ip = netip.AddrFrom16([16]byte(row[sockaddrIn6Addr:]))
+		} else {
+			ip = netip.AddrFrom4([4]byte(row[sockaddrInAddr:]))
+		}
+		if ip.IsMulticast() {
+			continue
+		}
+		nb := Neighbor{
+			IP:        ip,
+			Interface: ifaceName(int(binary.LittleEndian.Uint32(row[netRow2Index:]))),
+			State:     nlnsState(binary.LittleEndian.Uint32(row[netRow2State:])),
+		}
+		if l := binary.LittleEndian.Uint32(row[netRow2MACLen:]); l > 0 && l <= 32 {
+			nb.MAC = net.HardwareAddr(append([]byte(nil), row[netRow2MAC:netRow2MAC+l]...))
+		}
+		if nb.State == NeighborPermanent && isBroadcast(ip, nb.MAC) {
+			continue
+		}
+		neighbors = append(neighbors, nb)
+	}
+	return neighbors, nil
+}
+
+// isBroadcast reports whether a permanent entry is one that Windows keeps for a
+// broadcast address, with the all-ones MAC.
+func isBroadcast(ip netip.Addr, mac net.HardwareAddr) bool {
+	if !ip.Is4() || len(mac) == 0 {
+		return false
+	}
+	for _, b := range mac {
+		if b != 0xff {
+			return false
+		}
+	}
+	return true
+}
+
+// nlnsState maps an NL_NEIGHBOR_STATE to a NeighborState.
+func nlnsState(state uint32) NeighborState {
+	switch state {
+	case nlnsUnreachable:
+		return NeighborFailed
+	case nlnsIncomplete:
+		return NeighborIncomplete
+	case nlnsProbe, nlnsDelay, nlnsStale:
+		return NeighborStale
+	case nlnsReachable:
+		return NeighborReachable
+	case nlnsPermanent:
+		return NeighborPermanent
+	}
+	return NeighborUnknown
+}
@@ -0,0 +1,59 @@
+package localaddr
+
+import "net"
+
+// LinkState is the state of a link as systemd-networkd tracks it, with the values that
+// networkctl shows.
+type LinkState struct {
+	// Operational is e.g. "routable" (an address that reaches beyond the link),
+	// "degraded" (only link-local addresses), "carrier", "no-carrier", or "off".
+	Operational string
+	// Setup is e.g. "configured", "configuring" (DHCP still running), "failed", or
+	// "unmanaged" for links that networkd does not manage.
+	Setup string
+	// Online is "online", "partial", or "offline" by the link's RequiredForOnline
+	// setting, and empty before systemd 249.
+	Online string
+}
+
+// Routable reports whether networkd is done with the link and it has a routable address.
+// Links that networkd does not manage only need the address.
+func (s LinkState) Routable() bool {
+	return s.Operational == "routable" && (s.Setup == "configured" || s.Setup == "unmanaged")
+}
+
+// WithRoutable skips the interfaces that systemd-networkd has not finished configuring
+// or that have no routable address by its account, so that WaitForNetwork waits until
+// the network is routable rather than for the first address, which may be a link-local
+// one or a static one on a link that is still being set up.
+//
+// It only has an effect on Linux, for the links that networkd keeps state for (see
+// GetLinkState); without networkd the selection is as without the option.
+func WithRoutable() Option {
+	return func(c *config) {
+		c.routable = true
+	}
+}
+
+// GetLinkState returns the state of iface in systemd-networkd.
+//
+// It is read from the state files in /run/systemd/netif/links, as sd-network does. On
+// other platforms it returns an error wrapping errors.ErrUnsupported.
+//
+// Returns:
+//   - LinkState: The state (e.g., routable and configured)
+//   - error: An error wrapping fs.ErrNotExist if networkd is not running or has no
+//     state for iface, or another error if the state cannot be read
+func GetLinkState(iface net.Interface) (LinkState, error) {
+	return readLinkState(iface.Index)
+}
+
+// isRoutable reports whether v passes WithRoutable. Interfaces of a Provider and those
+// without networkd state do.
+func (cfg *config) isRoutable(v *net.Interface) bool {
+	if cfg.provider != nil {
+		return true
+	}
+	state, err := readLinkState(v.Index)
+	return err != nil || state.Routable()
+}
@@ -0,0 +1,38 @@
+package localaddr
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// readLinkState parses the networkd state file of the link with the given index, which
+// holds KEY=value lines such as OPER_STATE=routable.
+func readLinkState(index int) (LinkState, error) {
+	data, err := os.ReadFile("/run/systemd/netif/links/" + strconv.Itoa(index))
+	if err != nil {
+		return LinkState{}, fmt.Errorf("reading the networkd state: %w", err)
+	}
+	var state LinkState
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		key, value, ok := bytes.Cut(sc.Bytes(), []byte("="))
+		if !ok {
+			continue // comments
+		}
+		switch string(key) {
+		case "OPER_STATE":
+			state.Operational = string(value)
+		case "ADMIN_STATE":
+			state.Setup = string(value)
+		case "ONLINE_STATE":
+			state.Online = string(value)
+		}
+	}
+	if state.Operational == "" {
+		return LinkState{}, fmt.Errorf("reading the networkd state: no operational state for link %d", index)
+	}
+	return state, nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package localaddr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// readLinkState is not implemented on this platform, which has no systemd-networkd.
+func readLinkState(int) (LinkState, error) {
+	return LinkState{}, fmt.Errorf("reading the networkd state: %w", errors.ErrUnsupported)
+}
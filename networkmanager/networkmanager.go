@@ -0,0 +1,310 @@
+// Package networkmanager reads the network state of Linux desktops from NetworkManager,
+// over the system D-Bus: the primary connection, the device it runs on, its addresses,
+// and whether the internet is reachable through it.
+//
+// On laptops NetworkManager knows which of the present interfaces actually carries the
+// traffic, e.g. when Wi-Fi and a docked Ethernet are both up, and it checks connectivity
+// for captive portals by itself. The Resolver is a localaddr.Strategy, to be combined
+// with the strategies of the parent package:
+//
+//	addr, err := localaddr.Chain(networkmanager.Resolver{}, localaddr.DefaultChain()).Resolve(ctx)
+//
+// Without NetworkManager, e.g. on servers, in containers, or on other systems, the
+// functions return ErrNotRunning.
+package networkmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/golovingreg/localaddr"
+	"github.com/golovingreg/localaddr/internal/dbus"
+)
+
+// ErrNotRunning is returned if NetworkManager cannot be reached, because there is no
+// system bus or because NetworkManager is not running on it.
+var ErrNotRunning = errors.New("networkmanager: not running")
+
+// ErrNoConnection is returned by Primary if no connection is active.
+var ErrNoConnection = errors.New("networkmanager: no primary connection")
+
+// D-Bus names of NetworkManager.
+const (
+	service        = "org.freedesktop.NetworkManager"
+	rootPath       = "/org/freedesktop/NetworkManager"
+	ifaceManager   = "org.freedesktop.NetworkManager"
+	ifaceActive    = "org.freedesktop.NetworkManager.Connection.Active"
+	ifaceDevice    = "org.freedesktop.NetworkManager.Device"
+	ifaceIP4Config = "org.freedesktop.NetworkManager.IP4Config"
+	ifaceIP6Config = "org.freedesktop.NetworkManager.IP6Config"
+	noObject       = "/" // the value of object path properties that are not set
+)
+
+// Connectivity is the connectivity state that NetworkManager determined, with the
+// values of its NMConnectivityState.
+type Connectivity uint32
+
+const (
+	ConnectivityUnknown Connectivity = iota // not checked, e.g. checks are disabled
+	ConnectivityNone                        // not connected to any network
+	ConnectivityPortal                      // behind a captive portal
+	ConnectivityLimited                     // connected, but the internet cannot be reached
+	ConnectivityFull                        // the internet can be reached
+)
+
+var connectivityNames = [...]string{
+	ConnectivityUnknown: "unknown",
+	ConnectivityNone:    "none",
+	ConnectivityPortal:  "portal",
+	ConnectivityLimited: "limited",
+	ConnectivityFull:    "full",
+}
+
+// String returns the lower case name of c, e.g. "portal".
+func (c Connectivity) String() string {
+	if int(c) >= len(connectivityNames) {
+		return fmt.Sprintf("Connectivity(%d)", uint32(c))
+	}
+	return connectivityNames[c]
+}
+
+// Status returns c as the result of localaddr.CheckConnectivity, so both can be handled
+// alike.
+func (c Connectivity) Status() localaddr.Status {
+	switch c {
+	case ConnectivityNone, ConnectivityLimited:
+		return localaddr.StatusOffline
+	case ConnectivityPortal:
+		return localaddr.StatusCaptive
+	case ConnectivityFull:
+		return localaddr.StatusOnline
+	}
+	return localaddr.StatusUnknown
+}
+
+// Connection is an active connection of NetworkManager.
+type Connection struct {
+	ID        string         // the name of the connection profile, e.g. "Home Wi-Fi"
+	Type      string         // e.g. "802-11-wireless", "802-3-ethernet", "vpn", "wireguard"
+	Interface string         // the interface that carries the traffic, e.g. "wlp2s0"
+	IPv4      []netip.Prefix // the addresses with their prefix lengths, primary first
+	IPv6      []netip.Prefix
+	Gateway   netip.Addr // the IPv4 gateway, or the IPv6 one without IPv4, if any
+}
+
+// Primary returns the primary connection, the one that NetworkManager routes the default
+// traffic through.
+//
+// Returns:
+//   - Connection: The connection (e.g., "Home Wi-Fi" on "wlp2s0" with 192.168.1.2/24)
+//   - error: ErrNotRunning without NetworkManager, ErrNoConnection if nothing is
+//     connected, or an error if the bus fails
+func Primary(ctx context.Context) (Connection, error) {
+	bus, err := connect(ctx)
+	if err != nil {
+		return Connection{}, err
+	}
+	defer bus.Close()
+	path, err := stringProperty(ctx, bus, rootPath, ifaceManager, "PrimaryConnection")
+	if err != nil {
+		return Connection{}, err
+	}
+	if path == noObject {
+		return Connection{}, ErrNoConnection
+	}
+	return activeConnection(ctx, bus, path)
+}
+
+// GetConnectivity returns the connectivity state, as of NetworkManager's last check.
+// It does not start a check, so it is cheap to call often; NetworkManager checks again
+// whenever the network changes.
+//
+// Returns:
+//   - Connectivity: The state (e.g., ConnectivityFull)
+//   - error: ErrNotRunning without NetworkManager, or an error if the bus fails
+func GetConnectivity(ctx context.Context) (Connectivity, error) {
+	bus, err := connect(ctx)
+	if err != nil {
+		return ConnectivityUnknown, err
+	}
+	defer bus.Close()
+	v, err := property(ctx, bus, rootPath, ifaceManager, "Connectivity")
+	if err != nil {
+		return ConnectivityUnknown, err
+	}
+	u, ok := v.(uint32)
+	if !ok {
+		return ConnectivityUnknown, fmt.Errorf("networkmanager: Connectivity is a %T", v)
+	}
+	return Connectivity(u), nil
+}
+
+// Resolver returns the first address of the primary connection. It implements
+// localaddr.Strategy.
+type Resolver struct {
+	// IPv6 selects the IPv6 address of the connection, preferring global addresses to
+	// link-local ones, instead of the IPv4 one.
+	IPv6 bool
+}
+
+// Resolve implements localaddr.Strategy.
+//
+// Returns:
+//   - localaddr.Address: The address (e.g., 192.168.1.2 on "wlp2s0")
+//   - error: ErrNotRunning without NetworkManager, ErrNoConnection if nothing is
+//     connected, or an error if the connection has no address of the family
+func (r Resolver) Resolve(ctx context.Context) (localaddr.Address, error) {
+	conn, err := Primary(ctx)
+	if err != nil {
+		return localaddr.Address{}, err
+	}
+	prefixes := conn.IPv4
+	if r.IPv6 {
+		prefixes = nil
+		for _, p := range conn.IPv6 {
+			if !p.Addr().IsLinkLocalUnicast() {
+				prefixes = append(prefixes, p)
+			}
+		}
+		if len(prefixes) == 0 {
+			prefixes = conn.IPv6
+		}
+	}
+	if len(prefixes) == 0 {
+		family := "IPv4"
+		if r.IPv6 {
+			family = "IPv6"
+		}
+		return localaddr.Address{}, fmt.Errorf("networkmanager: connection %q has no %s address", conn.ID, family)
+	}
+	p := prefixes[0]
+	all, err := localaddr.GetAllDetailed(localaddr.WithInterface(conn.Interface), localaddr.WithIncludeVirtual(),
+		localaddr.WithIncludeNoCarrier(), localaddr.WithIgnoreEnv())
+	if err == nil {
+		for _, a := range all {
+			if a.IP.WithZone("") == p.Addr() {
+				return a, nil
+			}
+		}
+	}
+	return localaddr.Address{IP: p.Addr(), Prefix: p, Interface: conn.Interface}, nil
+}
+
+// connect connects to the system bus, and tells a missing bus by ErrNotRunning.
+func connect(ctx context.Context) (*dbus.Conn, error) {
+	bus, err := dbus.SystemBus(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %w", ErrNotRunning, err)
+	}
+	return bus, nil
+}
+
+// activeConnection reads the Connection.Active object at path.
+func activeConnection(ctx context.Context, bus *dbus.Conn, path string) (Connection, error) {
+	var conn Connection
+	var err error
+	if conn.ID, err = stringProperty(ctx, bus, path, ifaceActive, "Id"); err != nil {
+		return Connection{}, err
+	}
+	if conn.Type, err = stringProperty(ctx, bus, path, ifaceActive, "Type"); err != nil {
+		return Connection{}, err
+	}
+
+	v, err := property(ctx, bus, path, ifaceActive, "Devices")
+	if err != nil {
+		return Connection{}, err
+	}
+	if devices, _ := v.([]any); len(devices) > 0 {
+		device, _ := devices[0].(string)
+		// IpInterface is the one with the addresses, e.g. ppp0 over a modem, and empty
+		// for devices without IP configuration.
+		if conn.Interface, err = stringProperty(ctx, bus, device, ifaceDevice, "IpInterface"); err != nil {
+			return Connection{}, err
+		}
+		if conn.Interface == "" {
+			if conn.Interface, err = stringProperty(ctx, bus, device, ifaceDevice, "Interface"); err != nil {
+				return Connection{}, err
+			}
+		}
+	}
+
+	var gw4, gw6 netip.Addr
+	if conn.IPv4, gw4, err = ipConfig(ctx, bus, path, "Ip4Config", ifaceIP4Config); err != nil {
+		return Connection{}, err
+	}
+	if conn.IPv6, gw6, err = ipConfig(ctx, bus, path, "Ip6Config", ifaceIP6Config); err != nil {
+		return Connection{}, err
+	}
+	conn.Gateway = gw4
+	if !conn.Gateway.IsValid() {
+		conn.Gateway = gw6
+	}
+	return conn, nil
+}
+
+// ipConfig reads the addresses and the gateway of the IP configuration object that the
+// property name of the active connection at path refers to.
+func ipConfig(ctx context.Context, bus *dbus.Conn, path, name, iface string) ([]netip.Prefix, netip.Addr, error) {
+	config, err := stringProperty(ctx, bus, path, ifaceActive, name)
+	if err != nil || config == noObject {
+		return nil, netip.Addr{}, err
+	}
+	v, err := property(ctx, bus, config, iface, "AddressData")
+	if err != nil {
+		return nil, netip.Addr{}, err
+	}
+	list, _ := v.([]any)
+	var prefixes []netip.Prefix
+	for _, item := range list {
+		data, _ := item.(map[any]any)
+		address, _ := data["address"].(string)
+		bits, _ := data["prefix"].(uint32)
+		ip, err := netip.ParseAddr(address)
+		if err != nil {
+			continue
+		}
+		if p := netip.PrefixFrom(ip.Unmap(), int(bits)); p.IsValid() {
+			prefixes = append(prefixes, p)
+		}
+	}
+	gateway, err := stringProperty(ctx, bus, config, iface, "Gateway")
+	if err != nil {
+		return nil, netip.Addr{}, err
+	}
+	gw, _ := netip.ParseAddr(gateway) // empty without a gateway
+	return prefixes, gw, nil
+}
+
+// property reads a property of an object of NetworkManager, and tells a missing service
+// by ErrNotRunning.
+func property(ctx context.Context, bus *dbus.Conn, path, iface, name string) (any, error) {
+	v, err := bus.Property(ctx, service, path, iface, name)
+	if err != nil {
+		if dbus.ServiceUnknown(err) {
+			return nil, fmt.Errorf("%w: %w", ErrNotRunning, err)
+		}
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("networkmanager: reading %s.%s: %w", iface[strings.LastIndexByte(iface, '.')+1:], name, err)
+	}
+	return v, nil
+}
+
+func stringProperty(ctx context.Context, bus *dbus.Conn, path, iface, name string) (string, error) {
+	v, err := property(ctx, bus, path, iface, name)
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("networkmanager: %s is a %T", name, v)
+	}
+	return s, nil
+}
@@ -0,0 +1,165 @@
+package localaddr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Defaults of Online.
+const (
+	// DefaultOnlineURL answers 204 No Content; captive portals answer it with a redirect
+	// or a page of their own instead.
+	DefaultOnlineURL = "https://connectivitycheck.gstatic.com/generate_204"
+	// DefaultOnlineTimeout bounds the name lookup and the request together.
+	DefaultOnlineTimeout = 5 * time.Second
+)
+
+// Status is the result of a connectivity check.
+type Status int
+
+const (
+	StatusUnknown Status = iota // not checked
+	StatusOffline               // no address, or the check URL cannot be reached
+	StatusCaptive               // a captive portal answered instead of the check URL
+	StatusOnline
+)
+
+var statusNames = [...]string{
+	StatusUnknown: "unknown",
+	StatusOffline: "offline",
+	StatusCaptive: "captive",
+	StatusOnline:  "online",
+}
+
+// String returns the lower case name of s, e.g. "captive".
+func (s Status) String() string {
+	if s < 0 || int(s) >= len(statusNames) {
+		return "unknown"
+	}
+	return statusNames[s]
+}
+
+// errCaptive marks check failures caused by a captive portal.
+var errCaptive = errors.New("captive portal")
+
+// WithOnlineURL sets the https or http URL that Online and CheckConnectivity request,
+// instead of DefaultOnlineURL. Any 2xx status counts as online, so a captive portal is
+// only recognized if it redirects.
+func WithOnlineURL(rawURL string) Option {
+	return func(c *config) {
+		u, err := url.Parse(rawURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Hostname() == "" {
+			c.setErr(fmt.Errorf("invalid online check URL %q", rawURL))
+			return
+		}
+		c.onlineURL = u
+	}
+}
+
+// WithOnlineCheck makes a watcher run CheckConnectivity after every address change and
+// report the result in Event.Status. The event is delayed until the check is done, by
+// at most DefaultOnlineTimeout.
+func WithOnlineCheck() Option {
+	return func(c *config) {
+		c.onlineCheck = true
+	}
+}
+
+// Online reports whether the internet can actually be reached, not just whether an
+// interface has an address. It is CheckConnectivity reduced to a yes or no; a captive
+// portal counts as offline.
+//
+// Returns:
+//   - bool: Whether the check succeeded
+//   - error: Why the check failed (e.g., no address, the lookup or the request failing,
+//     or a captive portal), nil if online
+func Online(ctx context.Context, opts ...Option) (bool, error) {
+	if _, err := newConfig(ipv4, opts).connectivity(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// CheckConnectivity resolves the host of DefaultOnlineURL (or the URL of WithOnlineURL)
+// and sends it a HEAD request from the selected address, which is chosen as by Get,
+// through the proxy of the system if there is one (see SystemProxy). The check gives up
+// after DefaultOnlineTimeout, or earlier when ctx is done.
+//
+// Redirects are not followed: a redirect, or for DefaultOnlineURL a success other than
+// 204 No Content, means that a captive portal intercepted the request, as on hotel Wi-Fi
+// before logging in.
+//
+// Returns:
+//   - Status: StatusOnline, StatusCaptive, or StatusOffline
+//   - error: Why the status is not StatusOnline, nil if it is
+func CheckConnectivity(ctx context.Context, opts ...Option) (Status, error) {
+	return newConfig(ipv4, opts).connectivity(ctx)
+}
+
+// connectivity implements CheckConnectivity for cfg.
+func (cfg *config) connectivity(ctx context.Context) (Status, error) {
+	err := cfg.online(ctx)
+	switch {
+	case err == nil:
+		return StatusOnline, nil
+	case errors.Is(err, errCaptive):
+		return StatusCaptive, err
+	}
+	return StatusOffline, err
+}
+
+// online runs the check of CheckConnectivity, failing with errCaptive for a portal.
+func (cfg *config) online(ctx context.Context) error {
+	c, err := first(cfg)
+	if err != nil {
+		return err
+	}
+	u := cfg.onlineURL
+	if u == nil {
+		u, _ = url.Parse(DefaultOnlineURL)
+	}
+	ctx, cancel := context.WithTimeout(ctx, DefaultOnlineTimeout)
+	defer cancel()
+	if _, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname()); err != nil {
+		return fmt.Errorf("online check: %w", err)
+	}
+
+	network := "tcp4"
+	if c.ip.Is6() {
+		network = "tcp6"
+	}
+	dialer := &net.Dialer{LocalAddr: &net.TCPAddr{IP: c.ip.AsSlice(), Zone: c.ip.Zone()}}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = SystemProxy
+	transport.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{
+		Transport:     transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("online check: %w", err)
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode/100 == 3:
+		return fmt.Errorf("online check: %w: redirected to %q", errCaptive, resp.Header.Get("Location"))
+	case cfg.onlineURL == nil && resp.StatusCode/100 == 2 && resp.StatusCode != http.StatusNoContent:
+		return fmt.Errorf("online check: %w: got %s instead of 204 No Content", errCaptive, resp.Status)
+	case resp.StatusCode/100 == 2:
+		return nil
+	}
+	return fmt.Errorf("online check: unexpected status %s", resp.Status)
+}
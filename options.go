@@ -0,0 +1,342 @@
+package localaddr
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Option tunes how an address is selected. Options are passed to Get and the
+// other getters of this package.
+type Option func(*config)
+
+// config is the selection state built from a list of options.
+type config struct {
+	family           family
+	iface            string
+	subnets          []netip.Prefix // required subnets, empty means any
+	preferred        []netip.Prefix
+	includeVirtual   bool
+	includeNoCarrier bool
+	routable         bool     // WithRoutable
+	multicast        bool     // GetMulticastInterface
+	noMulticastLoop  bool     // WithMulticastLoopback(false)
+	include          []string // interface name patterns, empty means all
+	exclude          []string // interface name patterns
+	target           string   // GetOutbound destination
+	defaultRoute     bool
+	preferWired      bool
+	preferStable     bool
+	store            Store      // WithSticky
+	cgnat            Preference // zero if not given
+	tailscale        Preference // zero if not given
+	vpn              Preference // zero if not given, which means Avoid
+	vmAdapters       Preference // zero if not given, which means Avoid
+	allowLinkLocal   bool
+	primaryOnly      bool
+	aliases          map[string][]string // other names of the interfaces, see WithInterface
+	ipv6Scope        IPv6Scope
+	temporary        Preference // of IPv6 privacy extension addresses, zero if not given
+	transition       Preference // of Teredo and 6to4 addresses, zero if not given, which means Exclude
+	pollInterval     time.Duration
+	debounce         time.Duration
+	history          int
+	scanWorkers      int           // ScanSubnet concurrency, zero for the default
+	scanTimeout      time.Duration // ScanSubnet probe timeout, zero for the default
+	scanPorts        []int         // ScanSubnet TCP ports, nil for the default
+	pingCount        int           // PingGateway probes, zero for the default
+	portFirst        int           // FreePort range, zero for any port
+	portLast         int
+	rebind           bool                        // ServeLAN follows address changes
+	onRebind         func(url string, err error) // may be nil
+	filters          []func(net.Interface, netip.Addr) bool
+	dial             func(ctx context.Context, network, address string) (net.Conn, error)
+	provider         Provider     // nil for the operating system
+	osOrder          bool         // WithOSOrder, keep the interface order of the walk
+	verify           bool         // WithVerify
+	reach            bool         // WithReachability
+	reachTarget      string       // WithReachability, "" for the gateways
+	onlineURL        *url.URL     // Online target, nil for DefaultOnlineURL
+	onlineCheck      bool         // watcher events carry the result of Online
+	logger           *slog.Logger // WithLogger, nil for none
+	ignoreEnv        bool
+	kubernetes       bool // WithKubernetes
+	inContainer      bool // see InContainer, false with WithIgnoreContainer
+	ignoreContainer  bool
+	byHostname       bool
+	override         []netip.Addr // from EnvOverride, or the pod IP of WithKubernetes
+	overrideEnv      string       // the variable override was read from
+	err              error        // first error found while applying options
+}
+
+// newConfig applies opts on top of a config selecting addresses of family f.
+func newConfig(f family, opts []Option) *config {
+	cfg := &config{family: f, pollInterval: DefaultPollInterval}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	cfg.applyEnv()
+	cfg.applyKubernetes()
+	cfg.resolveAliases()
+	cfg.inContainer = !cfg.ignoreContainer && cfg.provider == nil && InContainer()
+	return cfg
+}
+
+// WithIPv6 makes the getter look for IPv6 addresses instead of IPv4 ones.
+//
+// As with GetIPv6, link-local addresses are skipped unless WithAllowLinkLocal is given.
+func WithIPv6() Option {
+	return func(c *config) {
+		c.family = ipv6
+	}
+}
+
+// WithInterface restricts the scan to the interface with the given name (e.g. "eth0").
+// Errors then mention the interface, see GetByInterface.
+//
+// On Windows, where the names are the friendly names of the adapters, such as
+// "Ethernet 3", the adapter can also be named by its description (see Address.Adapter),
+// ignoring case, or by its GUID, with or without braces. The patterns of
+// WithIncludeInterfaces and WithExcludeInterfaces match these names too.
+func WithInterface(name string) Option {
+	return func(c *config) {
+		c.iface = name
+	}
+}
+
+// WithPreferredSubnet makes addresses inside the given subnet (in CIDR notation,
+// e.g. "10.0.0.0/8") win over addresses found earlier in the scan. Addresses outside
+// of it are still returned if nothing matches. The subnet outweighs every other ranking
+// criterion, see Rank, also an avoided VPN against a preferred group such as
+// WithCGNAT(Prefer). The option can be given multiple times; earlier subnets are
+// preferred over later ones.
+func WithPreferredSubnet(cidr string) Option {
+	return func(c *config) {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			c.setErr(fmt.Errorf("invalid preferred subnet: %w", err))
+			return
+		}
+		c.preferred = append(c.preferred, prefix.Masked())
+	}
+}
+
+// WithAllowLinkLocal makes link-local addresses (169.254.0.0/16 and fe80::/10) candidates.
+// They are skipped by default: an interface gets an IPv4 one when DHCP fails, and
+// returning it is almost never wanted, so without a proper address the getters report
+// ErrNotConnected. Networks that rely on link-local addressing, e.g. some direct cable
+// connections and embedded devices, need this option. Other addresses still rank higher.
+//
+// IPv6 link-local addresses carry the name of their interface as zone, see
+// netip.Addr.WithZone, so that the returned string can be dialed, e.g. "[fe80::1%eth0]:80".
+func WithAllowLinkLocal() Option {
+	return func(c *config) {
+		c.allowLinkLocal = true
+	}
+}
+
+// WithPrimaryOnly skips secondary IPv4 addresses: aliases in the subnet of another
+// address of the same interface, such as a floating service IP that keepalived adds to
+// eth0. Linux marks them as secondary; elsewhere the first address of a subnet counts as
+// the primary one, and those after it as secondaries. See ReportedAddr.Secondary.
+func WithPrimaryOnly() Option {
+	return func(c *config) {
+		c.primaryOnly = true
+	}
+}
+
+// WithPreferWired makes addresses of wired Ethernet interfaces win over all others, in
+// particular over Wi-Fi, when a machine is connected both ways. Only WithPreferredSubnet
+// weighs more. Among several wired interfaces the usual ranking applies, see Rank.
+//
+// The interface type is read from sysfs on Linux, from the interface media on macOS, and
+// from the adapter type on Windows. Elsewhere nothing is known to be wired, and the option
+// has no effect.
+func WithPreferWired() Option {
+	return func(c *config) {
+		c.preferWired = true
+	}
+}
+
+// WithSubnet restricts the result to addresses inside prefix (e.g. 192.168.0.0/16).
+// Unlike WithPreferredSubnet, addresses outside of it are never returned. The option
+// can be given multiple times; an address then has to be inside any of the subnets.
+//
+// The option does not change the address family: use WithIPv6 with IPv6 prefixes,
+// or GetInSubnet, which picks the family from the prefix.
+func WithSubnet(prefix netip.Prefix) Option {
+	return func(c *config) {
+		if !prefix.IsValid() {
+			c.setErr(fmt.Errorf("invalid subnet %s", prefix))
+			return
+		}
+		c.subnets = append(c.subnets, prefix.Masked())
+	}
+}
+
+// WithDefaultRoute makes the getter return an address of the interface that owns the
+// default route (see Gateway), rather than of the first interface found. On machines
+// with many interfaces this is almost always the one meant by "my local address".
+//
+// The interface is used even if the name filters would skip it, e.g. when the default
+// route goes through a VPN tunnel. WithIPv6 selects the interface of the IPv6 default
+// route.
+func WithDefaultRoute() Option {
+	return func(c *config) {
+		c.defaultRoute = true
+	}
+}
+
+// WithExcludeVirtual skips interfaces that look virtual, such as Docker bridges,
+// veth pairs, and hypervisor host adapters. See IsVirtual for the heuristic.
+//
+// This is the default; the option exists to undo an earlier WithIncludeVirtual.
+func WithExcludeVirtual() Option {
+	return func(c *config) {
+		c.includeVirtual = false
+	}
+}
+
+// WithIncludeVirtual makes the scan consider virtual interfaces, which are skipped
+// by default. Use it when the address of a bridge or host-only adapter is wanted.
+func WithIncludeVirtual() Option {
+	return func(c *config) {
+		c.includeVirtual = true
+	}
+}
+
+// WithIncludeInterfaces restricts the scan to interfaces whose name matches at least
+// one of the given glob patterns (e.g. "en*", "eth*"). The pattern syntax is that of
+// path.Match. The option can be given multiple times; the patterns add up.
+func WithIncludeInterfaces(patterns ...string) Option {
+	return func(c *config) {
+		if c.checkPatterns(patterns) {
+			c.include = append(c.include, patterns...)
+		}
+	}
+}
+
+// WithExcludeInterfaces skips interfaces whose name matches any of the given glob patterns
+// (e.g. "docker*", "veth*"). The pattern syntax is that of path.Match.
+//
+// Exclusion takes precedence over WithIncludeInterfaces: an interface matching both is
+// skipped. Neither applies to WithInterface, which always scans the named interface.
+func WithExcludeInterfaces(patterns ...string) Option {
+	return func(c *config) {
+		if c.checkPatterns(patterns) {
+			c.exclude = append(c.exclude, patterns...)
+		}
+	}
+}
+
+// WithOSOrder keeps the interfaces in the order the operating system reports them in,
+// instead of sorting them by index and name before the selection. That order decides
+// between candidates of equal score; it differs between platforms and may change
+// across reboots, which makes the result of Get unpredictable on hosts with several
+// interfaces. Only use it to reproduce the behavior of earlier versions.
+func WithOSOrder() Option {
+	return func(c *config) {
+		c.osOrder = true
+	}
+}
+
+// checkPatterns validates glob patterns, recording an error for the first bad one.
+func (c *config) checkPatterns(patterns []string) bool {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			c.setErr(fmt.Errorf("invalid interface pattern %q: %w", pattern, err))
+			return false
+		}
+	}
+	return true
+}
+
+// allowed reports whether the interface name passes the include and exclude patterns,
+// with its aliases.
+func (c *config) allowed(name string) bool {
+	names := append([]string{name}, c.aliases[name]...)
+	matches := func(patterns []string) bool {
+		return slices.ContainsFunc(names, func(n string) bool { return matchAny(patterns, n) })
+	}
+	if matches(c.exclude) {
+		return false
+	}
+	return len(c.include) == 0 || matches(c.include)
+}
+
+// matchAny reports whether name matches any of the (already validated) patterns.
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// setErr records err, wrapping ErrInvalidOption, unless an earlier option already failed.
+func (c *config) setErr(err error) {
+	if c.err == nil {
+		c.err = &optionError{err}
+	}
+}
+
+// inSubnet reports whether ip passes the WithSubnet restriction.
+func (c *config) inSubnet(ip netip.Addr) bool {
+	if len(c.subnets) == 0 {
+		return true
+	}
+	for _, prefix := range c.subnets {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// notFound returns the error for a scan that found no usable address. connected tells
+// whether the scanned interfaces had usable addresses before filtering by family and
+// subnet. Lookups of any family wrap ErrNotConnected, as there is no family to blame.
+func (c *config) notFound(connected bool) error {
+	var err error
+	switch {
+	case !connected:
+		return ErrNotConnected
+	case c.family == ipv4:
+		err = ErrNoIPv4
+	case c.family == ipv6:
+		err = ErrNoIPv6
+	default:
+		err = fmt.Errorf("%w: no usable address", ErrNotConnected)
+	}
+	if len(c.subnets) > 0 {
+		return fmt.Errorf("%w in %s", err, joinPrefixes(c.subnets))
+	}
+	return err
+}
+
+// joinPrefixes formats prefixes as a comma separated list.
+func joinPrefixes(prefixes []netip.Prefix) string {
+	s := make([]string, len(prefixes))
+	for i, prefix := range prefixes {
+		s[i] = prefix.String()
+	}
+	return strings.Join(s, ", ")
+}
+
+// rank returns the preference rank of ip, lower is better.
+func (c *config) rank(ip netip.Addr) int {
+	for i, prefix := range c.preferred {
+		if prefix.Contains(ip.WithZone("")) {
+			return i
+		}
+	}
+	return len(c.preferred)
+}
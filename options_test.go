@@ -0,0 +1,63 @@
+package localaddr_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/golovingreg/localaddr"
+	"github.com/golovingreg/localaddr/localaddrtest"
+)
+
+func TestInterfaceFilters(t *testing.T) {
+	network := localaddrtest.New().Loopback().
+		Interface("eth0", "192.168.1.10/24").
+		Interface("eth1", "10.0.0.10/24").
+		Interface("wlan0", "192.168.2.10/24").
+		Interface("docker0", "172.17.0.1/16")
+	aliases := localaddr.WithAliases(map[string][]string{"eth1": {"Ethernet 2", "Intel(R) Ethernet Connection"}})
+
+	tests := []struct {
+		name string
+		opts []localaddr.Option
+		want []string // interfaces of the addresses, nil if none is found
+		err  error
+	}{
+		{"no filters", nil, []string{"eth0", "eth1", "wlan0"}, nil},
+		{"include", []localaddr.Option{localaddr.WithIncludeInterfaces("eth*")}, []string{"eth0", "eth1"}, nil},
+		{"includes add up", []localaddr.Option{localaddr.WithIncludeInterfaces("eth0"), localaddr.WithIncludeInterfaces("wlan*")}, []string{"eth0", "wlan0"}, nil},
+		{"exclude", []localaddr.Option{localaddr.WithExcludeInterfaces("eth1")}, []string{"eth0", "wlan0"}, nil},
+		{"exclude beats include", []localaddr.Option{localaddr.WithIncludeInterfaces("eth*"), localaddr.WithExcludeInterfaces("eth1")}, []string{"eth0"}, nil},
+		{"exclude beats include in any order", []localaddr.Option{localaddr.WithExcludeInterfaces("eth*"), localaddr.WithIncludeInterfaces("eth0")}, nil, localaddr.ErrNotConnected},
+		{"include does not add virtual interfaces", []localaddr.Option{localaddr.WithIncludeInterfaces("docker*")}, nil, localaddr.ErrNotConnected},
+		{"character class", []localaddr.Option{localaddr.WithIncludeInterfaces("eth[1-9]")}, []string{"eth1"}, nil},
+		{"WithInterface bypasses exclude", []localaddr.Option{localaddr.WithExcludeInterfaces("eth*"), localaddr.WithInterface("eth1")}, []string{"eth1"}, nil},
+		{"WithInterface bypasses include", []localaddr.Option{localaddr.WithIncludeInterfaces("eth*"), localaddr.WithInterface("wlan0")}, []string{"wlan0"}, nil},
+		{"include alias", []localaddr.Option{aliases, localaddr.WithIncludeInterfaces("Ethernet *")}, []string{"eth1"}, nil},
+		{"exclude alias", []localaddr.Option{aliases, localaddr.WithExcludeInterfaces("Intel*")}, []string{"eth0", "wlan0"}, nil},
+		{"exclude alias beats included name", []localaddr.Option{aliases, localaddr.WithIncludeInterfaces("eth*"), localaddr.WithExcludeInterfaces("Ethernet 2")}, []string{"eth0"}, nil},
+		{"invalid pattern", []localaddr.Option{localaddr.WithIncludeInterfaces("eth[")}, nil, localaddr.ErrInvalidOption},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addrs, err := localaddr.GetAllDetailed(append(tt.opts, network.Option())...)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("GetAllDetailed() error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetAllDetailed() error = %v", err)
+			}
+			var got []string
+			for _, a := range addrs {
+				got = append(got, a.Interface)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("GetAllDetailed() interfaces = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
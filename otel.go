@@ -0,0 +1,75 @@
+package localaddr
+
+import (
+	"errors"
+	"net"
+	"slices"
+	"strings"
+)
+
+// OpenTelemetry resource attribute keys set by ResourceAttributes, from the semantic
+// conventions for hosts and networks.
+const (
+	AttrHostIP        = "host.ip"
+	AttrHostMAC       = "host.mac"
+	AttrInterfaceName = "network.interface.name"
+)
+
+// ResourceAttributes describes the selected addresses as OpenTelemetry resource
+// attributes, so that telemetry is labelled with the address Get picks rather than one
+// chosen by the pipeline's own detector. No OpenTelemetry module is needed; the values
+// are strings or string slices, to be converted with attribute.String and
+// attribute.StringSlice:
+//
+//	for k, v := range attrs {
+//		switch v := v.(type) {
+//		case string:
+//			kvs = append(kvs, attribute.String(k, v))
+//		case []string:
+//			kvs = append(kvs, attribute.StringSlice(k, v))
+//		}
+//	}
+//
+// AttrHostIP lists the addresses Get and GetIPv6 select, AttrHostMAC the MAC addresses of
+// their interfaces in the upper case, hyphenated form the conventions require, and
+// AttrInterfaceName the interface of the IPv4 address, or of the IPv6 one without it.
+// Attributes without a value are left out.
+//
+// Returns:
+//   - map[string]any: The attributes (e.g., {"host.ip": ["192.168.1.42"],
+//     "host.mac": ["AC-DE-48-23-45-67"], "network.interface.name": "eth0"})
+//   - error: An error if neither an IPv4 nor an IPv6 address is selected
+func ResourceAttributes(opts ...Option) (map[string]any, error) {
+	v4, err4 := GetDetailed(opts...)
+	v6, err6 := GetIPv6Detailed(opts...)
+	if err4 != nil && err6 != nil {
+		return nil, errors.Join(err4, err6)
+	}
+	var selected []Address
+	if err4 == nil {
+		selected = append(selected, v4)
+	}
+	if err6 == nil {
+		selected = append(selected, v6)
+	}
+	var ips, macs []string
+	for _, a := range selected {
+		ips = append(ips, a.IP.WithZone("").String())
+		if mac := otelMAC(a.MAC); mac != "" && !slices.Contains(macs, mac) {
+			macs = append(macs, mac)
+		}
+	}
+	attrs := map[string]any{AttrHostIP: ips}
+	if len(macs) > 0 {
+		attrs[AttrHostMAC] = macs
+	}
+	if name := selected[0].Interface; name != "" {
+		attrs[AttrInterfaceName] = name
+	}
+	return attrs, nil
+}
+
+// otelMAC formats mac as the semantic conventions require, e.g. "AC-DE-48-23-45-67".
+func otelMAC(mac net.HardwareAddr) string {
+	return strings.ToUpper(strings.ReplaceAll(mac.String(), ":", "-"))
+}
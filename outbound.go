@@ -0,0 +1,153 @@
+package localaddr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+// Default targets of the outbound-dial mode. Nothing is ever sent to them.
+const (
+	DefaultTarget     = "8.8.8.8:53"
+	DefaultTargetIPv6 = "[2001:4860:4860::8888]:53"
+)
+
+// WithTarget sets the host:port that GetOutbound routes towards, instead of
+// DefaultTarget or DefaultTargetIPv6. Other getters ignore it.
+func WithTarget(hostport string) Option {
+	return func(c *config) {
+		c.target = hostport
+	}
+}
+
+// WithDialer sets the function that the outbound-dial mode connects its UDP socket with,
+// instead of a net.Dialer; dial must be of a UDP network, so that no packet is sent. It
+// lets GetOutbound and GetFor work where the standard library has no sockets, e.g. on
+// WASI with a host that provides them, and it is used by the getters' fallback on
+// platforms that hide the interfaces. Without it, those platforms return
+// ErrUnsupportedPlatform.
+func WithDialer(dial func(ctx context.Context, network, address string) (net.Conn, error)) Option {
+	return func(c *config) {
+		c.dial = dial
+	}
+}
+
+// GetOutbound returns the local address the operating system uses for outbound traffic.
+//
+// Instead of scanning interfaces, it connects a UDP socket towards a target (DefaultTarget,
+// or the one set with WithTarget) and reads the socket's local address. Connecting a UDP
+// socket only consults the routing table, no packet is actually sent, so the target does
+// not need to be reachable, only routable. The result often differs from Get on machines
+// with several interfaces, since it is the address the kernel really picks.
+//
+// WithIPv6 and WithTarget are honored; the interface filters do not apply.
+//
+// Returns:
+//   - string: The address as a string (e.g., "192.168.1.2")
+//   - error: An error if there is no route to the target
+func GetOutbound(opts ...Option) (string, error) {
+	return str(outbound(context.Background(), newConfig(ipv4, opts)))
+}
+
+// GetOutboundAddr is like GetOutbound, but returns the address as a netip.Addr.
+func GetOutboundAddr(opts ...Option) (netip.Addr, error) {
+	return outbound(context.Background(), newConfig(ipv4, opts))
+}
+
+// outbound implements the outbound-dial mode for cfg.
+func outbound(ctx context.Context, cfg *config) (netip.Addr, error) {
+	if cfg.err != nil {
+		return netip.Addr{}, cfg.err
+	}
+	if found := cfg.overridden(); len(found) > 0 {
+		return found[0].ip, nil
+	}
+	network, target := "udp4", DefaultTarget
+	if cfg.family == ipv6 {
+		network, target = "udp6", DefaultTargetIPv6
+	}
+	if cfg.target != "" {
+		target = cfg.target
+	}
+	return cfg.source(ctx, network, target)
+}
+
+// unlisted is scan on platforms that refuse to list the interfaces with err, e.g. for
+// apps on Android: the outbound address is the only candidate then, without interface
+// details. Restrictions by name do not apply, WithSubnet does.
+func (cfg *config) unlisted(err error) ([]candidate, error) {
+	ip, oerr := outbound(context.Background(), cfg)
+	if oerr != nil {
+		return nil, errors.Join(err, oerr)
+	}
+	if !cfg.usable(ip, cfg.family) || !cfg.inSubnet(ip) {
+		return nil, cfg.notFound(true)
+	}
+	cfg.trace("selected address", "address", ip, "reason", "interfaces cannot be listed")
+	return []candidate{{ip: ip, prefix: netip.PrefixFrom(ip.WithZone(""), ip.BitLen())}}, nil
+}
+
+// GetFor returns the local address the operating system uses to reach dst, i.e. the
+// one to tell that peer about, as GetOutbound does for a default target. It answers
+// precisely for hosts with several networks: a peer on the LAN gets the LAN address, one
+// behind a VPN the tunnel's address. No packet is sent.
+//
+// The family of the result is that of dst; a link-local dst needs its zone, e.g.
+// "fe80::1%eth0", and the result then carries it too. The interface filters do not
+// apply, EnvOverride does.
+//
+// Returns:
+//   - string: The address as a string (e.g., "192.168.1.2")
+//   - error: An error if there is no route to dst
+func GetFor(dst netip.Addr, opts ...Option) (string, error) {
+	return str(GetForAddr(dst, opts...))
+}
+
+// GetForAddr is like GetFor, but returns the address as a netip.Addr.
+func GetForAddr(dst netip.Addr, opts ...Option) (netip.Addr, error) {
+	if !dst.IsValid() {
+		return netip.Addr{}, errors.New("invalid destination address")
+	}
+	dst = dst.Unmap()
+	network, f := "udp4", ipv4
+	if dst.Is6() {
+		network, f = "udp6", ipv6
+	}
+	cfg := newConfig(f, opts)
+	if cfg.err != nil {
+		return netip.Addr{}, cfg.err
+	}
+	if found := cfg.overridden(); len(found) > 0 {
+		return found[0].ip, nil
+	}
+	return cfg.source(context.Background(), network, netip.AddrPortFrom(dst, 9).String()) // the discard port
+}
+
+// source connects a UDP socket to target and returns the local address the kernel bound
+// it to.
+func (cfg *config) source(ctx context.Context, network, target string) (netip.Addr, error) {
+	dial := cfg.dial
+	if dial == nil && noInterfaces {
+		return netip.Addr{}, ErrUnsupportedPlatform
+	}
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	conn, err := dial(ctx, network, target)
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("no route to %s: %w", target, err)
+	}
+	defer conn.Close()
+	local, ok := conn.LocalAddr().(*net.UDPAddr)
+	if !ok {
+		return netip.Addr{}, fmt.Errorf("unexpected local address %v", conn.LocalAddr())
+	}
+	ip, _ := netip.AddrFromSlice(local.IP)
+	ip = ip.Unmap()
+	if ip.Is6() && ip.IsLinkLocalUnicast() && local.Zone != "" {
+		ip = ip.WithZone(local.Zone)
+	}
+	return ip, nil
+}
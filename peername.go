@@ -0,0 +1,181 @@
+package localaddr
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/golovingreg/localaddr/internal/dns"
+	"github.com/golovingreg/localaddr/internal/netbios"
+)
+
+// llmnrPort is the port of LLMNR responders (RFC 4795).
+const llmnrPort = 5355
+
+// peerNameTimeout bounds LookupPeerName if ctx has no deadline.
+const peerNameTimeout = 2 * time.Second
+
+// LookupPeerName returns the name of the machine at peer on the LAN, e.g. "DESKTOP-1234"
+// for a Windows machine, which neither DNS nor mDNS may know. It asks peer itself, with
+// an LLMNR query (RFC 4795) for the PTR record of its address and, for IPv4 addresses,
+// a NetBIOS node status request, which also older Windows machines and Samba servers
+// answer, and returns the first name it gets. Machines with macOS or Linux usually
+// answer neither, only multicast DNS, as that of MDNSName does for this machine.
+//
+// The queries are sent from the address selected as by Get, or GetIPv6 for an IPv6
+// peer, with the same options; WithInterface picks the interface the peer is on.
+// Without a deadline on ctx, the answers are awaited for two seconds.
+//
+// Returns:
+//   - string: The name of the machine (e.g., "DESKTOP-1234")
+//   - error: An error if no address is selected, or peer answers neither query, e.g.
+//     because its firewall blocks them
+func LookupPeerName(ctx context.Context, peer netip.Addr, opts ...Option) (string, error) {
+	peer = peer.Unmap()
+	f := ipv4
+	if peer.Is6() {
+		f = ipv6
+	}
+	cfg := newConfig(f, opts)
+	cfg.family = f // the family of peer decides, also against WithIPv6
+	c, err := first(cfg)
+	if err != nil {
+		return "", err
+	}
+	if peer.Is6() && peer.IsLinkLocalUnicast() && peer.Zone() == "" {
+		peer = peer.WithZone(c.iface.Name)
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, peerNameTimeout)
+		defer cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		name string
+		err  error
+	}
+	lookups := []func() (string, error){
+		func() (string, error) { return llmnrName(ctx, c.ip, peer) },
+	}
+	if peer.Is4() {
+		lookups = append(lookups, func() (string, error) { return netbiosName(ctx, c.ip, peer) })
+	}
+	results := make(chan result, len(lookups))
+	for _, lookup := range lookups {
+		go func() {
+			name, err := lookup()
+			results <- result{name, err}
+		}()
+	}
+	var errs []error
+	for range lookups {
+		r := <-results
+		if r.err == nil {
+			return r.name, nil
+		}
+		errs = append(errs, r.err)
+	}
+	return "", fmt.Errorf("looking up the name of %s: %w", peer, errors.Join(errs...))
+}
+
+// llmnrName asks the LLMNR responder of peer for the PTR record of its address.
+func llmnrName(ctx context.Context, src, peer netip.Addr) (string, error) {
+	reverse := dns.ReverseName(peer)
+	id := queryID()
+	query, err := (&dns.Message{
+		ID:        id,
+		Questions: []dns.Question{{Name: reverse, Type: dns.TypePTR, Class: dns.ClassINET}},
+	}).Pack()
+	if err != nil {
+		return "", err
+	}
+	name, err := exchangeUDP(ctx, src, netip.AddrPortFrom(peer, llmnrPort), query, func(b []byte) string {
+		msg, err := dns.Parse(b)
+		if err != nil || msg.ID != id || msg.Flags&dns.FlagResponse == 0 {
+			return ""
+		}
+		for _, r := range msg.Answers {
+			if r.Type == dns.TypePTR && dns.EqualNames(r.Name, reverse) && r.Target != "" {
+				return r.Target
+			}
+		}
+		return ""
+	})
+	if err != nil {
+		return "", fmt.Errorf("LLMNR: %w", err)
+	}
+	return name, nil
+}
+
+// netbiosName asks the NetBIOS name service of peer for its names, and returns its
+// computer name.
+func netbiosName(ctx context.Context, src, peer netip.Addr) (string, error) {
+	id := queryID()
+	query, err := netbios.NodeStatus(id)
+	if err != nil {
+		return "", err
+	}
+	name, err := exchangeUDP(ctx, src, netip.AddrPortFrom(peer, netbios.Port), query, func(b []byte) string {
+		names, err := netbios.ParseNodeStatus(b, id)
+		if err != nil {
+			return ""
+		}
+		for _, n := range names {
+			if n.Suffix == netbios.SuffixWorkstation && !n.Group && n.Name != "" {
+				return n.Name
+			}
+		}
+		return ""
+	})
+	if err != nil {
+		return "", fmt.Errorf("NetBIOS: %w", err)
+	}
+	return name, nil
+}
+
+// exchangeUDP sends query from src to dst, and returns the name that answer finds in the
+// first message from dst that has one, or an error once ctx is done.
+func exchangeUDP(ctx context.Context, src netip.Addr, dst netip.AddrPort, query []byte, answer func([]byte) string) (string, error) {
+	conn, err := net.ListenUDP("udp", net.UDPAddrFromAddrPort(netip.AddrPortFrom(src, 0)))
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.SetReadDeadline(time.Now()) })
+	defer stop()
+
+	if _, err := conn.WriteToUDPAddrPort(query, dst); err != nil {
+		return "", err
+	}
+	buf := make([]byte, 1500)
+	for {
+		n, from, err := conn.ReadFromUDPAddrPort(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", errors.New("no answer")
+			}
+			return "", err
+		}
+		if from.Addr().Unmap().WithZone("") != dst.Addr().WithZone("") {
+			continue
+		}
+		if name := answer(buf[:n]); name != "" {
+			return name, nil
+		}
+	}
+}
+
+// queryID returns a random message ID.
+func queryID() uint16 {
+	var id [2]byte
+	rand.Read(id[:])
+	return binary.BigEndian.Uint16(id[:])
+}
@@ -0,0 +1,157 @@
+package localaddr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/golovingreg/localaddr/internal/icmp"
+)
+
+// Defaults of PingGateway.
+const (
+	DefaultPingCount    = 4
+	DefaultPingTimeout  = time.Second
+	pingInterval        = 250 * time.Millisecond
+	pingUnreachablePort = 33434 // the first port traceroute uses, which nothing listens on
+)
+
+// WithPingCount sets how many probes PingGateway sends, see DefaultPingCount.
+func WithPingCount(n int) Option {
+	return func(c *config) {
+		if n <= 0 {
+			c.setErr(fmt.Errorf("invalid ping count %d", n))
+			return
+		}
+		c.pingCount = n
+	}
+}
+
+// PingStats summarizes the probes sent by PingGateway.
+type PingStats struct {
+	Gateway   netip.Addr
+	Interface string
+	Probe     string // "icmp" for echo requests, "udp" for the fallback
+	Sent      int
+	Received  int
+	Loss      float64       // fraction of probes without an answer, from 0 to 1
+	Min       time.Duration // round trip times of the answered probes, zero if there were none
+	Avg       time.Duration
+	Max       time.Duration
+}
+
+// PingGateway measures the round trip time and the loss to the default gateway, found as
+// by Gateway, as a quick health signal of the local network. The probes are sent every
+// 250ms and each waits DefaultPingTimeout for its answer.
+//
+// The probes are ICMP echo requests where the process may send them, see ScanSubnet.
+// Otherwise, and for IPv6 gateways, they are UDP datagrams to a port nothing listens on,
+// answered by the gateway with a port unreachable message; gateways that rate limit
+// these messages or drop such datagrams show more loss than there is.
+//
+// Returns:
+//   - PingStats: The statistics (e.g., 4 sent, 4 received, 1.2ms average to 192.168.1.1)
+//   - error: An error if there is no default gateway or no probe can be sent, or ctx.Err()
+//     if ctx ended the probes early, in which case the statistics so far are returned
+func PingGateway(ctx context.Context, opts ...Option) (PingStats, error) {
+	cfg := newConfig(ipv4, opts)
+	if cfg.err != nil {
+		return PingStats{}, cfg.err
+	}
+	r, err := defaultRoute(cfg)
+	if err != nil {
+		return PingStats{}, err
+	}
+	gw := r.gw
+	if gw.Is6() && gw.IsLinkLocalUnicast() && gw.Zone() == "" {
+		gw = gw.WithZone(r.iface)
+	}
+	stats := PingStats{Gateway: gw, Interface: r.iface, Probe: "udp"}
+	probe := udpProbe
+	if gw.Is4() {
+		if conn, err := icmp.Listen(netip.Addr{}); err == nil {
+			defer conn.Close()
+			stats.Probe, probe = "icmp", conn.Ping
+		}
+	}
+	count := DefaultPingCount
+	if cfg.pingCount > 0 {
+		count = cfg.pingCount
+	}
+	var total time.Duration
+	var lastErr error
+	for i := range count {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(pingInterval):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		pctx, cancel := context.WithTimeout(ctx, DefaultPingTimeout)
+		rtt, err := probe(pctx, gw)
+		cancel()
+		stats.Sent++
+		if err != nil {
+			if !errors.Is(err, context.DeadlineExceeded) {
+				lastErr = err // a probe that could not be sent
+			}
+			continue
+		}
+		stats.Received++
+		total += rtt
+		if stats.Min == 0 || rtt < stats.Min {
+			stats.Min = rtt
+		}
+		stats.Max = max(stats.Max, rtt)
+	}
+	if stats.Sent > 0 {
+		stats.Loss = 1 - float64(stats.Received)/float64(stats.Sent)
+	}
+	if stats.Received > 0 {
+		stats.Avg = total / time.Duration(stats.Received)
+	}
+	if err := ctx.Err(); err != nil {
+		return stats, err
+	}
+	if stats.Received == 0 && lastErr != nil {
+		return stats, fmt.Errorf("pinging %s: %w", gw, lastErr)
+	}
+	return stats, nil
+}
+
+// udpProbe sends a datagram to a closed port of dst and waits for the port unreachable
+// answer, which a connected socket reports as a refused read.
+func udpProbe(ctx context.Context, dst netip.Addr) (time.Duration, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", netip.AddrPortFrom(dst, pingUnreachablePort).String())
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	start := time.Now()
+	if _, err := conn.Write([]byte("localaddr")); err != nil {
+		return 0, err
+	}
+	_, err = conn.Read(make([]byte, 64))
+	rtt := time.Since(start)
+	switch {
+	case err == nil, refused(err):
+		return rtt, nil // something answered
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, net.ErrClosed):
+		return 0, context.DeadlineExceeded
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return 0, context.DeadlineExceeded
+	}
+	return 0, err
+}
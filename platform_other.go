@@ -0,0 +1,6 @@
+//go:build !js && !wasip1
+
+package localaddr
+
+// noInterfaces tells that the platform has no network interfaces to list.
+const noInterfaces = false
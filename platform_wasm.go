@@ -0,0 +1,8 @@
+//go:build js || wasip1
+
+package localaddr
+
+// noInterfaces tells that the platform has no network interfaces to list: the standard
+// library reports none in a browser and under WASI, and its sockets there are an
+// in-memory fake.
+const noInterfaces = true
@@ -0,0 +1,69 @@
+package portmap
+
+import (
+	"context"
+	"net/netip"
+	"time"
+
+	"github.com/golovingreg/localaddr"
+)
+
+// unmapTimeout bounds deleting the mapping once the context of MapPort is done.
+const unmapTimeout = 2 * time.Second
+
+// MapPort exposes internalPort on the local address to the internet: it discovers the
+// router as Discover does, asks it to forward externalPort of its WAN address there,
+// and keeps the mapping alive in the background until ctx is done, when it is deleted.
+//
+// protocol is "tcp" or "udp". lease is the lifetime to ask for, DefaultLifetime if zero;
+// the mapping is renewed when half of the granted lifetime has passed, and renewals
+// that fail are retried, each time after half of the remaining time. externalPort is a
+// suggestion, zero for any port; the router may pick another one, so announce the
+// returned address rather than the port asked for. The address is the WAN address of
+// the router, which is not public behind a second NAT.
+//
+// Returns:
+//   - netip.AddrPort: The external address and port (e.g., "203.0.113.7:8080")
+//   - error: An error wrapping ErrNoGateway if the router maps no ports, or the error it reports
+func MapPort(ctx context.Context, protocol string, internalPort, externalPort int, lease time.Duration, opts ...localaddr.Option) (netip.AddrPort, error) {
+	c, err := Discover(ctx, opts...)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	m, err := c.Map(ctx, protocol, internalPort, externalPort, lease)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	go c.keep(ctx, m)
+	return m.External, nil
+}
+
+// keep renews m until ctx is done, then deletes it.
+func (c *Client) keep(ctx context.Context, m Mapping) {
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), unmapTimeout)
+		defer cancel()
+		c.Unmap(ctx, m)
+	}()
+	if m.Lifetime == 0 {
+		<-ctx.Done() // permanent
+		return
+	}
+	left := m.Lifetime
+	for {
+		wait := max(left/2, time.Second)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		renewed, err := c.Renew(ctx, m)
+		if err != nil {
+			left = max(left-wait, 0) // try again sooner; once it has ended, Renew maps anew
+			continue
+		}
+		m, left = renewed, renewed.Lifetime
+	}
+}
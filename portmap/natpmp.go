@@ -0,0 +1,133 @@
+package portmap
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"time"
+)
+
+// serverPort is the port of both NAT-PMP and PCP servers.
+const serverPort = 5351
+
+// errNoAnswer is returned by exchange when the gateway does not answer, or refuses the
+// packets, which means it runs no server.
+var errNoAnswer = errors.New("no answer from the gateway")
+
+// natpmpResults are the result codes of RFC 6886 section 3.5.
+var natpmpResults = []string{
+	1: "unsupported version",
+	2: "not authorized",
+	3: "network failure",
+	4: "out of resources",
+	5: "unsupported opcode",
+}
+
+// natpmpExternal asks a NAT-PMP server for its external address.
+func natpmpExternal(ctx context.Context, local, gateway netip.Addr) (netip.Addr, error) {
+	resp, err := exchange(ctx, local, gateway, []byte{0, 0}, func(b []byte) bool {
+		return len(b) >= 12 && b[0] == 0 && b[1] == 128 || len(b) >= 4 && b[0] == pcpVersion
+	})
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	if resp[0] == pcpVersion {
+		return netip.Addr{}, &Error{Method: NATPMP, Code: 1, Message: natpmpResults[1]} // a PCP-only server
+	}
+	if err := natpmpError(resp); err != nil {
+		return netip.Addr{}, err
+	}
+	return netip.AddrFrom4([4]byte(resp[8:12])), nil
+}
+
+// natpmpMap creates, renews, or with a zero lifetime deletes m with NAT-PMP.
+func natpmpMap(ctx context.Context, local, gateway netip.Addr, m Mapping) (Mapping, error) {
+	op := byte(1) // UDP
+	if m.Protocol == "tcp" {
+		op = 2
+	}
+	req := make([]byte, 12)
+	req[1] = op
+	binary.BigEndian.PutUint16(req[4:], m.Internal.Port())
+	binary.BigEndian.PutUint16(req[6:], m.External.Port())
+	binary.BigEndian.PutUint32(req[8:], uint32(m.Lifetime/time.Second))
+	resp, err := exchange(ctx, local, gateway, req, func(b []byte) bool {
+		return len(b) >= 16 && b[0] == 0 && b[1] == 128+op && binary.BigEndian.Uint16(b[8:]) == m.Internal.Port()
+	})
+	if err != nil {
+		return Mapping{}, err
+	}
+	if err := natpmpError(resp); err != nil {
+		return Mapping{}, err
+	}
+	var external netip.Addr
+	if m.Lifetime > 0 { // the response lacks the address, unlike in PCP
+		if external, err = natpmpExternal(ctx, local, gateway); err != nil {
+			return Mapping{}, err
+		}
+	}
+	m.External = netip.AddrPortFrom(external, binary.BigEndian.Uint16(resp[10:]))
+	m.Lifetime = time.Duration(binary.BigEndian.Uint32(resp[12:])) * time.Second
+	return m, nil
+}
+
+// natpmpError returns the error of the result code of a NAT-PMP response, if any.
+func natpmpError(resp []byte) error {
+	code := int(binary.BigEndian.Uint16(resp[2:]))
+	if code == 0 {
+		return nil
+	}
+	msg := "unknown error"
+	if code < len(natpmpResults) {
+		msg = natpmpResults[code]
+	}
+	return &Error{Method: NATPMP, Code: code, Message: msg}
+}
+
+// exchange sends req to the NAT-PMP or PCP server on gateway and returns the first
+// answer that accept takes. Requests are retransmitted after 250 ms, doubling the wait
+// every time, as both protocols ask, until ctx is done.
+func exchange(ctx context.Context, local, gateway netip.Addr, req []byte, accept func([]byte) bool) ([]byte, error) {
+	conn, err := net.DialUDP("udp",
+		net.UDPAddrFromAddrPort(netip.AddrPortFrom(local, 0)),
+		net.UDPAddrFromAddrPort(netip.AddrPortFrom(gateway, serverPort)))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.SetReadDeadline(time.Now()) })
+	defer stop()
+
+	buf := make([]byte, 1100) // the maximum PCP message size
+	for wait := 250 * time.Millisecond; ; wait *= 2 {
+		if _, err := conn.Write(req); err != nil {
+			return nil, err
+		}
+		conn.SetReadDeadline(time.Now().Add(wait))
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("%w: %w", errNoAnswer, ctx.Err()) // before the deadline was set
+		}
+		for {
+			n, err := conn.Read(buf)
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("%w: %w", errNoAnswer, ctx.Err())
+			}
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				break // send again
+			}
+			if refused(err) {
+				return nil, errNoAnswer
+			}
+			if err != nil {
+				return nil, err
+			}
+			if accept(buf[:n]) {
+				return buf[:n], nil
+			}
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package portmap
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"net/netip"
+	"time"
+)
+
+// PCP opcodes and the version of RFC 6887.
+const (
+	pcpVersion = 2
+	opAnnounce = 0
+	opMap      = 1
+)
+
+// pcpResults are the result codes of RFC 6887 section 7.4.
+var pcpResults = []string{
+	1:  "unsupported version",
+	2:  "not authorized",
+	3:  "malformed request",
+	4:  "unsupported opcode",
+	5:  "unsupported option",
+	6:  "malformed option",
+	7:  "network failure",
+	8:  "out of resources",
+	9:  "unsupported protocol",
+	10: "user exceeded quota",
+	11: "cannot provide external address",
+	12: "address mismatch",
+	13: "excessive remote peers",
+}
+
+// newNonce returns a random mapping nonce.
+func newNonce() [12]byte {
+	var nonce [12]byte
+	rand.Read(nonce[:])
+	return nonce
+}
+
+// pcpHeader returns a request header for opcode.
+func pcpHeader(opcode byte, lifetime time.Duration, local netip.Addr) []byte {
+	b := make([]byte, 24)
+	b[0], b[1] = pcpVersion, opcode
+	binary.BigEndian.PutUint32(b[4:], uint32(lifetime/time.Second))
+	client := local.As16() // IPv4 addresses are sent mapped to IPv6
+	copy(b[8:], client[:])
+	return b
+}
+
+// announcePCP finds out whether the gateway runs a PCP or a NAT-PMP server, with a PCP
+// ANNOUNCE request. NAT-PMP servers reject it with their own version number (RFC 6887
+// section 9).
+func announcePCP(ctx context.Context, local, gateway netip.Addr) (Method, error) {
+	resp, err := exchange(ctx, local, gateway, pcpHeader(opAnnounce, 0, local), func(b []byte) bool {
+		return len(b) >= 24 && b[0] == pcpVersion && b[1] == 0x80|opAnnounce || len(b) >= 4 && b[0] == 0
+	})
+	if err != nil {
+		return "", err
+	}
+	if resp[0] == 0 {
+		return NATPMP, nil
+	}
+	if err := pcpError(resp); err != nil {
+		return "", err
+	}
+	return PCP, nil
+}
+
+// pcpMap creates, renews, or with a zero lifetime deletes m with a PCP MAP request.
+func pcpMap(ctx context.Context, local, gateway netip.Addr, m Mapping) (Mapping, error) {
+	req := pcpHeader(opMap, m.Lifetime, local)
+	req = append(req, m.nonce[:]...)
+	protocol := byte(17) // UDP
+	if m.Protocol == "tcp" {
+		protocol = 6
+	}
+	req = append(req, protocol, 0, 0, 0)
+	req = binary.BigEndian.AppendUint16(req, m.Internal.Port())
+	req = binary.BigEndian.AppendUint16(req, m.External.Port())
+	suggested := netip.IPv4Unspecified().As16() // no preference, but IPv4
+	if m.External.Addr().IsValid() {
+		suggested = m.External.Addr().As16()
+	}
+	req = append(req, suggested[:]...)
+	resp, err := exchange(ctx, local, gateway, req, func(b []byte) bool {
+		return len(b) >= 60 && b[0] == pcpVersion && b[1] == 0x80|opMap && [12]byte(b[24:36]) == m.nonce
+	})
+	if err != nil {
+		return Mapping{}, err
+	}
+	if err := pcpError(resp); err != nil {
+		return Mapping{}, err
+	}
+	external := netip.AddrFrom16([16]byte(resp[44:60])).Unmap()
+	m.External = netip.AddrPortFrom(external, binary.BigEndian.Uint16(resp[42:]))
+	m.Lifetime = time.Duration(binary.BigEndian.Uint32(resp[4:])) * time.Second
+	return m, nil
+}
+
+// pcpError returns the error of the result code of a PCP response, if any.
+func pcpError(resp []byte) error {
+	code := int(resp[3])
+	if code == 0 {
+		return nil
+	}
+	msg := "unknown error"
+	if code < len(pcpResults) {
+		msg = pcpResults[code]
+	}
+	return &Error{Method: PCP, Code: code, Message: msg}
+}
@@ -0,0 +1,217 @@
+// Package portmap asks the router to forward ports of its WAN address to the machine,
+// and to tell its WAN address, with whichever of the three common protocols it speaks:
+// PCP (RFC 6887), its predecessor NAT-PMP (RFC 6886), found on Apple and many newer
+// routers, or UPnP IGD.
+//
+// Most routers have all of them turned off by default; then Discover fails and the
+// ports have to be forwarded by hand in the router's settings.
+package portmap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/golovingreg/localaddr"
+	"github.com/golovingreg/localaddr/internal/igd"
+)
+
+// Method is a port mapping protocol.
+type Method string
+
+// Port mapping protocols, in the order Discover tries them.
+const (
+	PCP    Method = "pcp"
+	NATPMP Method = "nat-pmp"
+	UPnP   Method = "upnp"
+)
+
+// ErrNoGateway is returned by Discover when the router answers none of the protocols.
+var ErrNoGateway = errors.New("portmap: the router supports no port mapping protocol")
+
+// DefaultLifetime is the lifetime of a mapping if zero is given. RFC 6886 recommends two
+// hours; the mapping has to be renewed before it ends.
+const DefaultLifetime = 2 * time.Hour
+
+// Client maps ports on the router with the protocol it answered when it was discovered.
+type Client struct {
+	Gateway netip.Addr // address of the router
+	Local   netip.Addr // local address the mappings point to
+	method  Method
+	upnp    *igd.Client // for UPnP
+}
+
+// Mapping is a port forwarded by the router.
+type Mapping struct {
+	Protocol string         // "tcp" or "udp"
+	Internal netip.AddrPort // where the router forwards to
+	External netip.AddrPort // address and port on the WAN side of the router
+	Lifetime time.Duration  // time until the router drops it; zero if it never does (UPnP)
+	Method   Method
+	nonce    [12]byte // PCP mapping nonce, identifies the mapping in renewals
+}
+
+// Error is a failure the router reports.
+type Error struct {
+	Method  Method
+	Code    int
+	Message string // e.g. "not authorized"
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("portmap: %s: %s (result %d)", e.Method, e.Message, e.Code)
+}
+
+// Discover finds out which protocol the router of the address that localaddr.Get(opts...)
+// selects speaks. PCP and NAT-PMP are asked first, since they are faster and answered
+// by the gateway itself; UPnP is searched for on the LAN after that.
+//
+// Returns:
+//   - *Client: A client for the protocol the router answered
+//   - error: An error wrapping ErrNoGateway if the router supports none of them, or an
+//     error if there is no local address or default gateway
+func Discover(ctx context.Context, opts ...localaddr.Option) (*Client, error) {
+	local, err := localaddr.GetAddr(opts...)
+	if err != nil {
+		return nil, err
+	}
+	gateway, _, err := localaddr.Gateway(opts...)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{Gateway: gateway, Local: local.WithZone("")}
+	announce, cancel := context.WithTimeout(ctx, time.Second) // the router answers right away if at all
+	method, perr := announcePCP(announce, c.Local, c.Gateway)
+	cancel()
+	if perr == nil {
+		c.method = method
+		return c, nil
+	}
+	u, uerr := igd.Discover(ctx, opts...)
+	if uerr == nil {
+		c.method, c.upnp = UPnP, u
+		return c, nil
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return nil, fmt.Errorf("%w: pcp, nat-pmp: %w; upnp: %w", ErrNoGateway, perr, uerr)
+}
+
+// Method returns the protocol c speaks.
+func (c *Client) Method() Method {
+	return c.method
+}
+
+// ExternalIP returns the WAN address of the router. Behind a second NAT, e.g. the
+// carrier-grade NAT of a provider, it is not the public address.
+func (c *Client) ExternalIP(ctx context.Context) (netip.Addr, error) {
+	switch c.method {
+	case NATPMP:
+		return natpmpExternal(ctx, c.Local, c.Gateway)
+	case PCP:
+		// PCP has no request for the address alone. Servers often speak NAT-PMP as well;
+		// otherwise a mapping of the discard port reveals it and is deleted right away.
+		quick, cancel := context.WithTimeout(ctx, time.Second)
+		addr, err := natpmpExternal(quick, c.Local, c.Gateway)
+		cancel()
+		if err == nil {
+			return addr, nil
+		}
+		m, err := c.Map(ctx, "udp", 9, 0, time.Minute)
+		if err != nil {
+			return netip.Addr{}, err
+		}
+		c.Unmap(ctx, m)
+		return m.External.Addr(), nil
+	default:
+		return c.upnp.ExternalIP(ctx)
+	}
+}
+
+// Map asks the router to forward externalPort of its WAN address to internalPort on
+// the local address, for lifetime, or DefaultLifetime if it is zero. protocol is "tcp"
+// or "udp". externalPort is a suggestion: if it is zero or taken, the router may pick
+// another port, so use the one in the returned Mapping. The mapping ends after its
+// Lifetime unless it is renewed with Renew.
+func (c *Client) Map(ctx context.Context, protocol string, internalPort, externalPort int, lifetime time.Duration) (Mapping, error) {
+	protocol = strings.ToLower(protocol)
+	if protocol != "tcp" && protocol != "udp" {
+		return Mapping{}, fmt.Errorf("portmap: unknown protocol %q, want \"tcp\" or \"udp\"", protocol)
+	}
+	if internalPort < 1 || internalPort > 65535 || externalPort < 0 || externalPort > 65535 {
+		return Mapping{}, fmt.Errorf("portmap: invalid ports %d and %d", internalPort, externalPort)
+	}
+	if lifetime <= 0 {
+		lifetime = DefaultLifetime
+	}
+	m := Mapping{
+		Protocol: protocol,
+		Internal: netip.AddrPortFrom(c.Local, uint16(internalPort)),
+		External: netip.AddrPortFrom(netip.Addr{}, uint16(externalPort)),
+		Lifetime: lifetime,
+		Method:   c.method,
+	}
+	if c.method == PCP {
+		m.nonce = newNonce()
+	}
+	return c.request(ctx, m)
+}
+
+// Renew extends m by its original lifetime, before it ends. The router may move it to
+// another external port, so use the returned Mapping from then on.
+func (c *Client) Renew(ctx context.Context, m Mapping) (Mapping, error) {
+	return c.request(ctx, m)
+}
+
+// Unmap deletes m on the router.
+func (c *Client) Unmap(ctx context.Context, m Mapping) error {
+	switch c.method {
+	case NATPMP:
+		m.External, m.Lifetime = netip.AddrPortFrom(netip.Addr{}, 0), 0
+		_, err := natpmpMap(ctx, c.Local, c.Gateway, m)
+		return err
+	case PCP:
+		m.Lifetime = 0
+		_, err := pcpMap(ctx, c.Local, c.Gateway, m)
+		return err
+	default:
+		return wrapUPnP(c.upnp.DeletePortMapping(ctx, strings.ToUpper(m.Protocol), int(m.External.Port())))
+	}
+}
+
+// request creates or renews m.
+func (c *Client) request(ctx context.Context, m Mapping) (Mapping, error) {
+	switch c.method {
+	case NATPMP:
+		return natpmpMap(ctx, c.Local, c.Gateway, m)
+	case PCP:
+		return pcpMap(ctx, c.Local, c.Gateway, m)
+	}
+	external := int(m.External.Port())
+	if external == 0 && !strings.HasSuffix(c.upnp.Service, ":2") {
+		external = int(m.Internal.Port()) // IGD version 1 cannot pick a port
+	}
+	port, lease, err := c.upnp.AddPortMapping(ctx, strings.ToUpper(m.Protocol), external, int(m.Internal.Port()), c.Local, m.Lifetime, "localaddr")
+	if err != nil {
+		return Mapping{}, wrapUPnP(err)
+	}
+	wan, err := c.upnp.ExternalIP(ctx)
+	if err != nil {
+		return Mapping{}, wrapUPnP(err)
+	}
+	m.External, m.Lifetime = netip.AddrPortFrom(wan, uint16(port)), lease
+	return m, nil
+}
+
+// wrapUPnP turns an error of the router into an *Error.
+func wrapUPnP(err error) error {
+	var e *igd.Error
+	if errors.As(err, &e) {
+		return &Error{Method: UPnP, Code: e.Code, Message: e.Description}
+	}
+	return err
+}
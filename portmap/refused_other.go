@@ -0,0 +1,14 @@
+//go:build !windows && !plan9
+
+package portmap
+
+import (
+	"errors"
+	"syscall"
+)
+
+// refused reports whether err means that the peer answered with a reset or, for UDP,
+// a port unreachable message.
+func refused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
@@ -0,0 +1,13 @@
+package portmap
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// refused reports whether err means that the peer answered with a reset or, for UDP,
+// a port unreachable message, which Windows reports as a reset connection.
+func refused(err error) bool {
+	return errors.Is(err, windows.WSAECONNREFUSED) || errors.Is(err, windows.WSAECONNRESET)
+}
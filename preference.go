@@ -0,0 +1,171 @@
+package localaddr
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// Preference tells how the ranking treats a group of addresses, such as carrier-grade
+// NAT addresses with WithCGNAT.
+type Preference int
+
+const (
+	Allow   Preference = iota + 1 // rank like any other address
+	Prefer                        // rank above the addresses outside the group
+	Avoid                         // rank below the addresses outside the group
+	Exclude                       // never return
+)
+
+var preferenceNames = [...]string{
+	Allow:   "allow",
+	Prefer:  "prefer",
+	Avoid:   "avoid",
+	Exclude: "exclude",
+}
+
+// String returns the lower case name of p, e.g. "prefer".
+func (p Preference) String() string {
+	if p < Allow || p > Exclude {
+		return fmt.Sprintf("Preference(%d)", int(p))
+	}
+	return preferenceNames[p]
+}
+
+// WithCGNAT sets how addresses in the carrier-grade NAT space 100.64.0.0/10 (ClassShared)
+// are treated. Behind carrier-grade NAT such an address is the machine's only address, and
+// code that assumes RFC 1918 space may want to know; Exclude then makes the getters fail
+// instead of returning it. The default is Allow.
+func WithCGNAT(p Preference) Option {
+	return func(c *config) {
+		if p < Allow || p > Exclude {
+			c.setErr(fmt.Errorf("invalid CGNAT preference %d", int(p)))
+			return
+		}
+		c.cgnat = p
+	}
+}
+
+// WithTailscale sets how the addresses of a Tailscale interface are treated, e.g. Prefer
+// to reach the machine over the tailnet, or Exclude to never hand out a tailnet address.
+// It takes precedence over WithVPN and WithCGNAT. Without it, a Tailscale interface is
+// treated as the tunnel it is, which WithVPN avoids by default; where its kind is not
+// known, e.g. with a Provider, its IPv4 addresses count as CGNAT addresses.
+//
+// The interface is recognized by its name, tailscale0 on Linux and "Tailscale" on Windows,
+// or, for the utun interfaces of macOS, by Tailscale's address ranges.
+func WithTailscale(p Preference) Option {
+	return func(c *config) {
+		if p < Allow || p > Exclude {
+			c.setErr(fmt.Errorf("invalid Tailscale preference %d", int(p)))
+			return
+		}
+		c.tailscale = p
+	}
+}
+
+// tailscaleIPv6 is the unique local prefix that Tailscale assigns IPv6 addresses from.
+var tailscaleIPv6 = netip.MustParsePrefix("fd7a:115c:a1e0::/48")
+
+// isTailscale reports whether ip of iface is a Tailscale address.
+func isTailscale(iface *net.Interface, ip netip.Addr) bool {
+	if strings.HasPrefix(strings.ToLower(iface.Name), "tailscale") {
+		return true
+	}
+	return strings.HasPrefix(iface.Name, "utun") && (sharedSpace.Contains(ip) || tailscaleIPv6.Contains(ip))
+}
+
+// WithVPN sets how the addresses of VPN and other tunnel interfaces (tun, tap, WireGuard,
+// PPP, ...; see KindTunnel) are treated. The default is Avoid: connecting to a VPN does not
+// change what Get returns, as long as there is any other address. Prefer makes the tunnel
+// address win instead, e.g. for services that must only be reachable over the VPN.
+func WithVPN(p Preference) Option {
+	return func(c *config) {
+		if p < Allow || p > Exclude {
+			c.setErr(fmt.Errorf("invalid VPN preference %d", int(p)))
+			return
+		}
+		c.vpn = p
+	}
+}
+
+// WithTemporary sets how temporary IPv6 addresses are treated. With SLAAC privacy
+// extensions (RFC 8981) an interface has a stable address and, for outgoing connections,
+// one that changes every day or so. Servers should Avoid or Exclude the temporary one so
+// clients can keep reaching them; clients may Prefer it. The default is Allow.
+//
+// Temporary addresses are recognized on Linux and Windows; elsewhere all addresses count
+// as stable.
+func WithTemporary(p Preference) Option {
+	return func(c *config) {
+		if p < Allow || p > Exclude {
+			c.setErr(fmt.Errorf("invalid temporary address preference %d", int(p)))
+			return
+		}
+		c.temporary = p
+	}
+}
+
+// WithTransition sets how the IPv6 addresses of the transition mechanisms Teredo
+// (2001::/32) and 6to4 (2002::/16) are treated. They reach the IPv6 internet through a
+// relay where the network has no IPv6 of its own, are slow and unreliable, and are rarely
+// what a service should advertise. The default is Exclude; Avoid returns them when there
+// is no other IPv6 address.
+func WithTransition(p Preference) Option {
+	return func(c *config) {
+		if p < Allow || p > Exclude {
+			c.setErr(fmt.Errorf("invalid transition address preference %d", int(p)))
+			return
+		}
+		c.transition = p
+	}
+}
+
+// transitionSpace holds the prefixes of Teredo and 6to4 addresses.
+var transitionSpace = []netip.Prefix{
+	netip.MustParsePrefix("2001::/32"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+// isTransition reports whether ip is a Teredo or 6to4 address.
+func isTransition(ip netip.Addr) bool {
+	for _, p := range transitionSpace {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// preference returns how ip of iface is treated, and the option that decided it. tunnel
+// tells whether iface is a tunnel. The most specific group of addresses decides:
+// Tailscale before transition addresses before VPNs before hypervisor adapters before
+// carrier-grade NAT.
+func (c *config) preference(iface *net.Interface, ip netip.Addr, tunnel bool) (Preference, string) {
+	if c.tailscale != 0 && isTailscale(iface, ip) {
+		return c.tailscale, "Tailscale"
+	}
+	if c.transition != Allow && isTransition(ip) {
+		if c.transition == 0 {
+			return Exclude, "Teredo/6to4"
+		}
+		return c.transition, "Teredo/6to4"
+	}
+	if tunnel {
+		if c.vpn == 0 {
+			return Avoid, "VPN"
+		}
+		return c.vpn, "VPN"
+	}
+	if c.vmAdapters != Allow && isVMAdapter(iface, ip) {
+		if c.vmAdapters == 0 {
+			return Avoid, "VM adapter"
+		}
+		return c.vmAdapters, "VM adapter"
+	}
+	if c.cgnat != 0 && sharedSpace.Contains(ip) {
+		return c.cgnat, "CGNAT"
+	}
+	return Allow, ""
+}
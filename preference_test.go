@@ -0,0 +1,44 @@
+package localaddr_test
+
+import (
+	"testing"
+
+	"github.com/golovingreg/localaddr"
+	"github.com/golovingreg/localaddr/localaddrtest"
+)
+
+func TestPreferences(t *testing.T) {
+	macOS := localaddrtest.New().Loopback().
+		Interface("en0", "192.168.1.10/24").
+		Tunnel("utun3", "100.101.102.103/32").
+		Gateway("192.168.1.1", "en0")
+	cgnatVPN := localaddrtest.New().Loopback().
+		Interface("eth0", "192.168.1.10/24").
+		Tunnel("wg0", "100.64.1.2/32").
+		Gateway("192.168.1.1", "eth0")
+	preferred := localaddrtest.New().Loopback().
+		Interface("eth1", "100.64.1.2/10").
+		Tunnel("wg0", "10.8.0.2/32").
+		Gateway("100.64.0.1", "eth1")
+
+	tests := []struct {
+		name    string
+		network *localaddrtest.Network
+		opts    []localaddr.Option
+		want    string
+	}{
+		{"utun avoided by default", macOS, nil, "192.168.1.10"},
+		{"Tailscale on utun preferred", macOS, []localaddr.Option{localaddr.WithTailscale(localaddr.Prefer)}, "100.101.102.103"},
+		{"VPN on utun preferred", macOS, []localaddr.Option{localaddr.WithVPN(localaddr.Prefer)}, "100.101.102.103"},
+		{"preferred VPN decides over excluded CGNAT", cgnatVPN, []localaddr.Option{localaddr.WithCGNAT(localaddr.Exclude), localaddr.WithVPN(localaddr.Prefer)}, "100.64.1.2"},
+		{"preferred subnet on an avoided VPN beats preferred CGNAT", preferred, []localaddr.Option{localaddr.WithPreferredSubnet("10.8.0.0/16"), localaddr.WithCGNAT(localaddr.Prefer)}, "10.8.0.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := localaddr.Get(append(tt.opts, tt.network.Option())...)
+			if err != nil || got != tt.want {
+				t.Errorf("Get() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
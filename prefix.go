@@ -0,0 +1,165 @@
+package localaddr
+
+import (
+	"cmp"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+)
+
+// GetPrefix is like GetAddr, but also returns the length of the network the address
+// is on, e.g. 192.168.1.42/24. The same options as for Get apply.
+//
+// The returned prefix keeps the host bits; call Masked on it to get the network
+// itself (192.168.1.0/24), or Contains to check whether a peer is on the same network.
+func GetPrefix(opts ...Option) (netip.Prefix, error) {
+	c, err := first(newConfig(ipv4, opts))
+	return c.prefix, err
+}
+
+// GetCIDR is like GetPrefix, but returns the address with the length of its network in
+// CIDR notation, the form that configuration files for Consul, keepalived, or firewall
+// rules expect. The same options as for Get apply.
+//
+// Returns:
+//   - string: The address and prefix length (e.g., "192.168.1.42/24")
+//   - error: An error if no suitable address is found or if there's an issue accessing network interfaces
+func GetCIDR(opts ...Option) (string, error) {
+	prefix, err := GetPrefix(opts...)
+	if err != nil {
+		return "", err
+	}
+	return prefix.String(), nil
+}
+
+// Broadcast returns the directed broadcast address of the IPv4 network that Get selects,
+// e.g. 192.168.1.255 for 192.168.1.42/24. The same options as for Get apply, except
+// WithIPv6: IPv6 has no broadcast addresses.
+//
+// Returns:
+//   - netip.Addr: The broadcast address (e.g., "192.168.1.255")
+//   - error: An error if no address is selected or its network is too small to have a broadcast address (/31, /32)
+func Broadcast(opts ...Option) (netip.Addr, error) {
+	cfg := newConfig(ipv4, opts)
+	if cfg.family == ipv6 {
+		return netip.Addr{}, fmt.Errorf("IPv6 has no broadcast addresses")
+	}
+	c, err := first(cfg)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	bcast, ok := broadcastOf(c.prefix)
+	if !ok {
+		return netip.Addr{}, fmt.Errorf("network %s has no broadcast address", c.prefix)
+	}
+	return bcast, nil
+}
+
+// broadcastOf returns the broadcast address of an IPv4 prefix by setting all host bits.
+// Point-to-point (/31) and host (/32) prefixes have none.
+func broadcastOf(prefix netip.Prefix) (netip.Addr, bool) {
+	if !prefix.Addr().Is4() || prefix.Bits() > 30 {
+		return netip.Addr{}, false
+	}
+	ip := binary.BigEndian.Uint32(prefix.Addr().AsSlice())
+	ip |= ^uint32(0) >> prefix.Bits()
+	return netip.AddrFrom4([4]byte(binary.BigEndian.AppendUint32(nil, ip))), true
+}
+
+// SameSubnet reports whether addr is on one of the networks the machine is directly
+// connected to, i.e. inside the on-link prefix of an address of an up interface, so that
+// it can be reached without a router, e.g. to connect to a peer directly rather than
+// through a relay. Loopback interfaces do not count. A link-local address is on every
+// link unless its zone names an interface; IPv4-mapped IPv6 addresses are compared as
+// IPv4 addresses.
+//
+// Only the options that choose interfaces apply, as for Report: WithProvider,
+// WithInterface, WithDefaultRoute, WithIncludeInterfaces, and WithExcludeInterfaces.
+// Virtual interfaces such as container bridges count, since their networks are directly
+// connected too.
+//
+// Returns:
+//   - bool: Whether addr is on a connected network (e.g., true for 192.168.1.7 on
+//     192.168.1.0/24)
+//   - error: An error if addr is invalid, the interfaces cannot be read, or an option
+//     is invalid
+func SameSubnet(addr netip.Addr, opts ...Option) (bool, error) {
+	if !addr.IsValid() {
+		return false, fmt.Errorf("invalid address")
+	}
+	cfg := newConfig(anyFamily, opts)
+	links, err := cfg.walk()
+	if err != nil {
+		return false, err
+	}
+	zone := addr.Zone()
+	addr = addr.Unmap().WithZone("")
+	for i := range links {
+		v := &links[i].iface
+		if !cfg.connected(v) {
+			continue
+		}
+		if zone != "" && v.Name != zone && strconv.Itoa(v.Index) != zone {
+			continue
+		}
+		for _, prefix := range links[i].addrs {
+			if prefix.Contains(addr) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// LocalNetworks returns the networks the machine is directly connected to, the on-link
+// prefixes of the addresses of up interfaces with their host bits cleared, e.g. for a
+// firewall allow-list or the trusted proxies of a reverse proxy. To check a single
+// address, such as the peer of a request, use SameSubnet.
+//
+// The interfaces are those of SameSubnet, with the same options. Link-local networks
+// (169.254.0.0/16 and fe80::/64), which every link has, are left out unless
+// WithAllowLinkLocal is given. The IPv4 networks come first, each listed once.
+//
+// Returns:
+//   - []netip.Prefix: The networks (e.g., [192.168.1.0/24 10.8.0.0/24 fd12::/64])
+//   - error: An error if the interfaces cannot be read or an option is invalid
+func LocalNetworks(opts ...Option) ([]netip.Prefix, error) {
+	cfg := newConfig(anyFamily, opts)
+	links, err := cfg.walk()
+	if err != nil {
+		return nil, err
+	}
+	var nets []netip.Prefix
+	for i := range links {
+		if !cfg.connected(&links[i].iface) {
+			continue
+		}
+		for _, prefix := range links[i].addrs {
+			if prefix.Addr().IsLinkLocalUnicast() && !cfg.allowLinkLocal {
+				continue
+			}
+			if n := prefix.Masked(); !slices.Contains(nets, n) {
+				nets = append(nets, n)
+			}
+		}
+	}
+	slices.SortStableFunc(nets, func(a, b netip.Prefix) int {
+		return cmp.Compare(a.Addr().BitLen(), b.Addr().BitLen())
+	})
+	return nets, nil
+}
+
+// connected reports whether the networks of v count as directly connected for
+// SameSubnet and LocalNetworks: v is up, not loopback, and chosen by the options.
+func (cfg *config) connected(v *net.Interface) bool {
+	if v.Flags&net.FlagUp == 0 || v.Flags&net.FlagLoopback != 0 {
+		return false
+	}
+	if cfg.iface != "" {
+		return v.Name == cfg.iface
+	}
+	return cfg.allowed(v.Name)
+}
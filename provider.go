@@ -0,0 +1,84 @@
+package localaddr
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+// Provider supplies the network interfaces and addresses that the package selects from.
+// By default they come from the operating system; WithProvider substitutes another
+// source, so that code using this package can be tested against any topology, e.g. no
+// network at all, several uplinks, or only a VPN. Package localaddrtest has ready-made
+// ones.
+//
+// The methods mirror net.Interfaces and net.Interface.Addrs, and their results are
+// interpreted the same way.
+type Provider interface {
+	Interfaces() ([]net.Interface, error)
+	Addrs(iface net.Interface) ([]net.Addr, error)
+}
+
+// GatewayProvider is a Provider that also supplies the default routes, which Gateway,
+// WithDefaultRoute, and the ranking use. The topology of a Provider without this method
+// has no default route.
+type GatewayProvider interface {
+	Provider
+	// DefaultGateway returns the gateway of the IPv4 or IPv6 default route and the
+	// name of its interface, or an error if there is none.
+	DefaultGateway(ipv6 bool) (netip.Addr, string, error)
+}
+
+// WithProvider makes the getters select from the interfaces and addresses of p instead
+// of those of the operating system. Nil means the operating system.
+//
+// Only the selection is simulated: GetOutbound still asks the operating system, and
+// watchers poll p for changes, see WithPollInterval. Interface kinds, see Kind, are guessed
+// from the names and flags of p's interfaces. A p whose Interfaces fails with
+// ErrUnsupportedPlatform, or with the refusal of Android, is treated as such a platform:
+// the getters fall back to the outbound address, see WithDialer.
+func WithProvider(p Provider) Option {
+	return func(c *config) {
+		c.provider = p
+	}
+}
+
+// system is the Provider of the operating system's interfaces, through package net.
+type system struct{}
+
+func (system) Interfaces() ([]net.Interface, error)          { return net.Interfaces() }
+func (system) Addrs(iface net.Interface) ([]net.Addr, error) { return iface.Addrs() }
+
+// walkProvider is walk for the interfaces of p.
+func walkProvider(p Provider) ([]link, error) {
+	interfaces, err := p.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	links := make([]link, len(interfaces))
+	for i, v := range interfaces {
+		links[i].iface = v
+	}
+	readAddrs(links, p.Addrs)
+	return links, nil
+}
+
+// providedRoute is defaultRoute for the topology of p.
+func providedRoute(p Provider, cfg *config) (route, error) {
+	gp, ok := p.(GatewayProvider)
+	if !ok {
+		return route{}, fmt.Errorf("no default gateway")
+	}
+	gw, iface, err := gp.DefaultGateway(cfg.family == ipv6)
+	if err != nil {
+		return route{}, err
+	}
+	if cfg.iface != "" && iface != cfg.iface {
+		return route{}, fmt.Errorf("no default gateway")
+	}
+	dst := netip.PrefixFrom(netip.IPv4Unspecified(), 0)
+	if cfg.family == ipv6 {
+		dst = netip.PrefixFrom(netip.IPv6Unspecified(), 0)
+	}
+	return route{dst: dst, gw: gw, iface: iface}, nil
+}
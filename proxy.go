@@ -0,0 +1,153 @@
+package localaddr
+
+import (
+	"cmp"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ProxySettings are the proxies that HTTP clients of the system are configured to use.
+// A nil URL means that requests of the kind go directly.
+type ProxySettings struct {
+	HTTP       *url.URL // for http:// requests
+	HTTPS      *url.URL // for https:// requests
+	SOCKS      *url.URL // socks5:// proxy for everything without one of the above
+	NoProxy    []string // hosts, domains (".example.com"), and prefixes that are reached directly
+	AutoConfig *url.URL // proxy auto-config (PAC) script, which Proxy does not run
+	Source     string   // where the settings come from, e.g. "environment", "" if none are set
+}
+
+// ProxyConfig returns the proxy settings of the system, to show them, or to make a
+// client that honors them, see SystemProxy.
+//
+// The environment variables HTTP_PROXY, HTTPS_PROXY, ALL_PROXY, and NO_PROXY, or their
+// lower case forms, win if any is set, as they do for the standard library and curl.
+// Otherwise the settings are read on Windows from the Internet Options of the user, and
+// then the WinHTTP settings that services use ("netsh winhttp"), and on macOS from
+// "scutil --proxy". Elsewhere only the environment is known.
+//
+// Returns:
+//   - ProxySettings: The settings (e.g., HTTPS through "http://proxy:3128"), empty if
+//     there is no proxy
+//   - error: An error if the system settings cannot be read
+func ProxyConfig() (ProxySettings, error) {
+	if p := envProxy(); p.Source != "" {
+		return p, nil
+	}
+	return readSystemProxy()
+}
+
+// SystemProxy returns the proxy for req by the proxy settings of the system, read once by
+// ProxyConfig; if they cannot be read, requests go directly. It can be used as the Proxy
+// of an http.Transport, in the place of http.ProxyFromEnvironment, which only knows the
+// environment variables.
+func SystemProxy(req *http.Request) (*url.URL, error) {
+	p, _ := systemProxy()
+	return p.Proxy(req)
+}
+
+var systemProxy = sync.OnceValues(ProxyConfig)
+
+// Proxy returns the proxy for req, or nil if it goes directly: the proxy of its scheme,
+// or else the SOCKS proxy. Requests to loopback hosts and to those of NoProxy are never
+// proxied. It has the signature of the Proxy of an http.Transport.
+func (p ProxySettings) Proxy(req *http.Request) (*url.URL, error) {
+	host := strings.ToLower(req.URL.Hostname())
+	if host == "localhost" || p.bypass(host) {
+		return nil, nil
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && ip.IsLoopback() {
+		return nil, nil
+	}
+	switch {
+	case req.URL.Scheme == "https" && p.HTTPS != nil:
+		return p.HTTPS, nil
+	case req.URL.Scheme == "http" && p.HTTP != nil:
+		return p.HTTP, nil
+	}
+	return p.SOCKS, nil
+}
+
+// bypass reports whether host matches an entry of NoProxy: "*" for every host, "<local>"
+// for the host names without a dot, a prefix such as 10.0.0.0/8, an address, ".example.com"
+// or "*.example.com" for the hosts of a domain, or a host name, which also matches the
+// host names under it. Ports of entries are ignored.
+func (p ProxySettings) bypass(host string) bool {
+	ip, ipErr := netip.ParseAddr(host)
+	for _, entry := range p.NoProxy {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if h, _, err := net.SplitHostPort(entry); err == nil {
+			entry = h
+		}
+		switch {
+		case entry == "":
+		case entry == "*":
+			return true
+		case entry == "<local>":
+			if ipErr != nil && !strings.Contains(host, ".") {
+				return true
+			}
+		case strings.Contains(entry, "/"):
+			if prefix, err := netip.ParsePrefix(entry); err == nil && ipErr == nil && prefix.Contains(ip.Unmap()) {
+				return true
+			}
+		case strings.HasPrefix(entry, "*.") || strings.HasPrefix(entry, "."):
+			if strings.HasSuffix(host, strings.TrimPrefix(entry, "*")) {
+				return true
+			}
+		case host == strings.Trim(entry, "[]") || strings.HasSuffix(host, "."+entry):
+			return true
+		}
+	}
+	return false
+}
+
+// envProxy reads the proxy environment variables, with Source "environment" if any is
+// set.
+func envProxy() ProxySettings {
+	var p ProxySettings
+	get := func(name string) string {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+		return os.Getenv(strings.ToLower(name))
+	}
+	p.HTTP = proxyURL(get("HTTP_PROXY"), "http")
+	p.HTTPS = proxyURL(get("HTTPS_PROXY"), "http")
+	if all := proxyURL(get("ALL_PROXY"), "http"); all != nil {
+		if strings.HasPrefix(all.Scheme, "socks") {
+			p.SOCKS = all
+		} else {
+			p.HTTP, p.HTTPS = cmp.Or(p.HTTP, all), cmp.Or(p.HTTPS, all)
+		}
+	}
+	if no := get("NO_PROXY"); no != "" {
+		p.NoProxy = strings.Split(no, ",")
+	}
+	if p.HTTP != nil || p.HTTPS != nil || p.SOCKS != nil || p.NoProxy != nil {
+		p.Source = "environment"
+	}
+	return p
+}
+
+// proxyURL parses a proxy setting, which may lack the scheme, as "proxy:3128" does; it
+// is scheme then. It returns nil for an empty or invalid setting.
+func proxyURL(s, scheme string) *url.URL {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	if !strings.Contains(s, "://") {
+		s = scheme + "://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+	return u
+}
@@ -0,0 +1,75 @@
+package localaddr
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"net/url"
+	"os/exec"
+	"strings"
+)
+
+// readSystemProxy parses the output of "scutil --proxy", the proxies of the network
+// service in use as System Settings configures them:
+//
+//	<dictionary> {
+//	  ExceptionsList : <array> {
+//	    0 : *.local
+//	  }
+//	  HTTPEnable : 1
+//	  HTTPPort : 3128
+//	  HTTPProxy : proxy.example.com
+//	}
+func readSystemProxy() (ProxySettings, error) {
+	out, err := exec.Command("/usr/sbin/scutil", "--proxy").Output()
+	if err != nil {
+		return ProxySettings{}, fmt.Errorf("reading the proxy settings: %w", err)
+	}
+	return parseScutilProxy(out), nil
+}
+
+// parseScutilProxy parses the dictionary that "scutil --proxy" prints.
+func parseScutilProxy(out []byte) ProxySettings {
+	values := make(map[string]string)
+	var p ProxySettings
+	inExceptions := false
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "}" {
+			inExceptions = false
+			continue
+		}
+		key, value, ok := strings.Cut(line, " : ")
+		if !ok {
+			continue
+		}
+		if inExceptions {
+			p.NoProxy = append(p.NoProxy, value)
+			continue
+		}
+		if key == "ExceptionsList" {
+			inExceptions = true
+			continue
+		}
+		values[key] = value
+	}
+	proxy := func(kind, scheme string) *url.URL {
+		if values[kind+"Enable"] != "1" || values[kind+"Proxy"] == "" {
+			return nil
+		}
+		return proxyURL(net.JoinHostPort(values[kind+"Proxy"], values[kind+"Port"]), scheme)
+	}
+	p.HTTP, p.HTTPS, p.SOCKS = proxy("HTTP", "http"), proxy("HTTPS", "http"), proxy("SOCKS", "socks5")
+	if values["ProxyAutoConfigEnable"] == "1" {
+		p.AutoConfig, _ = url.Parse(values["ProxyAutoConfigURLString"])
+	}
+	if values["ExcludeSimpleHostnames"] == "1" {
+		p.NoProxy = append(p.NoProxy, "<local>")
+	}
+	if p.HTTP != nil || p.HTTPS != nil || p.SOCKS != nil || p.AutoConfig != nil {
+		p.Source = "scutil"
+	}
+	return p
+}
@@ -0,0 +1,9 @@
+//go:build !windows && !darwin
+
+package localaddr
+
+// readSystemProxy returns no settings: without a system wide store, the environment is
+// the configuration.
+func readSystemProxy() (ProxySettings, error) {
+	return ProxySettings{}, nil
+}
@@ -0,0 +1,109 @@
+package localaddr
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// Registry keys of the proxy settings: the Internet Options of the user, and the
+// WinHTTP settings of the machine, a binary value that "netsh winhttp set proxy" writes.
+const (
+	internetSettingsKey = `Software\Microsoft\Windows\CurrentVersion\Internet Settings`
+	winHTTPKey          = `SOFTWARE\Microsoft\Windows\CurrentVersion\Internet Settings\Connections`
+)
+
+// readSystemProxy reads the proxy of the Internet Options, and where they have none, the
+// WinHTTP one.
+func readSystemProxy() (ProxySettings, error) {
+	k, err := registry.OpenKey(registry.CURRENT_USER, internetSettingsKey, registry.QUERY_VALUE)
+	if err != nil && !errors.Is(err, registry.ErrNotExist) {
+		return ProxySettings{}, fmt.Errorf("reading the proxy settings: %w", err)
+	}
+	if err == nil {
+		defer k.Close()
+		var p ProxySettings
+		if enabled, _, _ := k.GetIntegerValue("ProxyEnable"); enabled != 0 {
+			server, _, _ := k.GetStringValue("ProxyServer")
+			override, _, _ := k.GetStringValue("ProxyOverride")
+			p = parseProxyServer(server, override)
+		}
+		if pac, _, _ := k.GetStringValue("AutoConfigURL"); pac != "" {
+			p.AutoConfig = proxyURL(pac, "http")
+		}
+		if p.HTTP != nil || p.HTTPS != nil || p.SOCKS != nil || p.AutoConfig != nil {
+			p.Source = "internet options"
+			return p, nil
+		}
+	}
+	k, err = registry.OpenKey(registry.LOCAL_MACHINE, winHTTPKey, registry.QUERY_VALUE)
+	if err != nil {
+		return ProxySettings{}, nil // never configured
+	}
+	defer k.Close()
+	data, _, err := k.GetBinaryValue("WinHttpSettings")
+	if err != nil {
+		return ProxySettings{}, nil
+	}
+	return parseWinHTTPSettings(data), nil
+}
+
+// parseWinHTTPSettings parses the WinHttpSettings value: two words of version and
+// counter, the flags, of which 2 means a proxy is set, and the proxy and the bypass
+// list, each a length followed by as many bytes.
+func parseWinHTTPSettings(data []byte) ProxySettings {
+	if len(data) < 16 || binary.LittleEndian.Uint32(data[8:])&2 == 0 {
+		return ProxySettings{}
+	}
+	field := func(b []byte) (string, []byte) {
+		if len(b) < 4 {
+			return "", nil
+		}
+		n := binary.LittleEndian.Uint32(b)
+		if uint64(n) > uint64(len(b)-4) {
+			return "", nil
+		}
+		return string(b[4 : 4+n]), b[4+n:]
+	}
+	server, rest := field(data[12:])
+	bypass, _ := field(rest)
+	p := parseProxyServer(server, bypass)
+	if p.HTTP != nil || p.HTTPS != nil || p.SOCKS != nil {
+		p.Source = "winhttp"
+	}
+	return p
+}
+
+// parseProxyServer parses a proxy setting of Windows, either "proxy:3128" for all
+// schemes or "http=proxy:3128;https=proxy:3129;socks=proxy:1080", and its bypass list,
+// entries separated by semicolons.
+func parseProxyServer(server, bypass string) ProxySettings {
+	var p ProxySettings
+	if !strings.Contains(server, "=") {
+		p.HTTP = proxyURL(server, "http")
+		p.HTTPS = p.HTTP
+	}
+	for _, part := range strings.Split(server, ";") {
+		scheme, addr, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(scheme) {
+		case "http":
+			p.HTTP = proxyURL(addr, "http")
+		case "https":
+			p.HTTPS = proxyURL(addr, "http")
+		case "socks":
+			p.SOCKS = proxyURL(addr, "socks5")
+		}
+	}
+	for _, entry := range strings.Split(bypass, ";") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			p.NoProxy = append(p.NoProxy, entry)
+		}
+	}
+	return p
+}
@@ -0,0 +1,175 @@
+package publicaddr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/golovingreg/localaddr"
+)
+
+// DefaultHTTPServices are the plain-text "what is my IP" services queried when none
+// are given. Each of them answers a GET request with the caller's address.
+var DefaultHTTPServices = []string{
+	"https://api.ipify.org",
+	"https://ifconfig.me/ip",
+	"https://icanhazip.com",
+	"https://checkip.amazonaws.com",
+}
+
+// HTTPResolver looks up the public address by asking HTTP(S) services for it.
+//
+// All services are queried in parallel and the answers are put to a vote, so a single
+// misbehaving or hijacked service cannot decide the result on its own.
+type HTTPResolver struct {
+	// Services are the URLs to query. Empty means DefaultHTTPServices.
+	Services []string
+	// IPv6 makes the resolver connect over IPv6 and return the public IPv6 address.
+	IPv6 bool
+	// Timeout bounds each individual request. Zero means 3 seconds.
+	Timeout time.Duration
+	// Client is used for the requests. Nil means a client based on http.DefaultTransport
+	// that is restricted to the requested address family and goes through the proxy of
+	// the system, see localaddr.SystemProxy.
+	Client *http.Client
+}
+
+// HTTP looks up the public IPv4 address using the given services, or DefaultHTTPServices
+// if none are given. See HTTPResolver for details.
+func HTTP(ctx context.Context, services ...string) (netip.Addr, error) {
+	return (&HTTPResolver{Services: services}).Lookup(ctx)
+}
+
+// Lookup queries the services and returns the address that the majority of them report.
+//
+// It returns as soon as more than half of the services agree. Once every service has
+// answered or failed, the most reported address wins if there is one; a tie is an error.
+//
+// Returns:
+//   - netip.Addr: The public address (e.g., "203.0.113.7")
+//   - error: An error if no service answered or the answers are inconclusive
+func (r *HTTPResolver) Lookup(ctx context.Context) (netip.Addr, error) {
+	services := r.Services
+	if len(services) == 0 {
+		services = DefaultHTTPServices
+	}
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel() // also aborts the requests that are still running
+
+	type result struct {
+		service string
+		addr    netip.Addr
+		err     error
+	}
+	results := make(chan result, len(services))
+	client := r.client()
+	for _, service := range services {
+		go func() {
+			addr, err := r.query(ctx, client, service)
+			results <- result{service, addr, err}
+		}()
+	}
+
+	votes := make(map[netip.Addr]int)
+	var errs []error
+	for range services {
+		res := <-results
+		if res.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.service, res.err))
+			continue
+		}
+		votes[res.addr]++
+		if votes[res.addr] > len(services)/2 {
+			return res.addr, nil
+		}
+	}
+	if len(votes) == 0 {
+		return netip.Addr{}, errors.Join(errs...)
+	}
+	var best netip.Addr
+	tie := false
+	for addr, n := range votes {
+		switch {
+		case !best.IsValid() || n > votes[best]:
+			best, tie = addr, false
+		case n == votes[best]:
+			tie = true
+		}
+	}
+	if tie {
+		return netip.Addr{}, fmt.Errorf("services disagree about the public address")
+	}
+	return best, nil
+}
+
+// Resolve implements localaddr.Strategy with Lookup, so that the public address can
+// stand in for the local one, e.g. as the last resort of a chain of strategies. Only the
+// IP and Prefix, of a single address, of the result are set.
+func (r *HTTPResolver) Resolve(ctx context.Context) (localaddr.Address, error) {
+	addr, err := r.Lookup(ctx)
+	if err != nil {
+		return localaddr.Address{}, err
+	}
+	return localaddr.Address{IP: addr, Prefix: netip.PrefixFrom(addr, addr.BitLen())}, nil
+}
+
+// query asks a single service for the address.
+func (r *HTTPResolver) query(ctx context.Context, client *http.Client, service string) (netip.Addr, error) {
+	timeout := r.Timeout
+	if timeout == 0 {
+		timeout = 3 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, service, nil)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	req.Header.Set("Accept", "text/plain")
+	req.Header.Set("User-Agent", "curl/8") // some services reply with HTML to browsers
+	resp, err := client.Do(req)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return netip.Addr{}, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64))
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	addr, err := netip.ParseAddr(strings.TrimSpace(string(body)))
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("unexpected answer: %w", err)
+	}
+	addr = addr.Unmap()
+	if addr.Is6() != r.IPv6 {
+		return netip.Addr{}, fmt.Errorf("answer %s has the wrong address family", addr)
+	}
+	return addr, nil
+}
+
+// client returns the HTTP client to use for the requests.
+func (r *HTTPResolver) client() *http.Client {
+	if r.Client != nil {
+		return r.Client
+	}
+	network := "tcp4"
+	if r.IPv6 {
+		network = "tcp6"
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = localaddr.SystemProxy
+	dialer := &net.Dialer{}
+	transport.DialContext = func(ctx context.Context, _, addr string) (net.Conn, error) {
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return &http.Client{Transport: transport}
+}
@@ -0,0 +1,53 @@
+package publicaddr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+
+	"github.com/golovingreg/localaddr"
+)
+
+// ErrNotAssigned is returned by IPv6 when the internet sees an IPv6 address that is not
+// on any interface of the machine, e.g. behind NPTv6 prefix translation or a proxy.
+var ErrNotAssigned = errors.New("public address not assigned to an interface")
+
+// IPv6 returns the global IPv6 address of the machine that is reachable from the internet.
+//
+// Unlike IPv4 behind NAT, an IPv6 address is usually public as it is, so the candidates
+// are the global unicast addresses of the interfaces, as localaddr.GetIPv6 with
+// localaddr.WithIPv6Scope(localaddr.ScopeGlobal) selects them; opts narrow them further.
+// DefaultHTTPServices are then asked which address they see. If it is one of the
+// candidates, it is returned. If it is another address in the /64 of a candidate, for
+// instance a temporary address excluded by localaddr.WithTemporary, connectivity is
+// confirmed and the best candidate of that /64 is returned.
+//
+// Returns:
+//   - netip.Addr: The public IPv6 address (e.g., "2001:db8::2")
+//   - error: An error wrapping localaddr.ErrNoIPv6 if no interface has a global address,
+//     ErrNotAssigned if the services see a different network, or the error of the lookup
+func IPv6(ctx context.Context, opts ...localaddr.Option) (netip.Addr, error) {
+	local, err := localaddr.GetAllAddrs(append(opts, localaddr.WithIPv6(), localaddr.WithIPv6Scope(localaddr.ScopeGlobal))...)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	seen, err := (&HTTPResolver{IPv6: true}).Lookup(ctx)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	network := netip.PrefixFrom(seen, 64).Masked()
+	var same netip.Addr
+	for _, addr := range local { // best first
+		if addr == seen {
+			return addr, nil
+		}
+		if !same.IsValid() && network.Contains(addr) {
+			same = addr
+		}
+	}
+	if same.IsValid() {
+		return same, nil
+	}
+	return netip.Addr{}, fmt.Errorf("%s: %w", seen, ErrNotAssigned)
+}
@@ -0,0 +1,11 @@
+// Package publicaddr discovers the public (external) address of the machine, as seen
+// from the internet, e.g. when it sits behind a NAT router.
+//
+// Unlike the parent localaddr package, everything in here talks to remote servers,
+// so all functions take a context to bound how long they may take.
+package publicaddr
+
+import "time"
+
+// DefaultTimeout bounds a lookup whose context has no deadline.
+const DefaultTimeout = 5 * time.Second
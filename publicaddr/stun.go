@@ -0,0 +1,101 @@
+package publicaddr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/golovingreg/localaddr/internal/stun"
+)
+
+// DefaultSTUNServers are the public STUN servers queried when none are given.
+var DefaultSTUNServers = []string{
+	"stun.l.google.com:19302",
+	"stun.cloudflare.com:3478",
+	"stun1.l.google.com:19302",
+}
+
+// STUN learns the public address and port mapping of a UDP socket by sending a
+// Binding request (RFC 5389) to the given STUN servers (host:port), or to
+// DefaultSTUNServers if none are given.
+//
+// The servers are tried in order until one answers, each with an equal share of what
+// is left of the deadline of ctx, so that a server that does not answer leaves time for
+// the next ones. The returned port is the one the NAT mapped the (ephemeral) local
+// socket to; it is only meaningful for that socket, but the address is the machine's
+// public IP.
+//
+// Returns:
+//   - netip.AddrPort: The public address and mapped port (e.g., "203.0.113.7:61022")
+//   - error: An error if no server answered, joining the error of each server
+func STUN(ctx context.Context, servers ...string) (netip.AddrPort, error) {
+	if len(servers) == 0 {
+		servers = DefaultSTUNServers
+	}
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+	var errs []error
+	for i, server := range servers {
+		addr, err := stunQuery(ctx, server, len(servers)-i)
+		if err == nil {
+			return addr, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", server, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return netip.AddrPort{}, errors.Join(errs...)
+}
+
+// stunQuery sends a single Binding request to server from a fresh IPv4 socket, within
+// the share of the remaining deadline of ctx that falls to it with left servers to go.
+func stunQuery(ctx context.Context, server string, left int) (netip.AddrPort, error) {
+	if deadline, ok := ctx.Deadline(); ok && left > 1 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Until(deadline)/time.Duration(left))
+		defer cancel()
+	}
+	raddr, err := resolveUDP(ctx, "ip4", server)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	defer conn.Close()
+	resp, err := stun.Do(ctx, conn, net.UDPAddrFromAddrPort(raddr))
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	return resp.Mapped, nil
+}
+
+// resolveUDP resolves a host:port to the first address of the given network ("ip4" or "ip6").
+func resolveUDP(ctx context.Context, network, hostport string) (netip.AddrPort, error) {
+	host, service, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	port, err := net.DefaultResolver.LookupPort(ctx, "udp", service)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, network, host)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	return netip.AddrPortFrom(ips[0].Unmap(), uint16(port)), nil
+}
+
+// withDefaultTimeout applies DefaultTimeout to ctx unless it already has a deadline.
+func withDefaultTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, DefaultTimeout)
+}
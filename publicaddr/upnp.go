@@ -0,0 +1,33 @@
+package publicaddr
+
+import (
+	"context"
+	"net/netip"
+
+	"github.com/golovingreg/localaddr"
+	"github.com/golovingreg/localaddr/internal/igd"
+)
+
+// ErrNoGateway is returned by UPnP when no router on the LAN answers UPnP IGD requests.
+// Many routers ship with UPnP turned off.
+var ErrNoGateway = igd.ErrNoGateway
+
+// UPnP asks the router for its WAN address with the UPnP Internet Gateway Device
+// protocol. Unlike the other lookups, it does not contact any service on the internet.
+//
+// The router is searched for on the LAN of the address that localaddr.Get(opts...)
+// selects. Behind a second NAT, e.g. a provider's carrier-grade NAT, the WAN address of
+// the router is not the public address; see localaddr.Classify.
+//
+// Returns:
+//   - netip.Addr: The WAN address of the router (e.g., "203.0.113.7")
+//   - error: An error wrapping ErrNoGateway if no router answers, or the error the router reports
+func UPnP(ctx context.Context, opts ...localaddr.Option) (netip.Addr, error) {
+	ctx, cancel := withDefaultTimeout(ctx)
+	defer cancel()
+	c, err := igd.Discover(ctx, opts...)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	return c.ExternalIP(ctx)
+}
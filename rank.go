@@ -0,0 +1,232 @@
+package localaddr
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"net"
+	"net/netip"
+	"slices"
+)
+
+// Scored is a candidate address together with the score it was ranked by.
+type Scored struct {
+	Addr      netip.Addr
+	Interface string
+	Kind      Kind
+	Class     Class
+	Stability Stability
+	Score     int
+	Reasons   []string // the criteria that added to Score, e.g. "default route +256"
+}
+
+// Points of the ranking criteria. Each criterion outweighs the ones below it together,
+// counting the criteria that are added or subtracted in both directions and stability at
+// its highest level, so a preferred subnet outweighs everything else.
+const (
+	pointsPreferred    = 8192 // per position from the end of the WithPreferredSubnet list
+	pointsPreference   = 2048 // added for Prefer, subtracted for Avoid
+	pointsPreferWired  = 1024
+	pointsContainer    = 512
+	pointsDefaultRoute = 256
+	pointsRunning      = 128
+	pointsPhysical     = 64
+	pointsRoutable     = 32
+	pointsStable       = 4 // per Stability level, with WithPreferStable
+	pointsTemporary    = 4 // added for Prefer, subtracted for Avoid
+	pointsNotWireless  = 2
+	pointsHostname     = 1 // only breaks ties of the criteria above
+)
+
+// Rank returns the candidate addresses that Get considers, best first, with the scores that
+// ordered them. Get returns the first one; Rank explains why.
+//
+// Every candidate starts at zero and earns points for:
+//   - lying inside a WithPreferredSubnet subnet (more for subnets given earlier)
+//   - belonging to a group of addresses that is preferred, e.g. with WithCGNAT(Prefer);
+//     a group that is avoided, such as VPN tunnels and hypervisor adapters by default,
+//     costs as many points instead
+//   - being on a wired Ethernet interface, if WithPreferWired is given
+//   - being eth0 inside a container (see InContainer)
+//   - being on the interface of the default route
+//   - being on an interface with a link (cable plugged in, Wi-Fi associated)
+//   - being on a physical rather than virtual interface (see IsVirtual)
+//   - not being link-local
+//   - being stable, if WithPreferStable is given: more for a static address than for a
+//     leased one, and for that than for a temporary one (see Stability)
+//   - being a temporary IPv6 address, if WithTemporary(Prefer) is given; with
+//     WithTemporary(Avoid) it costs as many points instead
+//   - being on a wired rather than wireless interface
+//   - being an address the host name resolves to, if WithHostnameFallback is given
+//
+// Candidates with equal scores are ordered as the routing table orders their interfaces:
+// by the metric of their default route, then for interfaces without one by the lowest
+// metric of their other routes, so that e.g. Ethernet comes before Wi-Fi where the
+// system prefers it. On Windows the metrics include the interface metric. Ties that
+// remain are broken by the index of the interface, then its name, then the order of the
+// interface's addresses; WithOSOrder keeps the order the operating system reports the
+// interfaces in instead, without looking at metrics. As for Get, WithIPv6 ranks IPv6
+// addresses instead of IPv4 ones.
+//
+// Returns:
+//   - []Scored: The candidates, best first
+//   - error: An error if no suitable address is found or if there's an issue accessing network interfaces
+func Rank(opts ...Option) ([]Scored, error) {
+	cfg := newConfig(ipv4, opts)
+	found, err := scan(cfg)
+	if err != nil {
+		return nil, err
+	}
+	r := ranker{cfg: cfg, readHostname: len(found) < 2} // nothing to break ties between
+	ranked := make([]Scored, len(found))
+	for i, c := range found {
+		ranked[i] = Scored{Addr: c.ip, Interface: c.iface.Name, Kind: cfg.kind(&c.iface), Class: Classify(c.ip), Stability: cfg.stability(&c), Reasons: []string{}}
+		ranked[i].Score = r.score(c, &ranked[i].Reasons)
+	}
+	return ranked, nil
+}
+
+// ranker scores candidates. It reads the routing table at most once per family.
+type ranker struct {
+	cfg                *config
+	gw4, gw6           string           // interfaces of the default routes, "" if none
+	metrics4, metrics6 map[string]int64 // see interfaceMetrics
+	read4, read6       bool
+	hostname           []netip.Addr // WithHostnameFallback addresses
+	readHostname       bool
+}
+
+// sort orders candidates best first, keeping the scan order among equals.
+func (c *config) sort(found []candidate) {
+	if len(found) < 2 {
+		return // nothing to order, save reading the routing table
+	}
+	type scored struct {
+		c      candidate
+		score  int
+		metric int64
+	}
+	r := ranker{cfg: c}
+	all := make([]scored, len(found))
+	for i, cand := range found {
+		if !c.tracing() {
+			all[i] = scored{cand, r.score(cand, nil), r.metric(cand)}
+			continue
+		}
+		reasons := []string{}
+		all[i] = scored{cand, r.score(cand, &reasons), r.metric(cand)}
+		c.trace("scored candidate", "interface", cand.iface.Name, "address", cand.ip, "score", all[i].score, "reasons", reasons)
+	}
+	slices.SortStableFunc(all, func(a, b scored) int {
+		if a.score != b.score || c.osOrder {
+			return b.score - a.score
+		}
+		return cmp.Compare(a.metric, b.metric)
+	})
+	for i := range all {
+		found[i] = all[i].c
+	}
+}
+
+// score returns the points of c. If reasons is not nil, the criteria that gave points
+// are appended to it.
+func (r *ranker) score(c candidate, reasons *[]string) int {
+	total := 0
+	add := func(points int, reason string) {
+		total += points
+		if reasons != nil {
+			*reasons = append(*reasons, fmt.Sprintf("%s %+d", reason, points))
+		}
+	}
+	if i := r.cfg.rank(c.ip); i < len(r.cfg.preferred) {
+		add(pointsPreferred*(len(r.cfg.preferred)-i), "preferred subnet "+r.cfg.preferred[i].String())
+	}
+	k := r.cfg.kind(&c.iface)
+	switch p, group := r.cfg.preference(&c.iface, c.ip, k == KindTunnel); p {
+	case Prefer:
+		add(pointsPreference, group+" preferred")
+	case Avoid:
+		add(-pointsPreference, group+" avoided")
+	}
+	if r.cfg.preferWired && k == KindEthernet {
+		add(pointsPreferWired, "wired, preferred")
+	}
+	if r.cfg.inContainer && c.iface.Name == containerInterface {
+		add(pointsContainer, "container interface")
+	}
+	if name := r.defaultIface(c.ip.Is6()); name != "" && name == c.iface.Name {
+		add(pointsDefaultRoute, "default route")
+	}
+	if c.iface.Flags&net.FlagRunning != 0 {
+		add(pointsRunning, "link up")
+	}
+	if k != KindVirtual && k != KindBridge && k != KindTunnel && !IsVirtual(c.iface.Name) {
+		add(pointsPhysical, "physical interface")
+	}
+	if !c.ip.IsLinkLocalUnicast() {
+		add(pointsRoutable, "not link-local")
+	}
+	if r.cfg.preferStable {
+		if s := r.cfg.stability(&c); s != StabilityUnknown {
+			add(pointsStable*int(s), "stability "+s.String())
+		}
+	}
+	if c.flags&addrTemporary != 0 {
+		switch r.cfg.temporary {
+		case Prefer:
+			add(pointsTemporary, "temporary, preferred")
+		case Avoid:
+			add(-pointsTemporary, "temporary, avoided")
+		}
+	}
+	if k != KindWiFi {
+		add(pointsNotWireless, "not wireless")
+	}
+	if r.cfg.byHostname && slices.Contains(r.hostnameAddrs(), c.ip.WithZone("")) {
+		add(pointsHostname, "host name")
+	}
+	return total
+}
+
+// defaultIface returns the interface of the IPv4 or IPv6 default route, or "" if there
+// is none or the routing table cannot be read.
+func (r *ranker) defaultIface(v6 bool) string {
+	name, _ := r.routes(v6)
+	return name
+}
+
+// metric returns the rank of the interface of c in the routing table, by which the
+// system would choose between it and others; see interfaceMetrics. Interfaces without
+// routes come last.
+func (r *ranker) metric(c candidate) int64 {
+	_, metrics := r.routes(c.ip.Is6())
+	if m, ok := metrics[c.iface.Name]; ok {
+		return m
+	}
+	return math.MaxInt64
+}
+
+// routes reads the IPv4 or IPv6 routing table, or the default route of a Provider, on
+// first use, and returns the interface of its default route and the interface metrics.
+func (r *ranker) routes(v6 bool) (string, map[string]int64) {
+	name, metrics, read, f := &r.gw4, &r.metrics4, &r.read4, ipv4
+	if v6 {
+		name, metrics, read, f = &r.gw6, &r.metrics6, &r.read6, ipv6
+	}
+	if !*read {
+		*read = true
+		var routes []route
+		if r.cfg.provider != nil {
+			if gw, err := providedRoute(r.cfg.provider, &config{family: f}); err == nil {
+				routes = []route{gw}
+			}
+		} else {
+			routes, _ = readRoutes(f)
+		}
+		if gw, ok := bestDefault(routes, ""); ok {
+			*name = gw.iface
+		}
+		*metrics = interfaceMetrics(routes)
+	}
+	return *name, *metrics
+}
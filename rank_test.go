@@ -0,0 +1,33 @@
+package localaddr
+
+import "testing"
+
+func TestPointsOutweighLowerCriteria(t *testing.T) {
+	// The most that each criterion can tell two candidates apart by, top down.
+	criteria := []struct {
+		name   string
+		weight int
+	}{
+		{"preferred subnet", pointsPreferred},
+		{"preference", 2 * pointsPreference},
+		{"prefer wired", pointsPreferWired},
+		{"container", pointsContainer},
+		{"default route", pointsDefaultRoute},
+		{"running", pointsRunning},
+		{"physical", pointsPhysical},
+		{"routable", pointsRoutable},
+		{"stable", pointsStable * int(StabilityStatic)},
+		{"temporary", 2 * pointsTemporary},
+		{"not wireless", pointsNotWireless},
+		{"hostname", pointsHostname},
+	}
+	for i, c := range criteria {
+		below := 0
+		for _, l := range criteria[i+1:] {
+			below += l.weight
+		}
+		if c.weight <= below {
+			t.Errorf("%s: %d points do not outweigh the %d of the criteria below", c.name, c.weight, below)
+		}
+	}
+}
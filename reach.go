@@ -0,0 +1,159 @@
+package localaddr
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Defaults of WithReachability.
+const (
+	reachTimeout     = time.Second
+	reachGatewayPort = 53 // DNS, which most home and office routers serve
+)
+
+// WithReachability makes the getters test the candidates against the network, as Happy
+// Eyeballs (RFC 8305) does for the addresses of a host: a TCP connection to target, a
+// host:port, is started from every candidate at the same time, and the candidates are
+// returned in the order their connections completed, so that the first one is the
+// address that actually reaches target fastest. A reset counts as completed, since the
+// peer answered. Candidates whose connection fails or takes longer than a second are
+// dropped; if none is left, the error wraps ErrNotConnected, as with WithVerify.
+//
+// With an empty target every candidate connects to port 53 of the default gateway of
+// its interface, and those on interfaces without a default route are dropped. A host
+// name in target is resolved once, and each candidate connects to its first address of
+// the candidate's family.
+//
+// It costs a round trip per lookup, so cache the result, e.g. with NewCache. Rank
+// returns the candidates in the same order, with their scores. Addresses of EnvOverride
+// and of a Provider are not tested.
+func WithReachability(target string) Option {
+	return func(c *config) {
+		if target != "" {
+			_, port, err := net.SplitHostPort(target)
+			if err == nil {
+				_, err = strconv.ParseUint(port, 10, 16)
+			}
+			if err != nil {
+				c.setErr(fmt.Errorf("invalid reachability target %q: %w", target, err))
+				return
+			}
+		}
+		c.reach, c.reachTarget = true, target
+	}
+}
+
+// raced orders the candidates by how fast they connect to the reachability target, and
+// drops those that fail.
+func (cfg *config) raced(found []candidate) ([]candidate, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), reachTimeout)
+	defer cancel()
+	dsts, err := cfg.reachTargets(ctx, found)
+	if err != nil {
+		return nil, err
+	}
+	type result struct {
+		c   candidate
+		dst netip.AddrPort
+		rtt time.Duration
+		err error
+	}
+	results := make(chan result, len(found))
+	for i, c := range found {
+		go func() {
+			if !dsts[i].IsValid() {
+				results <- result{c, dsts[i], 0, fmt.Errorf("%s: no target to connect to", c.ip)}
+				return
+			}
+			rtt, err := connectTime(ctx, c.ip, dsts[i])
+			results <- result{c, dsts[i], rtt, err}
+		}()
+	}
+	var ok []result
+	var failed []error
+	for range found {
+		r := <-results
+		if r.err != nil {
+			cfg.trace("skipping address", "interface", r.c.iface.Name, "address", r.c.ip, "reason", "not reachable", "error", r.err)
+			failed = append(failed, r.err)
+			continue
+		}
+		cfg.trace("reached target", "interface", r.c.iface.Name, "address", r.c.ip, "target", r.dst, "rtt", r.rtt)
+		ok = append(ok, r)
+	}
+	if len(ok) == 0 {
+		return nil, fmt.Errorf("%w: no address reached the target: %w", ErrNotConnected, errors.Join(failed...))
+	}
+	slices.SortStableFunc(ok, func(a, b result) int { return cmp.Compare(a.rtt, b.rtt) })
+	found = found[:0]
+	for _, r := range ok {
+		found = append(found, r.c)
+	}
+	return found, nil
+}
+
+// reachTargets returns the address to connect to from each candidate, the zero
+// AddrPort for those that have none.
+func (cfg *config) reachTargets(ctx context.Context, found []candidate) ([]netip.AddrPort, error) {
+	dsts := make([]netip.AddrPort, len(found))
+	if cfg.reachTarget == "" {
+		var routes [2][]route // IPv4 and IPv6, read on first use
+		var read [2]bool
+		for i, c := range found {
+			v, f := 0, ipv4
+			if c.ip.Is6() {
+				v, f = 1, ipv6
+			}
+			if !read[v] {
+				routes[v], _ = readRoutes(f)
+				read[v] = true
+			}
+			if r, ok := bestDefault(routes[v], c.iface.Name); ok {
+				gw := r.gw
+				if gw.Is6() && gw.IsLinkLocalUnicast() && gw.Zone() == "" {
+					gw = gw.WithZone(c.iface.Name)
+				}
+				dsts[i] = netip.AddrPortFrom(gw, reachGatewayPort)
+			}
+		}
+		return dsts, nil
+	}
+	host, portStr, _ := net.SplitHostPort(cfg.reachTarget)
+	port, _ := strconv.ParseUint(portStr, 10, 16)
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return nil, fmt.Errorf("resolving the reachability target: %w", err)
+	}
+	for i, c := range found {
+		for _, ip := range ips {
+			if ip = ip.Unmap(); ip.Is6() == c.ip.Is6() {
+				dsts[i] = netip.AddrPortFrom(ip, uint16(port))
+				break
+			}
+		}
+	}
+	return dsts, nil
+}
+
+// connectTime connects from src to dst and returns how long it took until the peer
+// answered.
+func connectTime(ctx context.Context, src netip.Addr, dst netip.AddrPort) (time.Duration, error) {
+	d := net.Dialer{LocalAddr: net.TCPAddrFromAddrPort(netip.AddrPortFrom(src, 0))}
+	start := time.Now()
+	conn, err := d.DialContext(ctx, "tcp", dst.String())
+	rtt := time.Since(start)
+	if err != nil && !refused(err) {
+		return 0, err
+	}
+	if conn != nil {
+		conn.Close()
+	}
+	return rtt, nil
+}
@@ -0,0 +1,20 @@
+//go:build !windows && !plan9
+
+package localaddr
+
+import (
+	"errors"
+	"syscall"
+)
+
+// refused reports whether err means that the peer answered with a reset or, for UDP,
+// a port unreachable message.
+func refused(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// unreachable reports whether err means that no route leads to the peer, or that it did
+// not resolve its hardware address, as for addresses of the LAN that no host has.
+func unreachable(err error) bool {
+	return errors.Is(err, syscall.EHOSTUNREACH) || errors.Is(err, syscall.ENETUNREACH)
+}
@@ -0,0 +1,15 @@
+package localaddr
+
+import "strings"
+
+// refused reports whether err means that the peer answered with a reset. Plan 9 has no
+// error numbers; the network reports it in the text of the error.
+func refused(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "connection refused")
+}
+
+// unreachable reports whether err means that no route leads to the peer, as the text of
+// the error tells.
+func unreachable(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "unreachable")
+}
@@ -0,0 +1,19 @@
+package localaddr
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// refused reports whether err means that the peer answered with a reset or, for UDP,
+// a port unreachable message, which Windows reports as a reset connection.
+func refused(err error) bool {
+	return errors.Is(err, windows.WSAECONNREFUSED) || errors.Is(err, windows.WSAECONNRESET)
+}
+
+// unreachable reports whether err means that no route leads to the peer, or that it did
+// not resolve its hardware address, as for addresses of the LAN that no host has.
+func unreachable(err error) bool {
+	return errors.Is(err, windows.WSAEHOSTUNREACH) || errors.Is(err, windows.WSAENETUNREACH)
+}
@@ -0,0 +1,109 @@
+package localaddr
+
+import (
+	"net"
+	"net/netip"
+)
+
+// InterfaceReport describes an interface and all of its addresses, see Report.
+type InterfaceReport struct {
+	Name      string
+	Adapter   string // see Address.Adapter
+	Index     int
+	MAC       net.HardwareAddr // empty for interfaces without one, such as tunnels
+	Flags     net.Flags
+	MTU       int
+	Kind      Kind
+	Virtual   bool // see IsVirtual
+	Carrier   bool // see HasCarrier
+	Addresses []ReportedAddr
+}
+
+// ReportedAddr is an address of an interface in a Report.
+type ReportedAddr struct {
+	IP        netip.Addr   // with the interface as zone for link-local IPv6 addresses
+	Prefix    netip.Prefix // IP with the length of its on-link network, without a zone
+	Class     Class
+	Temporary bool // an IPv6 privacy extension address
+	Secondary bool // an IPv4 address in the subnet of an earlier one, see WithPrimaryOnly
+}
+
+// Report lists every up interface with all of its IPv4 and IPv6 addresses, whether
+// Get would consider them or not: loopback, virtual, and link-local ones included. It
+// saves network inventory tools from walking the interfaces themselves.
+//
+// Only the options that choose interfaces apply, i.e. WithProvider, WithInterface,
+// WithDefaultRoute, WithIncludeInterfaces, and WithExcludeInterfaces; those that select
+// addresses are ignored. An interface whose addresses cannot be read is listed without
+// addresses.
+//
+// Returns:
+//   - map[string]InterfaceReport: The interfaces by name (e.g., "eth0" with 192.168.1.2
+//     and fe80::1%eth0)
+//   - error: An error if the interfaces cannot be listed or an option is invalid
+func Report(opts ...Option) (map[string]InterfaceReport, error) {
+	cfg := newConfig(anyFamily, opts)
+	links, err := cfg.walk()
+	if err != nil {
+		return nil, err
+	}
+	report := make(map[string]InterfaceReport)
+	for i := range links {
+		l := &links[i]
+		v := l.iface
+		if v.Flags&net.FlagUp == 0 || (cfg.iface != "" && v.Name != cfg.iface) || (cfg.iface == "" && !cfg.allowed(v.Name)) {
+			continue
+		}
+		r := InterfaceReport{
+			Name:      v.Name,
+			Adapter:   cfg.description(&v),
+			Index:     v.Index,
+			MAC:       v.HardwareAddr,
+			Flags:     v.Flags,
+			MTU:       v.MTU,
+			Kind:      cfg.kind(&v),
+			Virtual:   IsVirtual(v.Name),
+			Carrier:   cfg.carrier(&v),
+			Addresses: make([]ReportedAddr, 0, len(l.addrs)),
+		}
+		for j, prefix := range l.addrs {
+			ip := prefix.Addr()
+			if ip.Is6() && ip.IsLinkLocalUnicast() {
+				ip = ip.WithZone(v.Name)
+			}
+			r.Addresses = append(r.Addresses, ReportedAddr{
+				IP:        ip,
+				Prefix:    prefix,
+				Class:     Classify(ip),
+				Temporary: l.flagsOf(j)&addrTemporary != 0,
+				Secondary: l.flagsOf(j)&addrSecondary != 0,
+			})
+		}
+		report[v.Name] = r
+	}
+	return report, nil
+}
+
+// Interfaces lists the network interfaces that the options choose from, up or down,
+// loopback ones included, in the order in which Get goes through them. As for Report,
+// only the options that choose interfaces apply.
+//
+// Returns:
+//   - []net.Interface: The interfaces (e.g., "lo", "eth0", and "wlan0")
+//   - error: An error if the interfaces cannot be listed or an option is invalid
+func Interfaces(opts ...Option) ([]net.Interface, error) {
+	cfg := newConfig(anyFamily, opts)
+	links, err := cfg.walk()
+	if err != nil {
+		return nil, err
+	}
+	var ifaces []net.Interface
+	for _, l := range links {
+		v := l.iface
+		if (cfg.iface != "" && v.Name != cfg.iface) || (cfg.iface == "" && !cfg.allowed(v.Name)) {
+			continue
+		}
+		ifaces = append(ifaces, v)
+	}
+	return ifaces, nil
+}
@@ -0,0 +1,12 @@
+package localaddr
+
+import (
+	"errors"
+	"os"
+)
+
+// restricted reports whether err is Android's refusal to list the interfaces: since
+// Android 11, apps may not dump the netlink tables that net.Interfaces reads.
+func restricted(err error) bool {
+	return errors.Is(err, os.ErrPermission)
+}
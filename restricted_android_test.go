@@ -0,0 +1,20 @@
+package localaddr_test
+
+import (
+	"fmt"
+	"syscall"
+	"testing"
+
+	"github.com/golovingreg/localaddr"
+)
+
+// Since Android 11, listing the interfaces fails as netlinkrib is refused, and the
+// getters fall back to the outbound address.
+func TestRestrictedFallback(t *testing.T) {
+	got, err := localaddr.Get(
+		localaddr.WithProvider(unlisted{fmt.Errorf("route ip+net: netlinkrib: %w", syscall.EACCES)}),
+		localaddr.WithDialer(dialer(map[string]string{"udp4": "192.168.1.10"})))
+	if err != nil || got != "192.168.1.10" {
+		t.Errorf("Get() = %q, %v, want %q", got, err, "192.168.1.10")
+	}
+}
@@ -0,0 +1,7 @@
+//go:build !android
+
+package localaddr
+
+// restricted reports whether err means that the platform does not let the process list
+// the interfaces, which only Android does.
+func restricted(error) bool { return false }
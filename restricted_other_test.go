@@ -0,0 +1,24 @@
+//go:build !android
+
+package localaddr_test
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+
+	"github.com/golovingreg/localaddr"
+)
+
+// Elsewhere, a refusal to list the interfaces is an error like any other; only Android
+// restricts apps this way.
+func TestRestrictedError(t *testing.T) {
+	refusal := fmt.Errorf("route ip+net: netlinkrib: %w", syscall.EACCES)
+	got, err := localaddr.Get(
+		localaddr.WithProvider(unlisted{refusal}),
+		localaddr.WithDialer(dialer(map[string]string{"udp4": "192.168.1.10"})))
+	if !errors.Is(err, refusal) {
+		t.Errorf("Get() = %q, %v, want error %v", got, err, refusal)
+	}
+}
@@ -0,0 +1,89 @@
+package localaddr_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"testing"
+
+	"github.com/golovingreg/localaddr"
+)
+
+// unlisted is a Provider that cannot list the interfaces, as on platforms that hide
+// them.
+type unlisted struct{ err error }
+
+func (p unlisted) Interfaces() ([]net.Interface, error)    { return nil, p.err }
+func (p unlisted) Addrs(net.Interface) ([]net.Addr, error) { return nil, p.err }
+
+// udpConn is the connected UDP socket of a dialer, of which only the local address is
+// read.
+type udpConn struct {
+	net.Conn
+	local *net.UDPAddr
+}
+
+func (c udpConn) LocalAddr() net.Addr { return c.local }
+func (c udpConn) Close() error        { return nil }
+
+// dialer returns a WithDialer function whose sockets are bound to the given addresses,
+// per network.
+func dialer(local map[string]string) func(ctx context.Context, network, address string) (net.Conn, error) {
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		addr, ok := local[network]
+		if !ok {
+			return nil, fmt.Errorf("dial %s %s: network is unreachable", network, address)
+		}
+		return udpConn{local: net.UDPAddrFromAddrPort(netip.AddrPortFrom(netip.MustParseAddr(addr), 40000))}, nil
+	}
+}
+
+func TestUnlistedFallback(t *testing.T) {
+	hidden := localaddr.WithProvider(unlisted{fmt.Errorf("listing interfaces: %w", localaddr.ErrUnsupportedPlatform)})
+	dial := localaddr.WithDialer(dialer(map[string]string{"udp4": "192.168.1.10", "udp6": "2001:db8::10"}))
+
+	tests := []struct {
+		name string
+		opts []localaddr.Option
+		get  func(...localaddr.Option) (string, error)
+		want string
+		err  error
+	}{
+		{"Get", []localaddr.Option{hidden, dial}, localaddr.Get, "192.168.1.10", nil},
+		{"GetIPv6", []localaddr.Option{hidden, dial}, localaddr.GetIPv6, "2001:db8::10", nil},
+		{"name filters do not apply", []localaddr.Option{hidden, dial, localaddr.WithExcludeInterfaces("*")}, localaddr.Get, "192.168.1.10", nil},
+		{"subnet applies", []localaddr.Option{hidden, dial, localaddr.WithSubnet(netip.MustParsePrefix("10.0.0.0/8"))}, localaddr.Get, "", localaddr.ErrNoIPv4},
+		{"subnet matches", []localaddr.Option{hidden, dial, localaddr.WithSubnet(netip.MustParsePrefix("192.168.0.0/16"))}, localaddr.Get, "192.168.1.10", nil},
+		{"loopback source", []localaddr.Option{hidden, localaddr.WithDialer(dialer(map[string]string{"udp4": "127.0.0.1"}))}, localaddr.Get, "", localaddr.ErrNoIPv4},
+		{"no route", []localaddr.Option{hidden, localaddr.WithDialer(dialer(nil))}, localaddr.Get, "", localaddr.ErrUnsupportedPlatform},
+		{"without a dialer", []localaddr.Option{hidden}, localaddr.Get, "", localaddr.ErrUnsupportedPlatform},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.get(tt.opts...)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("got %q, error %v, want %v", got, err, tt.err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("got %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnlistedFallbackDetails(t *testing.T) {
+	addr, err := localaddr.GetDetailed(
+		localaddr.WithProvider(unlisted{localaddr.ErrUnsupportedPlatform}),
+		localaddr.WithDialer(dialer(map[string]string{"udp4": "192.168.1.10"})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr.IP != netip.MustParseAddr("192.168.1.10") || addr.Interface != "" {
+		t.Errorf("GetDetailed() = %v on %q, want 192.168.1.10 without an interface", addr.IP, addr.Interface)
+	}
+}
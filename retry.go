@@ -0,0 +1,82 @@
+package localaddr
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy tells GetWithRetry how long to wait between attempts. The zero value is a
+// sensible policy: 100ms, doubling up to 5s, with 20% jitter, until ctx is done.
+type RetryPolicy struct {
+	// Initial is the wait after the first failed attempt. Zero means 100ms.
+	Initial time.Duration
+	// Max caps the wait between attempts. Zero means 5s.
+	Max time.Duration
+	// Multiplier grows the wait after every failed attempt. Values below 1 mean 2.
+	Multiplier float64
+	// Jitter randomizes each wait by up to this fraction in either direction, so that
+	// many machines booting together do not retry in lockstep. Zero means 0.2, negative
+	// values mean none.
+	Jitter float64
+	// Attempts limits the number of attempts. Zero means unlimited, until ctx is done.
+	Attempts int
+}
+
+// GetWithRetry is like Get, but retries with exponential backoff while the failure may
+// be temporary, i.e. with the errors WaitForNetwork keeps waiting on. Unlike
+// WaitForNetwork, it does not use change notifications and works the same everywhere.
+//
+// Returns:
+//   - string: The address as a string (e.g., "192.168.1.2")
+//   - error: The error of the last attempt, joined with ctx.Err() if ctx ended the retries
+func GetWithRetry(ctx context.Context, policy RetryPolicy, opts ...Option) (string, error) {
+	wait := policy.withDefaults()
+	for attempt := 1; ; attempt++ {
+		addr, err := Get(opts...)
+		if err == nil || !transient(err) || attempt == policy.Attempts {
+			return addr, err
+		}
+		timer := time.NewTimer(wait.next())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return "", fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// withDefaults returns p with its zero fields replaced by the defaults.
+func (p RetryPolicy) withDefaults() *backoff {
+	b := &backoff{p}
+	if b.Initial <= 0 {
+		b.Initial = 100 * time.Millisecond
+	}
+	if b.Max <= 0 {
+		b.Max = 5 * time.Second
+	}
+	if b.Multiplier < 1 {
+		b.Multiplier = 2
+	}
+	if b.Jitter == 0 {
+		b.Jitter = 0.2
+	}
+	return b
+}
+
+// backoff hands out the waits of a policy.
+type backoff struct {
+	RetryPolicy // Initial is the next wait before jitter
+}
+
+// next returns the next wait and grows the one after it.
+func (b *backoff) next() time.Duration {
+	d := min(b.Initial, b.Max)
+	b.Initial = min(time.Duration(float64(b.Initial)*b.Multiplier), b.Max)
+	if b.Jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * b.Jitter * float64(d))
+	}
+	return d
+}
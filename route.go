@@ -0,0 +1,177 @@
+package localaddr
+
+import (
+	"fmt"
+	"math/bits"
+	"net"
+	"net/netip"
+	"slices"
+)
+
+// Route is an entry of the routing table.
+type Route struct {
+	Dst       netip.Prefix // destination, 0.0.0.0/0 or ::/0 for a default route
+	Gateway   netip.Addr   // next hop, invalid for directly connected networks
+	Interface string       // name of the interface the route goes out of
+	Metric    int          // lower is preferred among routes to the same destination
+}
+
+// route is an entry of the system routing table.
+type route struct {
+	dst    netip.Prefix
+	gw     netip.Addr // invalid for directly connected networks
+	iface  string
+	metric int
+}
+
+// Gateway returns the default gateway and the name of the interface it is reached through.
+//
+// The routing table is read as by Routes. If there are several default routes, the one
+// with the lowest metric wins. WithIPv6 looks for the IPv6 default gateway instead, and
+// WithInterface restricts the search to routes through that interface.
+//
+// Returns:
+//   - netip.Addr: The gateway address (e.g., "192.168.1.1")
+//   - string: The name of the interface the gateway lives on (e.g., "eth0")
+//   - error: An error if there is no default route or the routing table cannot be read
+func Gateway(opts ...Option) (netip.Addr, string, error) {
+	cfg := newConfig(ipv4, opts)
+	if cfg.err != nil {
+		return netip.Addr{}, "", cfg.err
+	}
+	r, err := defaultRoute(cfg)
+	if err != nil {
+		return netip.Addr{}, "", err
+	}
+	return r.gw, r.iface, nil
+}
+
+// Routes returns the IPv4 routing table, or the IPv6 one with WithIPv6, most specific
+// destinations first and then by metric, the order in which the system picks a route.
+// WithInterface restricts it to the routes through that interface. With WithProvider,
+// only the default route of a GatewayProvider is known.
+//
+// The table is read from /proc/net/route and /proc/net/ipv6_route on Linux, from a route
+// socket dump on macOS and the BSDs, and with GetIpForwardTable and GetIpForwardTable2
+// on Windows, whose metrics include the interface metric. Routes have no metrics on
+// macOS and the BSDs, where Metric is zero.
+//
+// Returns:
+//   - []Route: The routes (e.g., 192.168.1.0/24 on "eth0", and 0.0.0.0/0 via 192.168.1.1)
+//   - error: An error if the routing table cannot be read
+func Routes(opts ...Option) ([]Route, error) {
+	cfg := newConfig(ipv4, opts)
+	if cfg.err != nil {
+		return nil, cfg.err
+	}
+	var routes []route
+	if cfg.provider != nil {
+		if r, err := providedRoute(cfg.provider, cfg); err == nil {
+			routes = append(routes, r)
+		}
+	} else {
+		var err error
+		if routes, err = readRoutes(cfg.family); err != nil {
+			return nil, err
+		}
+	}
+	out := make([]Route, 0, len(routes))
+	for _, r := range routes {
+		if cfg.iface != "" && r.iface != cfg.iface {
+			continue
+		}
+		out = append(out, Route{Dst: r.dst, Gateway: r.gw, Interface: r.iface, Metric: r.metric})
+	}
+	slices.SortStableFunc(out, func(a, b Route) int {
+		if a.Dst.Bits() != b.Dst.Bits() {
+			return b.Dst.Bits() - a.Dst.Bits()
+		}
+		return a.Metric - b.Metric
+	})
+	return out, nil
+}
+
+// defaultRoute returns the preferred default route for cfg.
+func defaultRoute(cfg *config) (route, error) {
+	if cfg.provider != nil {
+		return providedRoute(cfg.provider, cfg)
+	}
+	routes, err := readRoutes(cfg.family)
+	if err != nil {
+		return route{}, err
+	}
+	best, ok := bestDefault(routes, cfg.iface)
+	if !ok {
+		return route{}, fmt.Errorf("no default gateway")
+	}
+	return best, nil
+}
+
+// bestDefault returns the default route of routes with the lowest metric, restricted to
+// the interface iface unless it is "".
+func bestDefault(routes []route, iface string) (route, bool) {
+	var best route
+	found := false
+	for _, r := range routes {
+		if !r.isDefault() {
+			continue
+		}
+		if iface != "" && r.iface != iface {
+			continue
+		}
+		if !found || r.metric < best.metric {
+			best, found = r, true
+		}
+	}
+	return best, found
+}
+
+// isDefault reports whether r is a default route through a gateway.
+func (r route) isDefault() bool {
+	return r.dst.Bits() == 0 && r.gw.IsValid()
+}
+
+// noDefaultRoute is added to the metrics of interfaceMetrics for interfaces without a
+// default route, which are above any metric of a route.
+const noDefaultRoute = int64(1) << 32
+
+// interfaceMetrics returns the order in which the system prefers the interfaces of
+// routes, lowest first: by the lowest metric of their default routes, then, for those
+// without one, by the lowest metric of their other routes.
+func interfaceMetrics(routes []route) map[string]int64 {
+	metrics := make(map[string]int64)
+	for _, r := range routes {
+		if r.iface == "" {
+			continue
+		}
+		m := int64(r.metric)
+		if !r.isDefault() {
+			m += noDefaultRoute
+		}
+		if old, ok := metrics[r.iface]; !ok || m < old {
+			metrics[r.iface] = m
+		}
+	}
+	return metrics
+}
+
+// ifaceName returns the name of the interface with the given index, or "" if unknown.
+func ifaceName(index int) string {
+	iface, err := net.InterfaceByIndex(index)
+	if err != nil {
+		return ""
+	}
+	return iface.Name
+}
+
+// maskBits returns the prefix length of a contiguous netmask, counting its leading ones.
+func maskBits(mask []byte) int {
+	n := 0
+	for _, b := range mask {
+		n += bits.LeadingZeros8(^b)
+		if b != 0xff {
+			break
+		}
+	}
+	return n
+}
@@ -0,0 +1,68 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package localaddr
+
+import (
+	"net/netip"
+	"syscall"
+)
+
+// readRoutes dumps the kernel routing table through a route socket and keeps the routes
+// of the given family.
+func readRoutes(f family) ([]route, error) {
+	rib, err := syscall.RouteRIB(syscall.NET_RT_DUMP, 0) // every family; RouteRIB cannot pick one
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := syscall.ParseRoutingMessage(rib)
+	if err != nil {
+		return nil, err
+	}
+	var routes []route
+	for _, msg := range msgs {
+		m, ok := msg.(*syscall.RouteMessage)
+		if !ok || m.Header.Flags&syscall.RTF_UP == 0 || scopedRoute(m) {
+			continue
+		}
+		sas, err := syscall.ParseRoutingSockaddr(m)
+		if err != nil || len(sas) <= syscall.RTAX_NETMASK {
+			continue
+		}
+		dst := sockaddrIP(sas[syscall.RTAX_DST])
+		if !dst.IsValid() || dst.Is4() != (f == ipv4) {
+			continue
+		}
+		bits := dst.BitLen() // host route unless a netmask says otherwise
+		if m.Header.Addrs&syscall.RTA_NETMASK != 0 {
+			bits = maskBits(sockaddrIP(sas[syscall.RTAX_NETMASK]).AsSlice())
+		}
+		r := route{
+			dst:   netip.PrefixFrom(dst, bits),
+			iface: ifaceName(int(m.Header.Index)),
+		}
+		if m.Header.Flags&syscall.RTF_GATEWAY != 0 {
+			r.gw = sockaddrIP(sas[syscall.RTAX_GATEWAY])
+		}
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
+
+// sockaddrIP returns the IP of an internet socket address, or the zero Addr otherwise.
+// A nil netmask sockaddr, as the kernel sends for default routes, maps to an empty mask.
+func sockaddrIP(sa syscall.Sockaddr) netip.Addr {
+	switch sa := sa.(type) {
+	case *syscall.SockaddrInet4:
+		return netip.AddrFrom4(sa.Addr)
+	case *syscall.SockaddrInet6:
+		ip := netip.AddrFrom16(sa.Addr)
+		if ip.IsLinkLocalUnicast() {
+			// KAME stack: the scope ID is embedded in the second 16-bit word.
+			b := ip.As16()
+			b[2], b[3] = 0, 0
+			ip = netip.AddrFrom16(b)
+		}
+		return ip
+	}
+	return netip.Addr{}
+}
@@ -0,0 +1,10 @@
+//go:build dragonfly || freebsd || netbsd || openbsd
+
+package localaddr
+
+import "syscall"
+
+// scopedRoute reports whether m is an interface-scoped route, which only exist on macOS.
+func scopedRoute(*syscall.RouteMessage) bool {
+	return false
+}
@@ -0,0 +1,9 @@
+package localaddr
+
+import "syscall"
+
+// scopedRoute reports whether m is an interface-scoped route. macOS installs such a
+// default route for every interface next to the unscoped one of the primary interface.
+func scopedRoute(m *syscall.RouteMessage) bool {
+	return m.Header.Flags&syscall.RTF_IFSCOPE != 0
+}
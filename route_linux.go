@@ -0,0 +1,130 @@
+package localaddr
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Route flags from <linux/route.h>.
+const (
+	rtfUp      = 0x1
+	rtfGateway = 0x2
+)
+
+// readRoutes parses the kernel routing table of the given family from procfs.
+func readRoutes(f family) ([]route, error) {
+	if f == ipv6 {
+		return readRoutes6("/proc/net/ipv6_route")
+	}
+	return readRoutes4("/proc/net/route")
+}
+
+// readRoutes4 parses /proc/net/route, whose addresses are hex encoded in host byte order:
+//
+//	Iface Destination Gateway Flags RefCnt Use Metric Mask MTU Window IRTT
+func readRoutes4(name string) ([]route, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var routes []route
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // header line
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			continue
+		}
+		flags, _ := strconv.ParseUint(fields[3], 16, 32)
+		if flags&rtfUp == 0 {
+			continue
+		}
+		dst, err1 := parseHex4(fields[1])
+		gw, err2 := parseHex4(fields[2])
+		mask, err3 := parseHex4(fields[7])
+		if err1 != nil || err2 != nil || err3 != nil {
+			return nil, fmt.Errorf("malformed line in %s: %q", name, scanner.Text())
+		}
+		metric, _ := strconv.Atoi(fields[6])
+		r := route{
+			dst:    netip.PrefixFrom(dst, maskBits(mask.AsSlice())),
+			iface:  fields[0],
+			metric: metric,
+		}
+		if flags&rtfGateway != 0 {
+			r.gw = gw
+		}
+		routes = append(routes, r)
+	}
+	return routes, scanner.Err()
+}
+
+// readRoutes6 parses /proc/net/ipv6_route, whose addresses are hex encoded in network order:
+//
+//	dst dst_len src src_len gateway metric refcnt use flags iface
+func readRoutes6(name string) ([]route, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var routes []route
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		flags, _ := strconv.ParseUint(fields[8], 16, 32)
+		if flags&rtfUp == 0 || fields[9] == "lo" {
+			continue // also skips the unreachable routes of the loopback device
+		}
+		dst, err1 := parseHex6(fields[0])
+		bits, err2 := strconv.ParseUint(fields[1], 16, 8)
+		gw, err3 := parseHex6(fields[4])
+		metric, err4 := strconv.ParseUint(fields[5], 16, 32)
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+			return nil, fmt.Errorf("malformed line in %s: %q", name, scanner.Text())
+		}
+		r := route{
+			dst:    netip.PrefixFrom(dst, int(bits)),
+			iface:  fields[9],
+			metric: int(metric),
+		}
+		if flags&rtfGateway != 0 {
+			r.gw = gw
+		}
+		routes = append(routes, r)
+	}
+	return routes, scanner.Err()
+}
+
+// parseHex4 decodes an IPv4 address printed as a host byte order hex number.
+func parseHex4(s string) (netip.Addr, error) {
+	n, err := strconv.ParseUint(s, 16, 32)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	var b [4]byte
+	binary.NativeEndian.PutUint32(b[:], uint32(n))
+	return netip.AddrFrom4(b), nil
+}
+
+// parseHex6 decodes an IPv6 address printed as 32 hex digits.
+func parseHex6(s string) (netip.Addr, error) {
+	var b [16]byte
+	if len(s) != 32 {
+		return netip.Addr{}, fmt.Errorf("invalid address %q", s)
+	}
+	if _, err := hex.Decode(b[:], []byte(s)); err != nil {
+		return netip.Addr{}, err
+	}
+	return netip.AddrFrom16(b), nil
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !windows && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package localaddr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// readRoutes is not implemented on this platform.
+func readRoutes(family) ([]route, error) {
+	return nil, fmt.Errorf("reading the routing table: %w", errors.ErrUnsupported)
+}
@@ -0,0 +1,140 @@
+package localaddr
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net/netip"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	iphlpapi               = syscall.NewLazyDLL("iphlpapi.dll")
+	procGetIpForwardTable  = iphlpapi.NewProc("GetIpForwardTable")
+	procGetIpForwardTable2 = iphlpapi.NewProc("GetIpForwardTable2")
+	procFreeMibTable       = iphlpapi.NewProc("FreeMibTable")
+)
+
+// mibIPForwardRow mirrors MIB_IPFORWARDROW. Addresses are in network byte order.
+type mibIPForwardRow struct {
+	Dest      uint32
+	Mask      uint32
+	Policy    uint32
+	NextHop   uint32
+	IfIndex   uint32
+	Type      uint32
+	Proto     uint32
+	Age       uint32
+	NextHopAS uint32
+	Metric1   uint32
+	Metric2   uint32
+	Metric3   uint32
+	Metric4   uint32
+	Metric5   uint32
+}
+
+// forwardTypeIndirect is the MIB_IPFORWARD_TYPE of routes through a gateway.
+const forwardTypeIndirect = 4
+
+// readRoutes reads the IPv4 routing table with GetIpForwardTable, and the IPv6 one with
+// GetIpForwardTable2.
+func readRoutes(f family) ([]route, error) {
+	if f == ipv6 {
+		return readRoutes6()
+	}
+	var size uint32
+	buf := make([]byte, 4096)
+	for {
+		size = uint32(len(buf))
+		r, _, _ := procGetIpForwardTable.Call(uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&size)), 1)
+		if r == 0 {
+			break
+		}
+		if syscall.Errno(r) != syscall.ERROR_INSUFFICIENT_BUFFER {
+			return nil, fmt.Errorf("GetIpForwardTable: %w", syscall.Errno(r))
+		}
+		buf = make([]byte, size)
+	}
+	n := binary.LittleEndian.Uint32(buf)
+	rowSize := unsafe.Sizeof(mibIPForwardRow{})
+	routes := make([]route, 0, n)
+	for i := uintptr(0); i < uintptr(n); i++ {
+		row := (*mibIPForwardRow)(unsafe.Pointer(&buf[4+i*rowSize]))
+		r := route{
+			dst:    netip.PrefixFrom(addr4(row.Dest), maskBits(addr4(row.Mask).AsSlice())),
+			iface:  ifaceName(int(row.IfIndex)),
+			metric: int(row.Metric1),
+		}
+		if row.Type == forwardTypeIndirect {
+			r.gw = addr4(row.NextHop)
+		}
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
+
+// addr4 converts a DWORD holding an IPv4 address in network byte order.
+func addr4(v uint32) netip.Addr {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v) // undo the little-endian load of network order bytes
+	return netip.AddrFrom4(b)
+}
+
+// Layout of MIB_IPFORWARD_TABLE2 and its MIB_IPFORWARD_ROW2 entries on 64-bit and 32-bit
+// Windows alike: the rows start 8 bytes in, after NumEntries and padding for the NET_LUID.
+const (
+	forwardRow2Size    = 104
+	forwardRow2Index   = 8  // InterfaceIndex
+	forwardRow2Dest    = 12 // DestinationPrefix.Prefix, a SOCKADDR_INET
+	forwardRow2Bits    = 40 // DestinationPrefix.PrefixLength
+	forwardRow2NextHop = 44 // NextHop, a SOCKADDR_INET
+	forwardRow2Metric  = 84
+	sockaddrIn6Addr    = 8 // sin6_addr in SOCKADDR_IN6
+)
+
+// readRoutes6 reads the IPv6 routing table with GetIpForwardTable2. Its metrics are only
+// the route's part; the interface metric is added, as GetIpForwardTable has it added for
+// IPv4 and the system does when choosing a route.
+func readRoutes6() ([]route, error) {
+	var table unsafe.Pointer
+	r, _, _ := procGetIpForwardTable2.Call(uintptr(syscall.AF_INET6), uintptr(unsafe.Pointer(&table)))
+	if r != 0 {
+		return nil, fmt.Errorf("GetIpForwardTable2: %w", syscall.Errno(r))
+	}
+	defer procFreeMibTable.Call(uintptr(table))
+	n := *(*uint32)(table)
+	rows := unsafe.Slice((*byte)(unsafe.Add(table, 8)), uintptr(n)*forwardRow2Size)
+	metrics := interfaceMetrics6()
+	routes := make([]route, 0, n)
+	for i := 0; i < int(n); i++ {
+		row := rows[i*forwardRow2Size : (i+1)*forwardRow2Size]
+		dst := netip.AddrFrom16([16]byte(row[forwardRow2Dest+sockaddrIn6Addr:]))
+		index := binary.LittleEndian.Uint32(row[forwardRow2Index:])
+		r := route{
+			dst:    netip.PrefixFrom(dst, int(row[forwardRow2Bits])),
+			iface:  ifaceName(int(index)),
+			metric: int(binary.LittleEndian.Uint32(row[forwardRow2Metric:]) + metrics[index]),
+		}
+		if gw := netip.AddrFrom16([16]byte(row[forwardRow2NextHop+sockaddrIn6Addr:])); !gw.IsUnspecified() {
+			r.gw = gw
+		}
+		routes = append(routes, r)
+	}
+	return routes, nil
+}
+
+// interfaceMetrics6 returns the IPv6 interface metrics by interface index, or nil if the
+// adapters cannot be listed.
+func interfaceMetrics6() map[uint32]uint32 {
+	list, err := adapters(windows.GAA_FLAG_SKIP_UNICAST | windows.GAA_FLAG_SKIP_ANYCAST | windows.GAA_FLAG_SKIP_MULTICAST | windows.GAA_FLAG_SKIP_DNS_SERVER)
+	if err != nil {
+		return nil
+	}
+	metrics := make(map[uint32]uint32)
+	for aa := list; aa != nil; aa = aa.Next {
+		metrics[aa.Ipv6IfIndex] = aa.Ipv6Metric
+	}
+	return metrics
+}
@@ -0,0 +1,147 @@
+package localaddr
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"sync"
+)
+
+// WithRebind makes ServeLAN move to the new address whenever the local address changes,
+// as a Watcher reports it, instead of staying on the one it started with. notify, if not
+// nil, is called with the new URL, or with the error if listening on the new address
+// failed, in which case the server keeps its previous listener.
+func WithRebind(notify func(url string, err error)) Option {
+	return func(c *config) {
+		c.rebind = true
+		c.onRebind = notify
+	}
+}
+
+// LANServer is an HTTP server started by ServeLAN.
+type LANServer struct {
+	srv  *http.Server
+	stop func() bool // cancels closing the server when the ServeLAN context is done
+	done context.CancelFunc
+
+	mu  sync.Mutex
+	l   net.Listener
+	url string
+}
+
+// ServeLAN serves handler on port of the local address, so that other machines on the
+// network can open it, e.g. a development server shared with a phone. It waits for an
+// address as Listen does, starts serving in the background, and returns once the server
+// accepts connections. A port of 0 picks a free one, which is then kept when rebinding.
+//
+// The server is closed when ctx is done or one of its Close and Shutdown methods is
+// called. With WithRebind it follows the address when it changes; connections made to the
+// previous address are served until they are closed.
+//
+// Returns:
+//   - *LANServer: The running server, whose URL is e.g. "http://192.168.1.42:8080/"
+//   - error: An error if the selection fails for good, ctx is done first, or the port
+//     cannot be bound
+func ServeLAN(ctx context.Context, handler http.Handler, port int, opts ...Option) (*LANServer, error) {
+	cfg := newConfig(ipv4, opts)
+	if cfg.err != nil {
+		return nil, cfg.err
+	}
+	l, err := ListenContext(ctx, "tcp", port, opts...)
+	if err != nil {
+		return nil, err
+	}
+	s := &LANServer{srv: &http.Server{Handler: handler}}
+	s.serve(l)
+	s.stop = context.AfterFunc(ctx, func() { s.srv.Close() })
+	if cfg.rebind {
+		var watchCtx context.Context
+		watchCtx, s.done = context.WithCancel(ctx)
+		events, err := NewWatcher(opts...).Watch(watchCtx)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		port = l.Addr().(*net.TCPAddr).Port
+		go s.rebind(watchCtx, events, port, opts, cfg.onRebind)
+	}
+	return s, nil
+}
+
+// URL returns the address the server currently listens on as an http URL with the path
+// "/", e.g. "http://192.168.1.42:8080/" or "http://[fe80::1%25eth0]:8080/".
+func (s *LANServer) URL() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.url
+}
+
+// Addr returns the address the server currently listens on.
+func (s *LANServer) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.l.Addr()
+}
+
+// Close closes the server and its connections right away, as http.Server.Close does.
+func (s *LANServer) Close() error {
+	s.halt()
+	return s.srv.Close()
+}
+
+// Shutdown stops the server gracefully, as http.Server.Shutdown does.
+func (s *LANServer) Shutdown(ctx context.Context) error {
+	s.halt()
+	return s.srv.Shutdown(ctx)
+}
+
+// halt stops following the address and the context ServeLAN was given.
+func (s *LANServer) halt() {
+	if s.stop != nil {
+		s.stop()
+	}
+	if s.done != nil {
+		s.done()
+	}
+}
+
+// serve starts serving on l and closes the previous listener.
+func (s *LANServer) serve(l net.Listener) {
+	s.mu.Lock()
+	old := s.l
+	s.l, s.url = l, httpURL(l.Addr().(*net.TCPAddr).AddrPort())
+	s.mu.Unlock()
+	go s.srv.Serve(l) // returns when l or the server is closed
+	if old != nil {
+		old.Close()
+	}
+}
+
+// rebind listens on every new address reported by events until the channel is closed.
+func (s *LANServer) rebind(ctx context.Context, events <-chan Event, port int, opts []Option, notify func(string, error)) {
+	for ev := range events {
+		if !ev.New.IsValid() {
+			continue // keep the old listener until there is a new address
+		}
+		l, err := ListenContext(ctx, "tcp", port, opts...)
+		if ctx.Err() != nil {
+			if l != nil {
+				l.Close()
+			}
+			return
+		}
+		if err == nil {
+			s.serve(l)
+		}
+		if notify != nil {
+			notify(s.URL(), err)
+		}
+	}
+}
+
+// httpURL returns the http URL of ap with the path "/".
+func httpURL(ap netip.AddrPort) string {
+	u, _ := buildURL("http", ap.Addr(), int(ap.Port()), "/") // cannot fail
+	return u
+}
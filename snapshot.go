@@ -0,0 +1,126 @@
+package localaddr
+
+import (
+	"net"
+	"net/netip"
+	"slices"
+)
+
+// link is an interface together with its addresses, parsed once per walk.
+type link struct {
+	iface net.Interface
+	addrs []netip.Prefix
+	flags []addrFlags // flags of addrs[i], nil if no address has any
+	err   error       // reading the addresses failed; addrs is empty then
+}
+
+// addrFlags are properties of an address that the platform reports besides its value.
+type addrFlags uint8
+
+const (
+	addrTemporary addrFlags = 1 << iota // IPv6 privacy extension address (RFC 8981)
+	addrSecondary                       // IPv4 alias in the subnet of another address
+	addrStatic                          // no lifetime, see lifetimeFlags
+	addrLeased                          // a lifetime of an hour or more
+	addrExpiring                        // a lifetime of less than an hour
+)
+
+// flagsOf returns the flags of l.addrs[i].
+func (l *link) flagsOf(i int) addrFlags {
+	if l.flags == nil {
+		return 0
+	}
+	return l.flags[i]
+}
+
+// add appends an address with its flags to l. An IPv4 address in the subnet of one added
+// before, with the same prefix length, is a secondary one, as Linux marks them.
+func (l *link) add(prefix netip.Prefix, flags addrFlags) {
+	if prefix.Addr().Is4() && slices.ContainsFunc(l.addrs, func(p netip.Prefix) bool {
+		return p.Addr().Is4() && p.Bits() == prefix.Bits() && p.Contains(prefix.Addr())
+	}) {
+		flags |= addrSecondary
+	}
+	if flags != 0 && l.flags == nil {
+		l.flags = make([]addrFlags, len(l.addrs), cap(l.addrs))
+	}
+	l.addrs = append(l.addrs, prefix)
+	if l.flags != nil {
+		l.flags = append(l.flags, flags)
+	}
+}
+
+// walk enumerates the interfaces and parses their addresses into netip values. It is the
+// only place that asks the operating system about addresses; selection then works on its
+// result without further system calls or allocations per address.
+//
+// An interface whose addresses cannot be read does not fail the walk; its link records
+// the error instead.
+func walk() ([]link, error) {
+	if noInterfaces {
+		return nil, ErrUnsupportedPlatform
+	}
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	links := make([]link, len(interfaces))
+	for i, v := range interfaces {
+		links[i].iface = v
+	}
+	if err := interfaceAddrs(links); err != nil {
+		return nil, err
+	}
+	return links, nil
+}
+
+// readAddrs fills in the addresses of the up links with addrs, which works like
+// net.Interface.Addrs.
+func readAddrs(links []link, addrs func(net.Interface) ([]net.Addr, error)) {
+	for i := range links {
+		l := &links[i]
+		if l.iface.Flags&net.FlagUp == 0 {
+			continue // addresses of down interfaces are never used
+		}
+		list, err := addrs(l.iface)
+		if err != nil {
+			l.err = err // e.g. a VPN adapter being torn down; keep going
+			continue
+		}
+		l.addrs = make([]netip.Prefix, 0, len(list))
+		for _, addr := range list {
+			if prefix, ok := parseAddr(addr); ok {
+				l.add(prefix, 0)
+			}
+		}
+	}
+}
+
+// parseAddr converts an address as returned by net.Interface.Addrs into a prefix that
+// keeps the host bits, e.g. 192.168.1.42/24.
+func parseAddr(addr net.Addr) (netip.Prefix, bool) {
+	var ip netip.Addr
+	bits := -1
+	switch v := addr.(type) {
+	case *net.IPNet:
+		ip, _ = netip.AddrFromSlice(v.IP)
+		ones, size := v.Mask.Size()
+		if size == 0 {
+			break // non-canonical mask
+		}
+		bits = ones
+		if ip.Is4In6() && size == 128 {
+			bits -= 96
+		}
+	case *net.IPAddr:
+		ip, _ = netip.AddrFromSlice(v.IP)
+	}
+	ip = ip.Unmap()
+	if !ip.IsValid() {
+		return netip.Prefix{}, false
+	}
+	if bits < 0 || bits > ip.BitLen() {
+		bits = ip.BitLen() // no usable mask, assume a host address
+	}
+	return netip.PrefixFrom(ip, bits), true
+}
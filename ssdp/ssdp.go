@@ -0,0 +1,130 @@
+// Package ssdp finds UPnP devices on the local network, such as routers, TVs, and
+// network storage, with the Simple Service Discovery Protocol of the UPnP Device
+// Architecture.
+//
+// Searches are sent from the local address that localaddr.Get selects, so that on a
+// machine with several networks they reach the LAN rather than, say, a VPN.
+package ssdp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/golovingreg/localaddr"
+	"github.com/golovingreg/localaddr/internal/mcast"
+)
+
+// Search targets.
+const (
+	All        = "ssdp:all"        // every device and service
+	RootDevice = "upnp:rootdevice" // every device, once
+	// InternetGateway finds routers that let programs query and configure them (UPnP IGD).
+	InternetGateway = "urn:schemas-upnp-org:device:InternetGatewayDevice:1"
+)
+
+// DefaultWait is how long Search collects answers if ctx has no deadline.
+const DefaultWait = 3 * time.Second
+
+// SSDP groups and port.
+var (
+	groupIPv4 = netip.MustParseAddrPort("239.255.255.250:1900")
+	groupIPv6 = netip.MustParseAddrPort("[ff02::c]:1900") // link-local scope
+)
+
+// Device is an answer to a search.
+type Device struct {
+	Location string     // URL of the device description, e.g. "http://192.168.1.1:5000/rootDesc.xml"
+	Type     string     // the search target that matched (ST), e.g. InternetGateway
+	USN      string     // unique service name, "uuid:..." followed by the type
+	Server   string     // operating system and UPnP version of the device, as it reports them
+	Addr     netip.Addr // address the answer came from
+}
+
+// Search multicasts a search for target on the LAN, e.g. All or InternetGateway, and
+// returns the devices that answer before ctx is done, or within DefaultWait if ctx has
+// no deadline. The search goes out on the interface that
+// localaddr.GetMulticastInterface(opts...) selects; WithIPv6 searches the IPv6
+// link-local group instead.
+//
+// Each device is returned once per USN, in the order the answers arrived. No answers
+// is not an error: many networks have no UPnP devices, or block multicast.
+//
+// Returns:
+//   - []Device: The devices found
+//   - error: An error if no local address is selected or the search cannot be sent
+func Search(ctx context.Context, target string, opts ...localaddr.Option) ([]Device, error) {
+	iface, ip, err := localaddr.GetMulticastInterface(opts...)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := mcast.Sender(iface, ip)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, DefaultWait)
+		defer cancel()
+	}
+	stop := context.AfterFunc(ctx, func() { conn.SetReadDeadline(time.Now()) })
+	defer stop()
+
+	group := groupIPv4
+	if ip.Is6() {
+		group = groupIPv6
+	}
+	deadline, _ := ctx.Deadline()
+	mx := min(max(int(time.Until(deadline)/time.Second)-1, 1), 5) // devices answer within MX seconds
+	req := fmt.Sprintf("M-SEARCH * HTTP/1.1\r\nHOST: %s\r\nMAN: \"ssdp:discover\"\r\nMX: %d\r\nST: %s\r\n\r\n", group, mx, target)
+	for range 2 { // UDP may drop one, the UDA recommends sending more than once
+		if _, err := conn.WriteToUDP([]byte(req), mcast.Dest(iface, group)); err != nil {
+			return nil, err
+		}
+	}
+
+	var found []Device
+	seen := map[string]bool{}
+	buf := make([]byte, 9000)
+	for {
+		n, from, err := conn.ReadFromUDPAddrPort(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return found, nil
+			}
+			return found, err
+		}
+		d, ok := parseResponse(buf[:n])
+		if !ok || seen[d.USN+" "+d.Location] {
+			continue
+		}
+		seen[d.USN+" "+d.Location] = true
+		d.Addr = from.Addr().Unmap()
+		found = append(found, d)
+	}
+}
+
+// parseResponse decodes an answer to an M-SEARCH, an HTTP response over UDP.
+func parseResponse(b []byte) (Device, bool) {
+	resp, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(b)), nil)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return Device{}, false
+	}
+	resp.Body.Close()
+	d := Device{
+		Location: resp.Header.Get("Location"),
+		Type:     resp.Header.Get("ST"),
+		USN:      resp.Header.Get("USN"),
+		Server:   resp.Header.Get("Server"),
+	}
+	if d.Location == "" || !strings.HasPrefix(strings.ToLower(d.Location), "http") {
+		return Device{}, false
+	}
+	return d, true
+}
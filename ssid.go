@@ -0,0 +1,34 @@
+package localaddr
+
+import "fmt"
+
+// SSID returns the name of the Wi-Fi network that the interface of the selected address
+// is associated with, e.g. to behave differently at home and at the office. The address
+// is selected as by Get; pass WithIPv6, WithInterface, and the other options as needed.
+//
+// The SSID is read with nl80211 on Linux and with WlanQueryInterface on Windows. macOS
+// only reveals it through CoreWLAN, which cannot be used without cgo; there and on other
+// platforms SSID returns an error wrapping errors.ErrUnsupported.
+//
+// Returns:
+//   - string: The SSID (e.g., "HomeNetwork")
+//   - error: An error if no address is found, its interface is not wireless or not associated,
+//     or the SSID cannot be read
+func SSID(opts ...Option) (string, error) {
+	cfg := newConfig(ipv4, opts)
+	c, err := first(cfg)
+	if err != nil {
+		return "", err
+	}
+	if cfg.kind(&c.iface) != KindWiFi {
+		return "", fmt.Errorf("interface %q is not wireless", c.iface.Name)
+	}
+	ssid, err := readSSID(&c.iface)
+	if err != nil {
+		return "", fmt.Errorf("interface %q: %w", c.iface.Name, err)
+	}
+	if ssid == "" {
+		return "", fmt.Errorf("interface %q is not associated with a network", c.iface.Name)
+	}
+	return ssid, nil
+}
@@ -0,0 +1,124 @@
+package localaddr
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+)
+
+// Generic netlink and nl80211 constants, from <linux/genetlink.h> and <linux/nl80211.h>.
+const (
+	netlinkGeneric     = 16 // NETLINK_GENERIC
+	genlHeaderLen      = 4
+	genlIDCtrl         = 0x10
+	ctrlCmdGetFamily   = 3
+	ctrlAttrFamilyID   = 1
+	ctrlAttrFamilyName = 2
+	nl80211CmdGetIface = 5
+	nl80211AttrIfindex = 3
+	nl80211AttrSSID    = 52
+)
+
+// errNoNL80211 is returned by kernels without wireless support.
+var errNoNL80211 = errors.New("nl80211 is not available")
+
+// readSSID asks nl80211 for the interface of iface. For a station that is associated,
+// the reply carries the SSID.
+func readSSID(iface *net.Interface) (string, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, netlinkGeneric)
+	if err != nil {
+		return "", fmt.Errorf("netlink socket: %w", err)
+	}
+	defer syscall.Close(fd)
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return "", fmt.Errorf("netlink bind: %w", err)
+	}
+
+	reply, err := genlRequest(fd, genlIDCtrl, ctrlCmdGetFamily,
+		genlAttr(ctrlAttrFamilyName, []byte("nl80211\x00")))
+	if err != nil {
+		if errors.Is(err, syscall.ENOENT) {
+			return "", errNoNL80211
+		}
+		return "", err
+	}
+	id, ok := genlFind(reply, ctrlAttrFamilyID)
+	if !ok || len(id) < 2 {
+		return "", errNoNL80211
+	}
+	index := make([]byte, 4)
+	binary.NativeEndian.PutUint32(index, uint32(iface.Index))
+	reply, err = genlRequest(fd, binary.NativeEndian.Uint16(id), nl80211CmdGetIface,
+		genlAttr(nl80211AttrIfindex, index))
+	if err != nil {
+		return "", err
+	}
+	ssid, _ := genlFind(reply, nl80211AttrSSID)
+	return string(ssid), nil
+}
+
+// genlAttr encodes a netlink attribute, padded to 4 bytes.
+func genlAttr(typ uint16, value []byte) []byte {
+	b := make([]byte, 4+len(value), 4+(len(value)+3)&^3)
+	binary.NativeEndian.PutUint16(b[0:], uint16(4+len(value)))
+	binary.NativeEndian.PutUint16(b[2:], typ)
+	copy(b[4:], value)
+	return b[:cap(b)]
+}
+
+// genlRequest sends a generic netlink request and returns the attributes of the reply.
+func genlRequest(fd int, family uint16, cmd uint8, attrs []byte) ([]byte, error) {
+	msg := make([]byte, syscall.NLMSG_HDRLEN+genlHeaderLen, syscall.NLMSG_HDRLEN+genlHeaderLen+len(attrs))
+	binary.NativeEndian.PutUint16(msg[4:], family)
+	binary.NativeEndian.PutUint16(msg[6:], syscall.NLM_F_REQUEST)
+	binary.NativeEndian.PutUint32(msg[8:], 1) // sequence number
+	msg[syscall.NLMSG_HDRLEN] = cmd
+	msg[syscall.NLMSG_HDRLEN+1] = 1 // version
+	msg = append(msg, attrs...)
+	binary.NativeEndian.PutUint32(msg[0:], uint32(len(msg)))
+	if err := syscall.Sendto(fd, msg, 0, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK}); err != nil {
+		return nil, fmt.Errorf("netlink send: %w", err)
+	}
+
+	buf := make([]byte, 1<<16)
+	n, _, err := syscall.Recvfrom(fd, buf, 0)
+	if err != nil {
+		return nil, fmt.Errorf("netlink receive: %w", err)
+	}
+	msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range msgs {
+		switch m.Header.Type {
+		case syscall.NLMSG_ERROR:
+			if len(m.Data) >= 4 {
+				if errno := int32(binary.NativeEndian.Uint32(m.Data)); errno != 0 {
+					return nil, syscall.Errno(-errno)
+				}
+			}
+		case family:
+			if len(m.Data) >= genlHeaderLen {
+				return m.Data[genlHeaderLen:], nil
+			}
+		}
+	}
+	return nil, errors.New("netlink: no reply")
+}
+
+// genlFind returns the value of the first attribute of type typ in attrs.
+func genlFind(attrs []byte, typ uint16) ([]byte, bool) {
+	for len(attrs) >= 4 {
+		l := int(binary.NativeEndian.Uint16(attrs[0:]))
+		if l < 4 || l > len(attrs) {
+			break
+		}
+		if binary.NativeEndian.Uint16(attrs[2:])&0x3fff == typ { // strip NLA_F_NESTED and NLA_F_NET_BYTEORDER
+			return attrs[4:l], true
+		}
+		attrs = attrs[min((l+3)&^3, len(attrs)):]
+	}
+	return nil, false
+}
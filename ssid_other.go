@@ -0,0 +1,14 @@
+//go:build !linux && !windows
+
+package localaddr
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// readSSID is not implemented on this platform.
+func readSSID(*net.Interface) (string, error) {
+	return "", fmt.Errorf("reading the SSID: %w", errors.ErrUnsupported)
+}
@@ -0,0 +1,70 @@
+package localaddr
+
+import (
+	"net"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+var (
+	wlanapi                = syscall.NewLazyDLL("wlanapi.dll")
+	procWlanOpenHandle     = wlanapi.NewProc("WlanOpenHandle")
+	procWlanCloseHandle    = wlanapi.NewProc("WlanCloseHandle")
+	procWlanQueryInterface = wlanapi.NewProc("WlanQueryInterface")
+	procWlanFreeMemory     = wlanapi.NewProc("WlanFreeMemory")
+)
+
+const (
+	wlanClientVersion           = 2 // Windows Vista and later
+	wlanOpcodeCurrentConnection = 7 // wlan_intf_opcode_current_connection
+	errorInvalidState           = 5023
+)
+
+// wlanConnectionAttributes mirrors the start of WLAN_CONNECTION_ATTRIBUTES, up to the
+// DOT11_SSID of its association attributes.
+type wlanConnectionAttributes struct {
+	State       uint32
+	Mode        uint32
+	ProfileName [256]uint16
+	SSIDLength  uint32
+	SSID        [32]byte
+}
+
+// readSSID queries the current connection of iface from the WLAN service.
+func readSSID(iface *net.Interface) (string, error) {
+	aa, err := adapter(iface.Index)
+	if err != nil {
+		return "", err
+	}
+	guid, err := windows.GUIDFromString(windows.BytePtrToString(aa.AdapterName))
+	if err != nil {
+		return "", err
+	}
+	if err := wlanapi.Load(); err != nil {
+		return "", err // WLAN service not installed, e.g. on Windows Server
+	}
+	var version uint32
+	var handle windows.Handle
+	if r, _, _ := procWlanOpenHandle.Call(wlanClientVersion, 0, uintptr(unsafe.Pointer(&version)), uintptr(unsafe.Pointer(&handle))); r != 0 {
+		return "", syscall.Errno(r)
+	}
+	defer procWlanCloseHandle.Call(uintptr(handle), 0)
+
+	var size uint32
+	var data *wlanConnectionAttributes
+	r, _, _ := procWlanQueryInterface.Call(uintptr(handle), uintptr(unsafe.Pointer(&guid)), wlanOpcodeCurrentConnection, 0,
+		uintptr(unsafe.Pointer(&size)), uintptr(unsafe.Pointer(&data)), 0)
+	switch {
+	case r == errorInvalidState:
+		return "", nil // not connected
+	case r != 0:
+		return "", syscall.Errno(r)
+	}
+	defer procWlanFreeMemory.Call(uintptr(unsafe.Pointer(data)))
+	if size < uint32(unsafe.Sizeof(*data)) {
+		return "", nil
+	}
+	return string(data.SSID[:min(data.SSIDLength, uint32(len(data.SSID)))]), nil
+}
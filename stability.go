@@ -0,0 +1,92 @@
+package localaddr
+
+import (
+	"fmt"
+	"time"
+)
+
+// Stability tells how likely an address is to change while a program runs, from the
+// least to the most stable.
+type Stability int
+
+const (
+	StabilityUnknown    Stability = iota // the platform does not tell
+	StabilityTemporary                   // an IPv6 privacy extension address, replaced every day or so
+	StabilityShortLease                  // leased by DHCP or router advertisements for a short time
+	StabilityLease                       // leased for hours or longer, and renewed as it runs out
+	StabilityStatic                      // configured without a lifetime
+)
+
+var stabilityNames = [...]string{
+	StabilityUnknown:    "unknown",
+	StabilityTemporary:  "temporary",
+	StabilityShortLease: "short-lease",
+	StabilityLease:      "lease",
+	StabilityStatic:     "static",
+}
+
+// String returns the lower case name of s, e.g. "lease".
+func (s Stability) String() string {
+	if s < 0 || int(s) >= len(stabilityNames) {
+		return fmt.Sprintf("Stability(%d)", int(s))
+	}
+	return stabilityNames[s]
+}
+
+// shortLease is the lease time below which a lease counts as short: a network that hands
+// out such leases, e.g. a guest Wi-Fi, reassigns addresses soon after a client leaves.
+// Lifetimes that the kernel counts down are renewed at half of the lease time, so for
+// them half of it is the limit.
+const shortLease = 2 * time.Hour
+
+// WithPreferStable ranks addresses by their Stability, so that long-running services
+// prefer a static address to a leased one, and a leased one to a temporary one, where an
+// interface has several. It weighs less than the criteria that choose between interfaces,
+// see Rank.
+//
+// The lifetimes of addresses are known on Linux and Windows. On Linux, IPv4 addresses of
+// DHCP clients that do not tell the kernel the lease time are found by their lease file,
+// see DHCPLease; elsewhere all addresses are StabilityUnknown and the option has no
+// effect.
+func WithPreferStable() Option {
+	return func(c *config) {
+		c.preferStable = true
+	}
+}
+
+// lifetimeFlags returns the flags of an address with the given remaining valid lifetime,
+// as the platform reports it, or with none if infinite.
+func lifetimeFlags(valid time.Duration, infinite bool) addrFlags {
+	switch {
+	case infinite:
+		return addrStatic
+	case valid < shortLease/2:
+		return addrExpiring
+	}
+	return addrLeased
+}
+
+// stability returns the Stability of c. For an IPv4 address without a lifetime it looks
+// for a DHCP lease of it, since some clients, such as dhclient, leave the lifetime to
+// the kernel's default.
+func (cfg *config) stability(c *candidate) Stability {
+	switch f := c.flags; {
+	case f&addrTemporary != 0:
+		return StabilityTemporary
+	case f&addrExpiring != 0:
+		return StabilityShortLease
+	case f&addrLeased != 0:
+		return StabilityLease
+	case f&addrStatic == 0:
+		return StabilityUnknown
+	}
+	if c.ip.Is4() && cfg.provider == nil {
+		if lease, err := readLease(c.iface.Name, c.iface.Index, c.ip.WithZone("")); err == nil {
+			if lease.Duration > 0 && lease.Duration < shortLease {
+				return StabilityShortLease
+			}
+			return StabilityLease
+		}
+	}
+	return StabilityStatic
+}
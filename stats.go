@@ -0,0 +1,36 @@
+package localaddr
+
+// InterfaceStats are the traffic counters of an interface since it was brought up or
+// the system started. Counters a platform does not keep are zero.
+type InterfaceStats struct {
+	Interface string
+	RxBytes   uint64
+	TxBytes   uint64
+	RxPackets uint64
+	TxPackets uint64
+	RxErrors  uint64
+	TxErrors  uint64
+	RxDropped uint64
+	TxDropped uint64 // not kept on macOS and the BSDs
+}
+
+// Stats returns the traffic counters of the interface of the selected address, which is
+// chosen as by Get; with WithInterface, those of that interface.
+//
+// The counters are read from /sys/class/net on Linux, from the interface list of a route
+// socket on macOS and the BSDs, and with GetIfEntry2Ex on Windows. On macOS they are
+// 32 bits wide and wrap around after 4 GiB. Other platforms return an error wrapping
+// errors.ErrUnsupported.
+//
+// Returns:
+//   - InterfaceStats: The counters (e.g., 1.2 GB received on "eth0")
+//   - error: An error if no address is selected or the counters cannot be read
+func Stats(opts ...Option) (InterfaceStats, error) {
+	c, err := first(newConfig(ipv4, opts))
+	if err != nil {
+		return InterfaceStats{}, err
+	}
+	stats, err := readStats(c.iface.Name, c.iface.Index)
+	stats.Interface = c.iface.Name
+	return stats, err
+}
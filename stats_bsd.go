@@ -0,0 +1,52 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package localaddr
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// readStats reads the counters from the interface message of a route socket dump.
+func readStats(name string, index int) (InterfaceStats, error) {
+	d, err := ifData(name, index)
+	if err != nil {
+		return InterfaceStats{}, err
+	}
+	return InterfaceStats{
+		RxBytes:   uint64(d.Ibytes),
+		TxBytes:   uint64(d.Obytes),
+		RxPackets: uint64(d.Ipackets),
+		TxPackets: uint64(d.Opackets),
+		RxErrors:  uint64(d.Ierrors),
+		TxErrors:  uint64(d.Oerrors),
+		RxDropped: uint64(d.Iqdrops),
+	}, nil
+}
+
+// readSpeed returns the baud rate the interface reports, zero if it reports none.
+func readSpeed(name string, index int) uint64 {
+	d, err := ifData(name, index)
+	if err != nil {
+		return 0
+	}
+	return uint64(d.Baudrate)
+}
+
+// ifData returns the if_data of the interface from a route socket dump.
+func ifData(name string, index int) (*syscall.IfData, error) {
+	rib, err := syscall.RouteRIB(syscall.NET_RT_IFLIST, index)
+	if err != nil {
+		return nil, err
+	}
+	msgs, err := syscall.ParseRoutingMessage(rib)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range msgs {
+		if im, ok := m.(*syscall.InterfaceMessage); ok && int(im.Header.Index) == index {
+			return &im.Header.Data, nil
+		}
+	}
+	return nil, fmt.Errorf("no statistics for interface %q", name)
+}
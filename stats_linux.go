@@ -0,0 +1,50 @@
+package localaddr
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// readStats reads the counters of /sys/class/net/<name>/statistics.
+func readStats(name string, _ int) (InterfaceStats, error) {
+	var s InterfaceStats
+	dir := filepath.Join("/sys/class/net", name, "statistics")
+	for _, c := range []struct {
+		file string
+		v    *uint64
+	}{
+		{"rx_bytes", &s.RxBytes},
+		{"tx_bytes", &s.TxBytes},
+		{"rx_packets", &s.RxPackets},
+		{"tx_packets", &s.TxPackets},
+		{"rx_errors", &s.RxErrors},
+		{"tx_errors", &s.TxErrors},
+		{"rx_dropped", &s.RxDropped},
+		{"tx_dropped", &s.TxDropped},
+	} {
+		b, err := os.ReadFile(filepath.Join(dir, c.file))
+		if err != nil {
+			return InterfaceStats{}, err
+		}
+		if *c.v, err = strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64); err != nil {
+			return InterfaceStats{}, err
+		}
+	}
+	return s, nil
+}
+
+// readSpeed reads /sys/class/net/<name>/speed, which is in Mbit/s, and fails or reads -1
+// for interfaces without a link or a known speed.
+func readSpeed(name string, _ int) uint64 {
+	b, err := os.ReadFile(filepath.Join("/sys/class/net", name, "speed"))
+	if err != nil {
+		return 0
+	}
+	mbits, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+	if err != nil || mbits <= 0 {
+		return 0
+	}
+	return uint64(mbits) * 1_000_000
+}
@@ -0,0 +1,18 @@
+//go:build !linux && !windows && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package localaddr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// readStats is not implemented on this platform.
+func readStats(string, int) (InterfaceStats, error) {
+	return InterfaceStats{}, fmt.Errorf("reading the interface statistics: %w", errors.ErrUnsupported)
+}
+
+// readSpeed is not implemented on this platform.
+func readSpeed(string, int) uint64 {
+	return 0
+}
@@ -0,0 +1,35 @@
+package localaddr
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// readStats reads the counters of the MIB_IF_ROW2 of the interface.
+func readStats(_ string, index int) (InterfaceStats, error) {
+	row := windows.MibIfRow2{InterfaceIndex: uint32(index)}
+	if err := windows.GetIfEntry2Ex(windows.MibIfEntryNormal, &row); err != nil {
+		return InterfaceStats{}, err
+	}
+	return InterfaceStats{
+		RxBytes:   row.InOctets,
+		TxBytes:   row.OutOctets,
+		RxPackets: row.InUcastPkts + row.InNUcastPkts,
+		TxPackets: row.OutUcastPkts + row.OutNUcastPkts,
+		RxErrors:  row.InErrors,
+		TxErrors:  row.OutErrors,
+		RxDropped: row.InDiscards,
+		TxDropped: row.OutDiscards,
+	}, nil
+}
+
+// readSpeed returns the transmit link speed of the MIB_IF_ROW2 of the interface.
+func readSpeed(_ string, index int) uint64 {
+	row := windows.MibIfRow2{InterfaceIndex: uint32(index)}
+	if err := windows.GetIfEntry2Ex(windows.MibIfEntryNormalWithoutStatistics, &row); err != nil {
+		return 0
+	}
+	if row.TransmitLinkSpeed == ^uint64(0) {
+		return 0 // unknown
+	}
+	return row.TransmitLinkSpeed
+}
@@ -0,0 +1,147 @@
+package localaddr
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Choice is what a Store remembers: the address last selected of each family, with the
+// interface it was on. Only IP, Prefix, Interface, and Index of the addresses are set.
+type Choice struct {
+	IPv4 Address // the zero Address if none was selected yet
+	IPv6 Address
+}
+
+// Store keeps the Choice of WithSticky across restarts, e.g. in a file (see FileStore)
+// or in the configuration store of an application. It must be safe for concurrent use
+// if lookups with it run concurrently.
+type Store interface {
+	// Load returns the stored Choice, or the zero Choice if there is none. An error
+	// wrapping fs.ErrNotExist counts as none.
+	Load() (Choice, error)
+	// Save replaces the stored Choice.
+	Save(Choice) error
+}
+
+// WithSticky makes the selection remember its result in s, and prefer it on later calls,
+// also after a restart, as long as it is still a candidate: on machines with several
+// interfaces that rank alike, the address then does not move from one interface to
+// another when the order of the interfaces changes, e.g. after a reboot. If the address
+// is gone but its interface still has a candidate, e.g. after a new DHCP lease, the
+// interface is kept.
+//
+// The remembered address only wins over addresses that rank alike by
+// WithPreferredSubnet; all other options still decide which addresses are candidates.
+// It applies to the lookups of a single family, such as Get, GetIPv6, and Rank, which
+// lists it first; GetAll and the others without a family leave it alone. Errors of s
+// do not fail the lookup; they are logged with WithLogger.
+func WithSticky(s Store) Option {
+	return func(c *config) {
+		c.store = s
+	}
+}
+
+// FileStore returns a Store that keeps the Choice as JSON in the file at path, e.g. in
+// the directory of os.UserCacheDir or in /var/lib/<program>. The directory is created
+// when the Choice is first saved, and the file is replaced atomically.
+func FileStore(path string) Store {
+	return fileStore(path)
+}
+
+type fileStore string
+
+func (f fileStore) Load() (Choice, error) {
+	data, err := os.ReadFile(string(f))
+	if err != nil {
+		return Choice{}, err
+	}
+	var c Choice
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Choice{}, fmt.Errorf("reading %s: %w", f, err)
+	}
+	return c, nil
+}
+
+func (f fileStore) Save(c Choice) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(string(f))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(string(f))+".*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(append(data, '\n'))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), string(f))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// stick moves the remembered candidate of cfg.store to the front of found, which is
+// sorted, and remembers the one in front if it changed.
+func (cfg *config) stick(found []candidate) {
+	choice, err := cfg.store.Load()
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		cfg.trace("remembered address not loaded", "error", err)
+		choice = Choice{} // still save the new one over a damaged store
+	}
+	prev := &choice.IPv4
+	if cfg.family == ipv6 {
+		prev = &choice.IPv6
+	}
+	if i := cfg.remembered(found, prev); i > 0 {
+		c := found[i]
+		copy(found[1:i+1], found[:i])
+		found[0] = c
+		cfg.trace("kept remembered address", "address", c.ip, "interface", c.iface.Name)
+	}
+	best := &found[0]
+	if best.ip == prev.IP && best.iface.Name == prev.Interface {
+		return
+	}
+	*prev = Address{IP: best.ip, Prefix: best.prefix, Interface: best.iface.Name, Index: best.iface.Index}
+	if err := cfg.store.Save(choice); err != nil {
+		cfg.trace("remembered address not saved", "error", err)
+	}
+}
+
+// remembered returns the index of the candidate that prev was selected as, or else of
+// the best one on its interface, or -1 if there is none or it ranks below found[0] by
+// WithPreferredSubnet.
+func (cfg *config) remembered(found []candidate, prev *Address) int {
+	if prev.Interface == "" {
+		return -1
+	}
+	i := -1
+	for j := range found {
+		if found[j].iface.Name != prev.Interface {
+			continue
+		}
+		if found[j].ip == prev.IP {
+			i = j
+			break
+		}
+		if i < 0 {
+			i = j
+		}
+	}
+	if i < 0 || cfg.rank(found[i].ip) != cfg.rank(found[0].ip) {
+		return -1
+	}
+	return i
+}
@@ -0,0 +1,170 @@
+package localaddr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+)
+
+// Strategy is one way of finding the local address, e.g. scanning the interfaces or
+// asking a cloud metadata endpoint. The strategies of this package cover the built-in
+// ways; custom ones, such as a lookup in an inventory service, implement the interface or
+// use StrategyFunc. A Resolver, built from a Config, is a Strategy as well.
+type Strategy interface {
+	// Resolve returns the local address, or an error if the strategy cannot tell.
+	// Details that the strategy does not know, e.g. the interface of an address read
+	// from the environment, are left empty.
+	Resolve(ctx context.Context) (Address, error)
+}
+
+// StrategyFunc adapts a function to the Strategy interface.
+type StrategyFunc func(ctx context.Context) (Address, error)
+
+// Resolve calls f(ctx).
+func (f StrategyFunc) Resolve(ctx context.Context) (Address, error) { return f(ctx) }
+
+// ScanStrategy returns the strategy of Get: scanning the interfaces and returning the
+// best ranked address, with its details as GetDetailed returns them. Options are those
+// of Get, including WithIPv6 for an IPv6 address; the scan gives up once ctx is done, as
+// GetContext does.
+func ScanStrategy(opts ...Option) Strategy {
+	return StrategyFunc(func(ctx context.Context) (Address, error) {
+		return withContext(ctx, func() (Address, error) {
+			cfg := newConfig(ipv4, opts)
+			c, err := first(cfg)
+			if err != nil {
+				return Address{}, err
+			}
+			return cfg.address(&c), nil
+		})
+	})
+}
+
+// OutboundStrategy returns the strategy of GetOutbound: the source address of a UDP
+// socket connected to the target, bounded by ctx. The interface details are filled in if
+// one of the interfaces has the address.
+func OutboundStrategy(opts ...Option) Strategy {
+	return StrategyFunc(func(ctx context.Context) (Address, error) {
+		cfg := newConfig(ipv4, opts)
+		ip, err := outbound(ctx, cfg)
+		if err != nil {
+			return Address{}, err
+		}
+		return cfg.owner(ip), nil
+	})
+}
+
+// EnvStrategy returns the strategy of EnvOverride: the first address of the variable of
+// the family selected by opts, without interface details. It fails if the variable is
+// not set, has no address of that family, or WithIgnoreEnv is given.
+func EnvStrategy(opts ...Option) Strategy {
+	return StrategyFunc(func(context.Context) (Address, error) {
+		cfg := newConfig(ipv4, opts)
+		if cfg.err != nil {
+			return Address{}, cfg.err
+		}
+		if len(cfg.override) == 0 {
+			return Address{}, fmt.Errorf("%s is not set", EnvOverride)
+		}
+		found := cfg.overridden()
+		if len(found) == 0 {
+			return Address{}, fmt.Errorf("%s: %w", EnvOverride, cfg.notFound(true))
+		}
+		return Address{IP: found[0].ip, Prefix: found[0].prefix}, nil
+	})
+}
+
+// Resolve implements Strategy with the selection of Get for the configuration of r.
+func (r *Resolver) Resolve(ctx context.Context) (Address, error) {
+	return ScanStrategy(r.opts...).Resolve(ctx)
+}
+
+// owner returns the details of ip, found by a strategy without looking at the
+// interfaces. Only IP and Prefix, of a single address, are set if no interface has it.
+func (cfg *config) owner(ip netip.Addr) Address {
+	if links, err := cfg.walk(); err == nil {
+		for i := range links {
+			l := &links[i]
+			for _, prefix := range l.addrs {
+				if prefix.Addr() == ip.WithZone("") {
+					return cfg.address(&candidate{ip: ip, prefix: prefix, iface: l.iface})
+				}
+			}
+		}
+	}
+	return Address{IP: ip, Prefix: netip.PrefixFrom(ip.WithZone(""), ip.BitLen())}
+}
+
+// HostnameStrategy returns a strategy that resolves the machine's host name (os.Hostname)
+// with the system resolver, as WithHostnameFallback does, and returns the first address
+// of the family selected by opts that is not a loopback one. It finds the address an
+// administrator put into /etc/hosts or DNS for the machine, even where the interfaces
+// cannot be read.
+func HostnameStrategy(opts ...Option) Strategy {
+	return StrategyFunc(func(ctx context.Context) (Address, error) {
+		cfg := newConfig(ipv4, opts)
+		if cfg.err != nil {
+			return Address{}, cfg.err
+		}
+		ctx, cancel := context.WithTimeout(ctx, hostnameTimeout)
+		defer cancel()
+		addrs, err := lookupHostname(ctx)
+		if err != nil {
+			return Address{}, fmt.Errorf("resolving the host name: %w", err)
+		}
+		for _, ip := range addrs {
+			if cfg.usable(ip, cfg.family) {
+				return cfg.owner(ip), nil
+			}
+		}
+		return Address{}, fmt.Errorf("host name: %w", cfg.notFound(true))
+	})
+}
+
+// Chain returns a strategy that tries strategies in order and returns the result of the
+// first one that succeeds. If all of them fail, the error joins theirs; it stops early,
+// with ctx.Err(), once ctx is done.
+func Chain(strategies ...Strategy) Strategy {
+	return StrategyFunc(func(ctx context.Context) (Address, error) {
+		var errs []error
+		for _, s := range strategies {
+			a, err := s.Resolve(ctx)
+			if err == nil {
+				return a, nil
+			}
+			if ctx.Err() != nil {
+				return Address{}, ctx.Err()
+			}
+			errs = append(errs, err)
+		}
+		if len(errs) == 0 {
+			return Address{}, ErrNotConnected
+		}
+		return Address{}, errors.Join(errs...)
+	})
+}
+
+// DefaultChain returns the chain of strategies that finds a usable address on the most
+// machines, for callers that need something rather than "not connected":
+//
+//  1. EnvStrategy, an explicit EnvOverride
+//  2. ScanStrategy with WithDefaultRoute, the interface of the default route
+//  3. ScanStrategy, every interface
+//  4. OutboundStrategy, the source address the kernel picks, which also works where
+//     the interfaces cannot be read, e.g. in some sandboxes
+//  5. HostnameStrategy, the address of the host name
+//
+// Each strategy gets opts, e.g. WithIPv6 for an IPv6 address; restrictions such as
+// WithSubnet and the interface filters only bind the scans, as for GetOutbound. Public
+// addresses are not looked up, since that takes a remote service; append a
+// publicaddr.HTTPResolver with Chain(DefaultChain(opts...), resolver) for that.
+func DefaultChain(opts ...Option) Strategy {
+	return Chain(
+		EnvStrategy(opts...),
+		ScanStrategy(append(opts[:len(opts):len(opts)], WithDefaultRoute())...),
+		ScanStrategy(opts...),
+		OutboundStrategy(opts...),
+		HostnameStrategy(opts...),
+	)
+}
@@ -0,0 +1,280 @@
+package localaddr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golovingreg/localaddr/internal/icmp"
+)
+
+// Defaults of ScanSubnet.
+const (
+	DefaultScanConcurrency = 64
+	DefaultScanTimeout     = time.Second
+)
+
+// maxScanDials bounds the connection attempts of ScanSubnet that are in flight at the
+// same time, whatever the concurrency, so that a scan stays well below the usual limit
+// of 1024 open files.
+const maxScanDials = 256
+
+// DefaultScanPorts are the TCP ports ScanSubnet tries when none are given: remote
+// access, web servers and admin pages, file sharing, and ports that phones, printers,
+// cameras, and media players listen on.
+var DefaultScanPorts = []int{22, 80, 443, 445, 139, 8080, 8443, 554, 631, 8008, 62078}
+
+// WithScanConcurrency bounds how many hosts ScanSubnet probes at the same time, see
+// DefaultScanConcurrency.
+func WithScanConcurrency(n int) Option {
+	return func(c *config) {
+		if n <= 0 {
+			c.setErr(fmt.Errorf("invalid scan concurrency %d", n))
+			return
+		}
+		c.scanWorkers = n
+	}
+}
+
+// WithScanTimeout sets how long ScanSubnet waits for a host to answer, see
+// DefaultScanTimeout.
+func WithScanTimeout(d time.Duration) Option {
+	return func(c *config) {
+		if d <= 0 {
+			c.setErr(fmt.Errorf("invalid scan timeout %v", d))
+			return
+		}
+		c.scanTimeout = d
+	}
+}
+
+// WithScanPorts sets the TCP ports ScanSubnet connects to, see DefaultScanPorts.
+func WithScanPorts(ports ...int) Option {
+	return func(c *config) {
+		for _, p := range ports {
+			if p < 1 || p > 65535 {
+				c.setErr(fmt.Errorf("invalid scan port %d", p))
+				return
+			}
+		}
+		c.scanPorts = ports
+	}
+}
+
+// Host is a machine found by ScanSubnet.
+type Host struct {
+	IP    netip.Addr
+	MAC   net.HardwareAddr // from the neighbor table, empty if unknown
+	Probe string           // what it answered: "icmp", "tcp", or "arp" if it only answered address resolution
+	RTT   time.Duration    // round trip time of the answer, zero for "arp"
+}
+
+// ScanSubnet looks for the other hosts on the network of the selected address, which
+// is chosen as by Get. Networks larger than a /24 are narrowed to the /24 around the
+// address, so a scan takes seconds rather than hours.
+//
+// Every address is sent an ICMP echo request and connection attempts to the TCP ports of
+// WithScanPorts; a reply, an accepted connection, or a refused one all mean the host is
+// up. Hosts that answer none of them but resolved their MAC address do so too, as far as
+// Neighbors tells. WithScanConcurrency and WithScanTimeout bound the scan. ICMP needs an
+// unprivileged ICMP socket on Linux and macOS, or root; without it, only the TCP and
+// address resolution answers count. IPv6 networks are too large to sweep; use Neighbors.
+// At most 256 connection attempts are in flight at a time.
+//
+// Connection attempts that time out, or fail because no host has the address, mean
+// that the host is absent; other failures, such as running out of file descriptors,
+// are reported.
+//
+// Returns:
+//   - []Host: The hosts that answered, in address order (e.g., "192.168.1.1" by "icmp")
+//   - error: An error if no address is selected, the first connection attempt that
+//     failed for other reasons than an absent host, or ctx.Err() if it ended the scan
+//     early; the hosts found are returned with the latter two
+func ScanSubnet(ctx context.Context, opts ...Option) ([]Host, error) {
+	cfg := newConfig(ipv4, opts)
+	if cfg.family != ipv4 {
+		return nil, fmt.Errorf("scanning IPv6 networks: %w", errors.ErrUnsupported)
+	}
+	c, err := first(cfg)
+	if err != nil {
+		return nil, err
+	}
+	prefix := c.prefix.Masked()
+	if prefix.Bits() < 24 {
+		prefix = netip.PrefixFrom(c.ip, 24).Masked()
+	}
+	workers, timeout, ports := DefaultScanConcurrency, DefaultScanTimeout, DefaultScanPorts
+	if cfg.scanWorkers > 0 {
+		workers = cfg.scanWorkers
+	}
+	if cfg.scanTimeout > 0 {
+		timeout = cfg.scanTimeout
+	}
+	if cfg.scanPorts != nil {
+		ports = cfg.scanPorts
+	}
+	pinger, _ := icmp.Listen(c.ip) // nil without permission
+	if pinger != nil {
+		defer pinger.Close()
+	}
+	s := &sweep{local: c.ip, pinger: pinger, ports: ports, timeout: timeout, dials: make(chan struct{}, maxScanDials)}
+
+	var (
+		mu      sync.Mutex
+		hosts   []Host
+		scanErr error
+		wg      sync.WaitGroup
+	)
+	sem := make(chan struct{}, workers)
+	for addr := range sweepAddrs(prefix) {
+		if addr == c.ip {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			h, ok, err := s.probe(ctx, addr)
+			mu.Lock()
+			defer mu.Unlock()
+			if ok {
+				hosts = append(hosts, h)
+			} else if err != nil && scanErr == nil {
+				scanErr = err
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every probe made the system resolve the MAC address, so the neighbor table now
+	// also holds the hosts that drop everything else.
+	if neighbors, err := readNeighbors(ipv4); err == nil {
+		for _, n := range neighbors {
+			if !prefix.Contains(n.IP) || len(n.MAC) == 0 || n.Interface != c.iface.Name {
+				continue
+			}
+			i := slices.IndexFunc(hosts, func(h Host) bool { return h.IP == n.IP })
+			switch {
+			case i >= 0:
+				hosts[i].MAC = n.MAC
+			case n.State == NeighborReachable || n.State == NeighborStale || n.State == NeighborPermanent:
+				hosts = append(hosts, Host{IP: n.IP, MAC: n.MAC, Probe: "arp"})
+			}
+		}
+	}
+	slices.SortFunc(hosts, func(a, b Host) int { return a.IP.Compare(b.IP) })
+	if ctx.Err() != nil {
+		return hosts, ctx.Err()
+	}
+	if scanErr != nil {
+		return hosts, fmt.Errorf("scanning %s: %w", prefix, scanErr)
+	}
+	return hosts, nil
+}
+
+// sweepAddrs returns the host addresses of prefix: all but the network and broadcast
+// addresses, except on /31 and /32 networks, which have neither.
+func sweepAddrs(prefix netip.Prefix) func(yield func(netip.Addr) bool) {
+	return func(yield func(netip.Addr) bool) {
+		first, last := prefix.Addr(), prefix.Addr()
+		for next := last.Next(); next.IsValid() && prefix.Contains(next); next = next.Next() {
+			last = next
+		}
+		if prefix.Bits() < 31 {
+			first, last = first.Next(), last.Prev()
+		}
+		for a := first; a.IsValid() && a.Compare(last) <= 0; a = a.Next() {
+			if !yield(a) {
+				return
+			}
+		}
+	}
+}
+
+// sweep holds the settings of a ScanSubnet run.
+type sweep struct {
+	local   netip.Addr
+	pinger  *icmp.Conn // nil if ICMP is not available
+	ports   []int
+	timeout time.Duration
+	dials   chan struct{} // semaphore of the connection attempts in flight
+}
+
+// probe pings addr and connects to its ports, and reports the first answer. The ping
+// and every connection attempt wait for up to the timeout, the latter from when they
+// got a slot of dials. Without an answer, the error is that of the first connection
+// attempt that failed for other reasons than an absent host, if any.
+func (s *sweep) probe(ctx context.Context, addr netip.Addr) (Host, bool, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // stops the other probes once one answered
+	type answer struct {
+		host Host
+		err  error
+	}
+	answers := make(chan answer, 1+len(s.ports))
+	probes := 0
+	start := time.Now()
+	if s.pinger != nil {
+		probes++
+		go func() {
+			ctx, cancel := context.WithTimeout(ctx, s.timeout)
+			defer cancel()
+			if rtt, err := s.pinger.Ping(ctx, addr); err == nil {
+				answers <- answer{host: Host{IP: addr, Probe: "icmp", RTT: rtt}}
+				return
+			}
+			answers <- answer{}
+		}()
+	}
+	dialer := &net.Dialer{LocalAddr: &net.TCPAddr{IP: s.local.AsSlice()}}
+	for _, port := range s.ports {
+		probes++
+		go func() {
+			select {
+			case s.dials <- struct{}{}:
+			case <-ctx.Done():
+				answers <- answer{}
+				return
+			}
+			defer func() { <-s.dials }()
+			ctx, cancel := context.WithTimeout(ctx, s.timeout)
+			defer cancel()
+			conn, err := dialer.DialContext(ctx, "tcp4", net.JoinHostPort(addr.String(), strconv.Itoa(port)))
+			var netErr net.Error
+			switch {
+			case err == nil:
+				conn.Close()
+				fallthrough
+			case refused(err):
+				answers <- answer{host: Host{IP: addr, Probe: "tcp", RTT: time.Since(start)}}
+			case ctx.Err() != nil, unreachable(err), errors.As(err, &netErr) && netErr.Timeout():
+				answers <- answer{}
+			default:
+				answers <- answer{err: err}
+			}
+		}()
+	}
+	var firstErr error
+	for range probes {
+		a := <-answers
+		if a.host.IP.IsValid() {
+			return a.host, true, nil
+		}
+		if firstErr == nil {
+			firstErr = a.err
+		}
+	}
+	return Host{}, false, firstErr
+}
@@ -0,0 +1,29 @@
+package localaddr
+
+import (
+	"context"
+	"log/slog"
+)
+
+// WithLogger makes the selection explain itself on l at debug level: every interface
+// and address it skips and why, the score of every candidate with the criteria behind
+// it, and the address that wins. It helps find out why a machine reports the wrong
+// address; Rank gives the scores without the skipped ones.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = l
+	}
+}
+
+// tracing reports whether cfg logs the selection, so that callers can skip preparing
+// log attributes otherwise.
+func (cfg *config) tracing() bool {
+	return cfg.logger != nil && cfg.logger.Enabled(context.Background(), slog.LevelDebug)
+}
+
+// trace logs a step of the selection at debug level, if WithLogger was given.
+func (cfg *config) trace(msg string, args ...any) {
+	if cfg.tracing() {
+		cfg.logger.Debug(msg, args...)
+	}
+}
@@ -0,0 +1,245 @@
+// Package trust tells whether the machine is on one of the networks that an application
+// trusts, such as the home or the office network, so that it can behave differently
+// there than on public networks, e.g. share files only at home, or require a VPN
+// everywhere else.
+//
+// Networks are recognized by what the machine sees of them: the SSID of the Wi-Fi
+// network, the hardware address of the default gateway, and the subnet of the local
+// address. None of them proves anything: anyone can name an access point like the home
+// network, subnets such as 192.168.1.0/24 are used by many networks, and the gateway MAC,
+// which is the hardest to match by chance, can still be spoofed on purpose. Combine them
+// where it matters, and do not treat a trusted network as authentication.
+package trust
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/golovingreg/localaddr"
+)
+
+// DefaultInterval is the time between the checks of Monitor.Watch if Monitor.Interval
+// is zero. Changes of the local address are checked right away.
+const DefaultInterval = 30 * time.Second
+
+// Network is a trusted network. The machine is on it if all the criteria that are set
+// match; at least one must be.
+type Network struct {
+	Name       string           // reported in Status, e.g. "home"
+	SSID       string           // name of the Wi-Fi network, "" for any
+	GatewayMAC net.HardwareAddr // hardware address of the default gateway, nil for any
+	Subnet     netip.Prefix     // network that the local address must be in, the zero Prefix for any
+}
+
+// Status is what the machine sees of the network it is on, and whether it is trusted.
+type Status struct {
+	Trusted    bool
+	Network    string           // Name of the trusted Network, "" if not Trusted
+	Addr       netip.Addr       // local address, the zero Addr if the machine is not connected
+	Interface  string           // interface of Addr
+	SSID       string           // "" if the interface is not wireless or its SSID cannot be read
+	Gateway    netip.Addr       // default gateway of the interface, the zero Addr if it has none
+	GatewayMAC net.HardwareAddr // nil if Gateway is not in the ARP or NDP table
+}
+
+// Event is a change of the trusted network, including from and to none.
+type Event struct {
+	Old  Status
+	New  Status
+	Time time.Time // when the change was noticed
+}
+
+// Monitor keeps the trusted networks, and checks the current network against them.
+// Networks can be added and removed while Watch runs; the next check uses them.
+type Monitor struct {
+	Options  []localaddr.Option // selection of the local address, as for localaddr.Get
+	Interval time.Duration      // time between the checks of Watch, DefaultInterval if zero
+	OnError  func(error)        // called with errors of the checks of Watch, may be nil
+
+	mu       sync.Mutex
+	networks []Network // in the order of Add, which breaks ties
+}
+
+// Add registers n as trusted. A network with the name of one that is already
+// registered replaces it. If several networks match, the one added first wins.
+//
+// Returns:
+//   - error: An error if n has no name or no criteria
+func (m *Monitor) Add(n Network) error {
+	if n.Name == "" {
+		return errors.New("trust: network has no name")
+	}
+	if n.SSID == "" && len(n.GatewayMAC) == 0 && !n.Subnet.IsValid() {
+		return fmt.Errorf("trust: network %q has no criteria", n.Name)
+	}
+	n.GatewayMAC = slices.Clone(n.GatewayMAC)
+	n.Subnet = n.Subnet.Masked()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if i := slices.IndexFunc(m.networks, func(o Network) bool { return o.Name == n.Name }); i >= 0 {
+		m.networks[i] = n
+	} else {
+		m.networks = append(m.networks, n)
+	}
+	return nil
+}
+
+// Remove unregisters the network with the given name, and reports whether there was
+// one.
+func (m *Monitor) Remove(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := len(m.networks)
+	m.networks = slices.DeleteFunc(m.networks, func(o Network) bool { return o.Name == name })
+	return len(m.networks) < n
+}
+
+// Networks returns the registered networks, in the order they were added.
+func (m *Monitor) Networks() []Network {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.networks)
+}
+
+// Check returns the network the machine is on now. A machine that is not connected is on
+// no trusted network, which is not an error.
+//
+// The local address is selected with Options, which also apply to the lookups on its
+// interface. The interface tells the SSID, and the default gateway, whose hardware
+// address is looked up in the ARP table, or in the NDP table for IPv6 addresses. The
+// SSID is not read on macOS (see localaddr.SSID), where a Network with an SSID never
+// matches.
+//
+// Returns:
+//   - Status: The network (e.g., trusted as "home", on "HomeNetwork" via 192.168.1.1)
+//   - error: An error if the local address cannot be selected for other reasons than
+//     being disconnected
+func (m *Monitor) Check() (Status, error) {
+	addr, err := localaddr.GetDetailed(m.Options...)
+	if errors.Is(err, localaddr.ErrNotConnected) {
+		return Status{}, nil
+	}
+	if err != nil {
+		return Status{}, err
+	}
+	s := Status{Addr: addr.IP, Interface: addr.Interface}
+	opts := append(slices.Clone(m.Options), localaddr.WithInterface(addr.Interface))
+	if addr.IP.Is6() {
+		opts = append(opts, localaddr.WithIPv6())
+	}
+	if addr.Kind == localaddr.KindWiFi {
+		s.SSID, _ = localaddr.SSID(opts...)
+	}
+	if gw, _, err := localaddr.Gateway(opts...); err == nil {
+		s.Gateway = gw
+		s.GatewayMAC = neighborMAC(gw, opts)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, n := range m.networks {
+		if matches(&n, &s) {
+			s.Trusted, s.Network = true, n.Name
+			break
+		}
+	}
+	return s, nil
+}
+
+// Watch starts watching and returns a channel that receives an Event whenever the
+// machine moves to another trusted network, or onto or off one, e.g. when a laptop
+// joins the Wi-Fi of a café. The channel is closed once ctx is done.
+//
+// The network is checked whenever the local address changes (see localaddr.Watcher),
+// and every Interval, which catches networks that hand out the same address. The
+// current network is not sent; call Check for it. Checks that fail are reported to
+// OnError and do not change the network.
+//
+// Returns:
+//   - <-chan Event: The channel of changes
+//   - error: An error if the options are invalid or watching cannot be set up
+func (m *Monitor) Watch(ctx context.Context) (<-chan Event, error) {
+	interval := m.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	current, err := m.Check()
+	if err != nil {
+		return nil, err
+	}
+	changed, err := localaddr.NewWatcher(m.Options...).Watch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-changed:
+				if !ok {
+					return
+				}
+			case <-ticker.C:
+			}
+			next, err := m.Check()
+			if err != nil {
+				if m.OnError != nil {
+					m.OnError(err)
+				}
+				continue
+			}
+			if next.Network == current.Network {
+				current = next // e.g. a new address on the same network
+				continue
+			}
+			ev := Event{Old: current, New: next, Time: time.Now()}
+			current = next
+			select {
+			case events <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// matches reports whether s is on n.
+func matches(n *Network, s *Status) bool {
+	if !s.Addr.IsValid() {
+		return false
+	}
+	if n.SSID != "" && n.SSID != s.SSID {
+		return false
+	}
+	if len(n.GatewayMAC) > 0 && !bytes.Equal(n.GatewayMAC, s.GatewayMAC) {
+		return false
+	}
+	return !n.Subnet.IsValid() || n.Subnet.Contains(s.Addr.Unmap().WithZone(""))
+}
+
+// neighborMAC returns the hardware address of ip from the neighbor table of the
+// interface in opts, or nil if it is not listed.
+func neighborMAC(ip netip.Addr, opts []localaddr.Option) net.HardwareAddr {
+	neighbors, err := localaddr.Neighbors(opts...)
+	if err != nil {
+		return nil
+	}
+	for _, n := range neighbors {
+		if n.IP.WithZone("") == ip.WithZone("") && len(n.MAC) > 0 {
+			return n.MAC
+		}
+	}
+	return nil
+}
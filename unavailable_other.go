@@ -0,0 +1,14 @@
+//go:build !windows && !plan9
+
+package localaddr
+
+import (
+	"errors"
+	"syscall"
+)
+
+// unavailable reports whether err means that the address to bind is not, or not yet,
+// assigned to the machine, e.g. because an IPv6 address is still tentative.
+func unavailable(err error) bool {
+	return errors.Is(err, syscall.EADDRNOTAVAIL)
+}
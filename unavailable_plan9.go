@@ -0,0 +1,7 @@
+package localaddr
+
+// unavailable reports false: Plan 9 has no distinct error for binding an address that
+// is not assigned to the machine.
+func unavailable(error) bool {
+	return false
+}
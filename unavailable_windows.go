@@ -0,0 +1,13 @@
+package localaddr
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows"
+)
+
+// unavailable reports whether err means that the address to bind is not, or not yet,
+// assigned to the machine, e.g. because an IPv6 address is still tentative.
+func unavailable(err error) bool {
+	return errors.Is(err, windows.WSAEADDRNOTAVAIL)
+}
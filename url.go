@@ -0,0 +1,78 @@
+package localaddr
+
+import (
+	"fmt"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// BuildURL returns a URL pointing at port of the selected address, which is chosen as by
+// Get. IPv6 addresses are put in brackets, and link-local ones get their zone, escaped
+// as "%25" as RFC 6874 requires. A port of 0 leaves the port out, and path may carry a
+// query and a fragment; a missing leading slash is added and other characters are
+// escaped as needed, in the keys and values of the query too, whose escapes are kept.
+//
+// Returns:
+//   - string: The URL (e.g., "http://192.168.1.42:8080/metrics" or
+//     "http://[fe80::1%25eth0]:8080/metrics")
+//   - error: An error if no address is selected, or the scheme or port is invalid
+func BuildURL(scheme string, port int, path string, opts ...Option) (string, error) {
+	c, err := first(newConfig(ipv4, opts))
+	if err != nil {
+		return "", err
+	}
+	return buildURL(scheme, c.ip, port, path)
+}
+
+// buildURL implements BuildURL for the address ip.
+func buildURL(scheme string, ip netip.Addr, port int, path string) (string, error) {
+	if scheme == "" || strings.ContainsAny(scheme, ":/") {
+		return "", fmt.Errorf("invalid URL scheme %q", scheme)
+	}
+	if port < 0 || port > 65535 {
+		return "", fmt.Errorf("invalid port %d", port)
+	}
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	u := &url.URL{Scheme: scheme, Host: ip.Unmap().String()}
+	path, u.Fragment, _ = strings.Cut(path, "#")
+	var query string
+	u.Path, query, _ = strings.Cut(path, "?")
+	u.RawQuery = escapeQuery(query)
+	if ip.Is6() && !ip.Is4In6() {
+		u.Host = "[" + u.Host + "]"
+	}
+	if port != 0 {
+		u.Host += ":" + strconv.Itoa(port)
+	}
+	return u.String(), nil
+}
+
+// escapeQuery escapes the keys and values of query as needed, keeping their order and
+// the escapes that are already there, e.g. "a=b c&d=e%26f" becomes "a=b+c&d=e%26f".
+func escapeQuery(query string) string {
+	if query == "" {
+		return ""
+	}
+	pairs := strings.Split(query, "&")
+	for i, pair := range pairs {
+		key, value, hasValue := strings.Cut(pair, "=")
+		pairs[i] = escapeQueryPart(key)
+		if hasValue {
+			pairs[i] += "=" + escapeQueryPart(value)
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// escapeQueryPart escapes a key or value of a query, decoding it first unless it is not
+// validly escaped.
+func escapeQueryPart(s string) string {
+	if u, err := url.QueryUnescape(s); err == nil {
+		s = u
+	}
+	return url.QueryEscape(s)
+}
@@ -0,0 +1,46 @@
+package localaddr
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestBuildURL(t *testing.T) {
+	v4, v6 := netip.MustParseAddr("192.168.1.42"), netip.MustParseAddr("fe80::1%eth0")
+	tests := []struct {
+		name   string
+		scheme string
+		ip     netip.Addr
+		port   int
+		path   string
+		want   string // "" for an error
+	}{
+		{"plain", "http", v4, 8080, "/metrics", "http://192.168.1.42:8080/metrics"},
+		{"no port", "https", v4, 0, "", "https://192.168.1.42"},
+		{"leading slash added", "http", v4, 80, "metrics", "http://192.168.1.42:80/metrics"},
+		{"path escaped", "http", v4, 8080, "/a b", "http://192.168.1.42:8080/a%20b"},
+		{"query escaped", "http", v4, 8080, "/metrics?a=b c", "http://192.168.1.42:8080/metrics?a=b+c"},
+		{"query order kept", "http", v4, 8080, "/?z=1&a=2&flag", "http://192.168.1.42:8080/?z=1&a=2&flag"},
+		{"query escapes kept", "http", v4, 8080, "/?q=a%26b&r=c+d", "http://192.168.1.42:8080/?q=a%26b&r=c+d"},
+		{"query invalid escape", "http", v4, 8080, "/?q=100%", "http://192.168.1.42:8080/?q=100%25"},
+		{"fragment", "http", v4, 8080, "/docs?x=1#part two", "http://192.168.1.42:8080/docs?x=1#part%20two"},
+		{"link-local IPv6", "http", v6, 8080, "/", "http://[fe80::1%25eth0]:8080/"},
+		{"mapped IPv4", "http", netip.MustParseAddr("::ffff:192.168.1.42"), 0, "/", "http://192.168.1.42/"},
+		{"invalid scheme", "http://", v4, 8080, "/", ""},
+		{"invalid port", "http", v4, 70000, "/", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildURL(tt.scheme, tt.ip, tt.port, tt.path)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("buildURL() = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("buildURL() = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package localaddr
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"slices"
+	"time"
+)
+
+// verifyTimeout bounds the connection of WithVerify to each candidate.
+const verifyTimeout = 500 * time.Millisecond
+
+// WithVerify makes the getters check every candidate before returning it: a throwaway
+// TCP listener is bound to the address and connected to from the address itself, and
+// candidates where either fails are dropped. That filters out addresses the interfaces
+// still report but that no longer work, such as those left behind by half torn down VPN
+// adapters or IPv6 addresses whose duplicate address detection failed. It costs a
+// connection per candidate, so only use it where such addresses are a problem.
+//
+// If no candidate passes, the error wraps ErrNotConnected, so WaitForNetwork and
+// GetWithRetry keep waiting. Addresses of EnvOverride and of a Provider are not verified.
+func WithVerify() Option {
+	return func(c *config) {
+		c.verify = true
+	}
+}
+
+// verified drops the candidates that fail verifyAddr, and fails if none is left.
+func (cfg *config) verified(found []candidate) ([]candidate, error) {
+	var failed []error
+	found = slices.DeleteFunc(found, func(c candidate) bool {
+		err := verifyAddr(c.ip)
+		if err != nil {
+			cfg.trace("skipping address", "interface", c.iface.Name, "address", c.ip, "reason", "verification failed", "error", err)
+			failed = append(failed, err)
+		}
+		return err != nil
+	})
+	if len(found) == 0 {
+		return nil, fmt.Errorf("%w: no address passed verification: %w", ErrNotConnected, errors.Join(failed...))
+	}
+	return found, nil
+}
+
+// verifyAddr binds a listener to ip and connects to it from ip.
+func verifyAddr(ip netip.Addr) error {
+	addr := net.TCPAddrFromAddrPort(netip.AddrPortFrom(ip, 0))
+	l, err := net.ListenTCP("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	d := net.Dialer{Timeout: verifyTimeout, LocalAddr: addr}
+	conn, err := d.Dial("tcp", l.Addr().String())
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
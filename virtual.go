@@ -0,0 +1,36 @@
+package localaddr
+
+import "strings"
+
+// virtualPrefixes lists name prefixes of interfaces created by container runtimes,
+// hypervisors, and bridges rather than by physical network hardware.
+var virtualPrefixes = []string{
+	"docker",    // Docker default bridge
+	"br-",       // Docker user-defined bridges
+	"veth",      // host side of container veth pairs
+	"cni",       // Kubernetes CNI bridges
+	"flannel",   // flannel overlay
+	"cali",      // Calico workload interfaces
+	"podman",    // Podman bridges
+	"lxcbr",     // LXC bridge
+	"lxdbr",     // LXD bridge
+	"virbr",     // libvirt
+	"vmnet",     // VMware
+	"vboxnet",   // VirtualBox host-only
+	"vEthernet", // Hyper-V virtual switches
+	"bridge",    // macOS bridges (Internet Sharing, VM networking)
+}
+
+// IsVirtual reports whether the interface name looks like a virtual interface.
+//
+// The check is a heuristic based on the naming conventions of common container
+// runtimes and hypervisors (e.g. "docker0", "veth1a2b3c", "virbr0"). Interfaces for
+// which it returns true are skipped by the getters unless WithIncludeVirtual is given.
+func IsVirtual(name string) bool {
+	for _, prefix := range virtualPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
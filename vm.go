@@ -0,0 +1,75 @@
+package localaddr
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// WithVMAdapters sets how the addresses of hypervisor network adapters are treated:
+// the host-only and NAT adapters that VirtualBox, VMware, and Hyper-V add to the host,
+// and the virtual NICs of a guest. The default is Avoid, so that the LAN address wins
+// over the host-only network on a developer machine; Prefer targets the adapters, e.g.
+// for a server that guests talk to over the host-only network.
+//
+// Adapters are recognized by the MAC address prefixes the hypervisors assign, by
+// interface name, and by the default subnets of VirtualBox (192.168.56.0/24 host-only,
+// 10.0.2.0/24 NAT) on interfaces with a locally administered MAC address. Interfaces
+// named like vboxnet0 or vmnet1 are also virtual, see IsVirtual, and are skipped
+// unless WithIncludeVirtual is given.
+func WithVMAdapters(p Preference) Option {
+	return func(c *config) {
+		if p < Allow || p > Exclude {
+			c.setErr(fmt.Errorf("invalid VM adapter preference %d", int(p)))
+			return
+		}
+		c.vmAdapters = p
+	}
+}
+
+// vmOUIs are the MAC address prefixes that hypervisors assign to virtual adapters.
+var vmOUIs = [][3]byte{
+	{0x08, 0x00, 0x27}, // VirtualBox guests
+	{0x0a, 0x00, 0x27}, // VirtualBox host-only adapters
+	{0x00, 0x50, 0x56}, // VMware, including the host adapters VMnet1 and VMnet8
+	{0x00, 0x0c, 0x29}, // VMware guests
+	{0x00, 0x05, 0x69}, // VMware
+	{0x00, 0x1c, 0x14}, // VMware
+	{0x00, 0x15, 0x5d}, // Hyper-V
+}
+
+// vmNames are the prefixes of the names hypervisors give their adapters on the host.
+var vmNames = []string{"vboxnet", "vmnet", "VirtualBox", "VMware", "vEthernet"}
+
+// vmSubnets are the default subnets of VirtualBox.
+var vmSubnets = []netip.Prefix{
+	netip.MustParsePrefix("192.168.56.0/24"), // host-only
+	netip.MustParsePrefix("10.0.2.0/24"),     // NAT, guest side
+}
+
+// isVMAdapter reports whether ip of iface belongs to a hypervisor adapter.
+func isVMAdapter(iface *net.Interface, ip netip.Addr) bool {
+	mac := iface.HardwareAddr
+	if len(mac) == 6 {
+		for _, oui := range vmOUIs {
+			if [3]byte(mac[:3]) == oui {
+				return true
+			}
+		}
+	}
+	for _, name := range vmNames {
+		if strings.HasPrefix(iface.Name, name) {
+			return true
+		}
+	}
+	if len(mac) == 0 || mac[0]&0x02 == 0 {
+		return false // burnt-in address, a real NIC on a LAN of the same range
+	}
+	for _, prefix := range vmSubnets {
+		if prefix.Contains(ip.WithZone("")) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package localaddr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// WaitForNetwork blocks until Get would find an address, and returns it with its details.
+// Services started at boot often run before DHCP has finished; this replaces the retry
+// loop they would otherwise need.
+//
+// The same options as for GetDetailed apply. It keeps waiting while the selection fails
+// with ErrNotConnected, ErrNoIPv4, ErrNoIPv6, or ErrInterfaceNotFound, since an interface
+// named with WithInterface may still be coming up; other errors, such as invalid options,
+// are returned right away. With WithRoutable it waits until systemd-networkd reports the
+// interface as routable, not just for an address. Changes are picked up from the
+// watcher's notifications and, in case those are unavailable or missed, by checking
+// every poll interval (see WithPollInterval).
+//
+// Returns:
+//   - Address: The address once there is one
+//   - error: An error if the selection fails for good, or ctx.Err() together with the
+//     last selection error if ctx is done first
+func WaitForNetwork(ctx context.Context, opts ...Option) (Address, error) {
+	cfg := newConfig(ipv4, opts)
+	if cfg.err != nil {
+		return Address{}, cfg.err
+	}
+	// Subscribe before the first check, so a change in between is not missed.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	changed, err := cfg.changes(ctx)
+	if err != nil {
+		changed = nil // rely on the ticker alone
+	}
+	ticker := time.NewTicker(cfg.pollInterval)
+	defer ticker.Stop()
+	for {
+		addr, err := GetDetailed(opts...)
+		if err == nil || !transient(err) {
+			return addr, err
+		}
+		select {
+		case <-ctx.Done():
+			return Address{}, fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-changed:
+			if cfg.debounce > 0 && !settle(ctx, changed, cfg.debounce) {
+				return Address{}, fmt.Errorf("%w: %w", ctx.Err(), err)
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+// transient reports whether err may go away once the network comes up.
+func transient(err error) bool {
+	for _, target := range []error{ErrNotConnected, ErrNoIPv4, ErrNoIPv6, ErrInterfaceNotFound} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
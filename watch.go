@@ -0,0 +1,248 @@
+package localaddr
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultPollInterval is how often a watcher polls on platforms without change notifications.
+const DefaultPollInterval = 2 * time.Second
+
+// WithPollInterval sets how often a watcher re-checks the interfaces on platforms where it
+// has to poll, and those of a Provider. It has no effect where the operating system
+// reports changes by itself, unless reading those reports fails.
+func WithPollInterval(d time.Duration) Option {
+	return func(c *config) {
+		if d <= 0 {
+			c.setErr(fmt.Errorf("invalid poll interval %v", d))
+			return
+		}
+		c.pollInterval = d
+	}
+}
+
+// WithDebounce makes a watcher wait until the network has been quiet for d before it
+// re-checks the address. DHCP transactions and interface flaps produce bursts of changes;
+// with debouncing they result in a single Event from the address before the burst to the
+// one after it, or in none if the address ends up unchanged. The default is no debouncing.
+func WithDebounce(d time.Duration) Option {
+	return func(c *config) {
+		if d < 0 {
+			c.setErr(fmt.Errorf("invalid debounce duration %v", d))
+			return
+		}
+		c.debounce = d
+	}
+}
+
+// WithHistory makes a watcher remember its last n events, see Watcher.History.
+func WithHistory(n int) Option {
+	return func(c *config) {
+		if n < 0 {
+			c.setErr(fmt.Errorf("invalid history size %d", n))
+			return
+		}
+		c.history = n
+	}
+}
+
+// Event describes a change of the local address.
+type Event struct {
+	Old       netip.Addr // previous address, the zero Addr if there was none
+	New       netip.Addr // current address, the zero Addr if the machine got disconnected
+	Interface string     // interface of New, "" if there is none; the adapter's friendly name on Windows
+	Time      time.Time  // when the change was noticed
+	Status    Status     // connectivity after the change with WithOnlineCheck, StatusUnknown otherwise
+}
+
+// Watcher reports changes of the local address, e.g. after a DHCP renew, a Wi-Fi roam,
+// or a cable being unplugged.
+//
+// It watches the address that Get would return for the options it was created with.
+type Watcher struct {
+	opts []Option
+
+	mu      sync.Mutex
+	limit   int     // WithHistory size
+	history []Event // oldest first
+}
+
+// NewWatcher returns a watcher for the address that Get(opts...) selects.
+//
+// On Linux, macOS, the BSDs, and Windows the watcher is driven by the operating system's
+// change notifications. Elsewhere, and if reading the notifications of Linux, macOS, or
+// the BSDs fails, it polls the interfaces, by default every DefaultPollInterval;
+// WithPollInterval changes that.
+func NewWatcher(opts ...Option) *Watcher {
+	return &Watcher{opts: opts, limit: newConfig(ipv4, opts).history}
+}
+
+// History returns the most recent address changes the watcher reported, oldest first.
+// It is empty unless the watcher was created with WithHistory.
+func (w *Watcher) History() []Event {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return slices.Clone(w.history)
+}
+
+// record adds ev to the history, dropping the oldest entry when it is full.
+func (w *Watcher) record(ev Event) {
+	if w.limit == 0 {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.history) == w.limit {
+		w.history = slices.Delete(w.history, 0, 1)
+	}
+	w.history = append(w.history, ev)
+}
+
+// Watch starts watching and returns a channel that receives an Event whenever the
+// address changes. The channel is closed once ctx is done.
+//
+// The current address is not sent; call Get for it. Losing the address is reported as
+// an Event with a zero New address, getting it back as one with a zero Old address.
+//
+// Returns:
+//   - <-chan Event: The channel of address changes
+//   - error: An error if the options are invalid or watching cannot be set up
+func (w *Watcher) Watch(ctx context.Context) (<-chan Event, error) {
+	cfg := newConfig(ipv4, w.opts)
+	if cfg.err != nil {
+		return nil, cfg.err
+	}
+	changed, err := cfg.changes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Look up the address before returning, so a change right after is not missed.
+	current := w.lookup()
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-changed:
+			}
+			if cfg.debounce > 0 && !settle(ctx, changed, cfg.debounce) {
+				return
+			}
+			next := w.lookup()
+			if next.ip == current.ip {
+				continue
+			}
+			ev := Event{Old: current.ip, New: next.ip, Interface: next.iface.Name, Time: time.Now()}
+			if cfg.onlineCheck && next.ip.IsValid() {
+				ev.Status, _ = cfg.connectivity(ctx)
+			}
+			current = next
+			w.record(ev)
+			select {
+			case events <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return events, nil
+}
+
+// lookup returns the currently selected candidate, or the zero candidate if there is none.
+func (w *Watcher) lookup() candidate {
+	c, err := first(newConfig(ipv4, w.opts))
+	if err != nil {
+		return candidate{}
+	}
+	return c
+}
+
+// settle waits until no change has been signalled for d. It returns false if ctx is done first.
+func settle(ctx context.Context, changed <-chan struct{}, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-changed:
+			timer.Reset(d)
+		case <-timer.C:
+			return true
+		}
+	}
+}
+
+// changes returns a channel that receives a value whenever the interfaces that cfg
+// selects from may have changed, until ctx is done. Those of a Provider are polled.
+func (cfg *config) changes(ctx context.Context) (<-chan struct{}, error) {
+	if cfg.provider != nil {
+		return poll(ctx, cfg.pollInterval, cfg.provider)
+	}
+	return subscribe(ctx, cfg)
+}
+
+// poll checks the interfaces of p every interval and signals when the interfaces, their
+// flags, or their addresses differ from the previous check, until ctx is done.
+func poll(ctx context.Context, interval time.Duration, p Provider) (<-chan struct{}, error) {
+	previous, err := fingerprint(p)
+	if err != nil {
+		return nil, err
+	}
+	changed := make(chan struct{}, 1)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			current, err := fingerprint(p)
+			if err != nil || current == previous {
+				continue
+			}
+			previous = current
+			notify(changed)
+		}
+	}()
+	return changed, nil
+}
+
+// fingerprint summarizes the interface configuration of p, so two polls can be compared.
+func fingerprint(p Provider) (string, error) {
+	interfaces, err := p.Interfaces()
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, v := range interfaces {
+		fmt.Fprintf(&b, "%d %s %v:", v.Index, v.Name, v.Flags)
+		addrs, err := p.Addrs(v)
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			fmt.Fprintf(&b, " %s", addr)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+// notify signals on a buffered channel without blocking. Signals that arrive while one
+// is already pending are coalesced into it.
+func notify(changed chan<- struct{}) {
+	select {
+	case changed <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,46 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package localaddr
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"syscall"
+)
+
+// subscribe returns a channel that receives a value whenever the network configuration
+// may have changed, until ctx is done. It listens on a PF_ROUTE socket, which reports
+// address, interface, and route changes as they happen, so Wi-Fi network switches and
+// VPNs coming up or down are noticed instantly.
+func subscribe(ctx context.Context, cfg *config) (<-chan struct{}, error) {
+	fd, err := syscall.Socket(syscall.AF_ROUTE, syscall.SOCK_RAW, syscall.AF_UNSPEC)
+	if err != nil {
+		return nil, fmt.Errorf("route socket: %w", err)
+	}
+	syscall.CloseOnExec(fd)
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	return watchSocket(ctx, fd, cfg.pollInterval, relevantRouteMessage)
+}
+
+// relevantRouteMessage reports whether a read from a route socket contains a message
+// about addresses, interfaces, or routes. Each message starts with its length (16 bits),
+// version, and type.
+func relevantRouteMessage(b []byte) bool {
+	for len(b) >= 4 {
+		n := int(binary.NativeEndian.Uint16(b))
+		switch b[3] {
+		case syscall.RTM_NEWADDR, syscall.RTM_DELADDR, syscall.RTM_IFINFO,
+			syscall.RTM_ADD, syscall.RTM_DELETE, syscall.RTM_CHANGE:
+			return true
+		}
+		if n < 4 || n > len(b) {
+			break
+		}
+		b = b[n:]
+	}
+	return false
+}
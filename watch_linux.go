@@ -0,0 +1,45 @@
+package localaddr
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+)
+
+// rtnetlink multicast groups from <linux/rtnetlink.h>; package syscall does not define them.
+const (
+	rtmgrpLink       = 0x1
+	rtmgrpIPv4IfAddr = 0x10
+	rtmgrpIPv6IfAddr = 0x100
+)
+
+// subscribe returns a channel that receives a value whenever the network configuration
+// may have changed, until ctx is done. It listens for rtnetlink address and link
+// notifications, so changes arrive within milliseconds and nothing runs in between.
+func subscribe(ctx context.Context, cfg *config) (<-chan struct{}, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC|syscall.SOCK_NONBLOCK, syscall.NETLINK_ROUTE)
+	if err != nil {
+		return nil, fmt.Errorf("netlink socket: %w", err)
+	}
+	sa := &syscall.SockaddrNetlink{
+		Family: syscall.AF_NETLINK,
+		Groups: rtmgrpLink | rtmgrpIPv4IfAddr | rtmgrpIPv6IfAddr,
+	}
+	if err := syscall.Bind(fd, sa); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("netlink bind: %w", err)
+	}
+	return watchSocket(ctx, fd, cfg.pollInterval, func(b []byte) bool {
+		msgs, err := syscall.ParseNetlinkMessage(b)
+		if err != nil {
+			return false
+		}
+		for _, m := range msgs {
+			switch m.Header.Type {
+			case syscall.RTM_NEWADDR, syscall.RTM_DELADDR, syscall.RTM_NEWLINK, syscall.RTM_DELLINK:
+				return true
+			}
+		}
+		return false
+	})
+}
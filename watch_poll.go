@@ -0,0 +1,14 @@
+//go:build !linux && !windows && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+
+package localaddr
+
+import "context"
+
+// subscribe returns a channel that receives a value whenever the network configuration
+// may have changed, until ctx is done.
+//
+// This platform has no change notifications the package knows how to use, so the
+// interfaces are polled every cfg.pollInterval, see poll.
+func subscribe(ctx context.Context, cfg *config) (<-chan struct{}, error) {
+	return poll(ctx, cfg.pollInterval, system{})
+}
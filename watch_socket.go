@@ -0,0 +1,79 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package localaddr
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"time"
+)
+
+// watchSocket reads kernel notifications from fd, a non-blocking netlink or route socket,
+// until ctx is done, and signals on the returned channel whenever relevant reports that
+// a message may concern the address configuration. It takes ownership of fd.
+//
+// If reading fails for other reasons than dropped messages or interruptions, the socket
+// is closed and the interfaces are polled every interval instead, see poll, so that a
+// broken socket neither ends the notifications nor keeps the goroutine spinning.
+func watchSocket(ctx context.Context, fd int, interval time.Duration, relevant func(msg []byte) bool) (<-chan struct{}, error) {
+	// Wrapping the non-blocking socket in an os.File hands it to the runtime poller,
+	// so reads park the goroutine and Close wakes it up.
+	file := os.NewFile(uintptr(fd), "notifications")
+	conn, err := file.SyscallConn()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	go func() {
+		<-ctx.Done()
+		file.Close()
+	}()
+	changed := make(chan struct{}, 1)
+	go func() {
+		buf := make([]byte, os.Getpagesize())
+		for {
+			var n int
+			var rerr error
+			err := conn.Read(func(fd uintptr) bool {
+				n, rerr = syscall.Read(int(fd), buf)
+				return rerr != syscall.EAGAIN
+			})
+			if err != nil {
+				return // closed
+			}
+			switch {
+			case rerr == nil:
+				if relevant(buf[:n]) {
+					notify(changed)
+				}
+			case rerr == syscall.ENOBUFS:
+				notify(changed) // the kernel dropped messages, assume something changed
+			case rerr == syscall.EINTR:
+			default:
+				file.Close()
+				notify(changed) // changes may have been missed
+				pollInto(ctx, interval, changed)
+				return
+			}
+		}
+	}()
+	return changed, nil
+}
+
+// pollInto signals on changed whenever a poll of the system's interfaces finds a change,
+// until ctx is done.
+func pollInto(ctx context.Context, interval time.Duration, changed chan<- struct{}) {
+	polled, err := poll(ctx, interval, system{})
+	if err != nil {
+		return
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-polled:
+			notify(changed)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package localaddr
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"golang.org/x/sys/windows"
+)
+
+// The IP Helper notifications are registered once and shared by all subscribers.
+// Two locks are needed because CancelMibChangeNotify2 waits for running callbacks,
+// which take subscribersMu.
+var (
+	registerMu    sync.Mutex // guards registered and notifyHandles
+	registered    int
+	notifyHandles []windows.Handle
+
+	subscribersMu sync.Mutex
+	subscribers   = make(map[chan struct{}]struct{})
+
+	// changeCallback is the callback for both notification kinds. Callbacks created with
+	// windows.NewCallback are never freed, so it is created once.
+	changeCallback = sync.OnceValue(func() uintptr {
+		return windows.NewCallback(func(callerContext, row, notificationType uintptr) uintptr {
+			subscribersMu.Lock()
+			defer subscribersMu.Unlock()
+			for changed := range subscribers {
+				notify(changed)
+			}
+			return 0
+		})
+	})
+)
+
+// subscribe returns a channel that receives a value whenever the network configuration
+// may have changed, until ctx is done. It uses NotifyIpInterfaceChange and
+// NotifyUnicastIpAddressChange, so changes are reported as soon as Windows applies them.
+func subscribe(ctx context.Context, _ *config) (<-chan struct{}, error) {
+	if err := register(); err != nil {
+		return nil, err
+	}
+	changed := make(chan struct{}, 1)
+	subscribersMu.Lock()
+	subscribers[changed] = struct{}{}
+	subscribersMu.Unlock()
+	go func() {
+		<-ctx.Done()
+		subscribersMu.Lock()
+		delete(subscribers, changed)
+		subscribersMu.Unlock()
+		unregister()
+	}()
+	return changed, nil
+}
+
+// register sets up the interface and address change notifications for the first subscriber.
+func register() error {
+	registerMu.Lock()
+	defer registerMu.Unlock()
+	if registered > 0 {
+		registered++
+		return nil
+	}
+	var iface, addr windows.Handle
+	err := windows.NotifyIpInterfaceChange(windows.AF_UNSPEC, changeCallback(), nil, false, &iface)
+	if err != nil {
+		return fmt.Errorf("NotifyIpInterfaceChange: %w", err)
+	}
+	err = windows.NotifyUnicastIpAddressChange(windows.AF_UNSPEC, changeCallback(), nil, false, &addr)
+	if err != nil {
+		windows.CancelMibChangeNotify2(iface)
+		return fmt.Errorf("NotifyUnicastIpAddressChange: %w", err)
+	}
+	notifyHandles = []windows.Handle{iface, addr}
+	registered = 1
+	return nil
+}
+
+// unregister cancels the notifications once the last subscriber is gone.
+func unregister() {
+	registerMu.Lock()
+	defer registerMu.Unlock()
+	registered--
+	if registered > 0 {
+		return
+	}
+	for _, h := range notifyHandles {
+		windows.CancelMibChangeNotify2(h)
+	}
+	notifyHandles = nil
+}
@@ -0,0 +1,80 @@
+package localaddr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"time"
+)
+
+// Webhook posts the address changes reported by a Watcher to a URL, e.g. to keep a
+// dynamic DNS record of a home server up to date.
+//
+// Each change is sent as a JSON object:
+//
+//	{"old": "192.168.1.2", "new": "192.168.1.7", "interface": "eth0", "timestamp": "2024-05-01T10:00:00Z"}
+//
+// A missing address is sent as an empty string.
+type Webhook struct {
+	// URL receives a POST request for every change.
+	URL string
+	// Client sends the requests. Nil means http.DefaultClient.
+	Client *http.Client
+	// OnError, if set, is called when a change could not be delivered.
+	OnError func(Event, error)
+}
+
+// webhookPayload is the JSON body of a webhook request.
+type webhookPayload struct {
+	Old       netip.Addr `json:"old"`
+	New       netip.Addr `json:"new"`
+	Interface string     `json:"interface"`
+	Timestamp time.Time  `json:"timestamp"`
+}
+
+// Run watches w and posts every change until ctx is done. Delivery failures are passed
+// to OnError and do not stop the webhook.
+//
+// Returns:
+//   - error: An error if watching cannot be started, nil once ctx is done
+func (h *Webhook) Run(ctx context.Context, w *Watcher) error {
+	events, err := w.Watch(ctx)
+	if err != nil {
+		return err
+	}
+	for ev := range events {
+		if err := h.post(ctx, ev); err != nil && h.OnError != nil {
+			h.OnError(ev, err)
+		}
+	}
+	return nil
+}
+
+// post delivers a single event.
+func (h *Webhook) post(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(webhookPayload{ev.Old, ev.New, ev.Interface, ev.Time.UTC()})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := h.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook: unexpected status %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package localaddr
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+// WakeOnLANPort is the UDP port that WakeOnLAN sends to, the discard port, which is the
+// one most tools use; network cards take the magic packet on any port.
+const WakeOnLANPort = 9
+
+// WakeOnLAN wakes the machine with the hardware address mac by sending the magic packet,
+// six bytes 0xff followed by mac sixteen times, to the directed broadcast address of the
+// selected IPv4 network (see Broadcast), from the address on it. Directed broadcasts
+// leave through the interface of that network, unlike 255.255.255.255, which only goes
+// out of the interface of the default route.
+//
+// The network is the one of the interface that the machine was last seen on in the ARP
+// table (see Neighbors), if it is still listed there, and otherwise the one that Get
+// selects. The options are those of Get; WithInterface picks the network instead.
+//
+// Returns:
+//   - error: An error if mac is not a 48-bit address, no address is selected, its
+//     network has no broadcast address, or the packet cannot be sent
+func WakeOnLAN(mac net.HardwareAddr, opts ...Option) error {
+	if len(mac) != 6 {
+		return fmt.Errorf("invalid hardware address %s: Wake-on-LAN needs 48 bits", mac)
+	}
+	cfg := newConfig(ipv4, opts)
+	if cfg.err == nil && cfg.iface == "" && cfg.provider == nil {
+		if iface := neighborInterface(mac); iface != "" {
+			cfg = newConfig(ipv4, append(opts, WithInterface(iface)))
+		}
+	}
+	cfg.family = ipv4 // broadcasts are IPv4 only
+	c, err := first(cfg)
+	if err != nil {
+		return err
+	}
+	bcast, ok := broadcastOf(c.prefix)
+	if !ok {
+		return fmt.Errorf("network %s has no broadcast address", c.prefix)
+	}
+	conn, err := net.ListenUDP("udp4", net.UDPAddrFromAddrPort(netip.AddrPortFrom(c.ip, 0)))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	packet := append(bytes.Repeat([]byte{0xff}, 6), bytes.Repeat(mac, 16)...)
+	if _, err := conn.WriteToUDPAddrPort(packet, netip.AddrPortFrom(bcast, WakeOnLANPort)); err != nil {
+		return fmt.Errorf("sending the magic packet to %s: %w", bcast, err)
+	}
+	return nil
+}
+
+// neighborInterface returns the interface of the ARP table entry of mac, or "" if there
+// is none or the table cannot be read.
+func neighborInterface(mac net.HardwareAddr) string {
+	neighbors, err := readNeighbors(ipv4)
+	if err != nil {
+		return ""
+	}
+	for _, n := range neighbors {
+		if bytes.Equal(n.MAC, mac) {
+			return n.Interface
+		}
+	}
+	return ""
+}
@@ -0,0 +1,88 @@
+package localaddr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"os/exec"
+	"strings"
+)
+
+// WSLHost holds the addresses of the Windows machine that a WSL2 distribution runs on.
+type WSLHost struct {
+	// Gateway is the Windows side of the virtual network of WSL, the default gateway of
+	// the distribution. It is reachable from WSL only. In mirrored networking mode it is
+	// the gateway of the LAN instead.
+	Gateway netip.Addr
+	// LAN lists the IPv4 addresses of the Windows network adapters that have a default
+	// gateway, which other devices on the LAN can reach. It is empty if Windows cannot
+	// be asked, e.g. with interop turned off in wsl.conf.
+	LAN []netip.Addr
+}
+
+// wslHostScript prints the IPv4 addresses of the connected Windows adapters with a
+// default gateway, one per line.
+const wslHostScript = `Get-NetIPConfiguration | Where-Object { $_.IPv4DefaultGateway -ne $null -and ` +
+	`$_.NetAdapter.Status -eq 'Up' } | ForEach-Object { $_.IPv4Address.IPAddress }`
+
+// InWSL reports whether the process runs in a WSL2 distribution on Windows, which it
+// tells by the kernel release of Microsoft's WSL kernel, e.g.
+// "5.15.153.1-microsoft-standard-WSL2". WSL1 has no network of its own, and is not
+// reported.
+func InWSL() bool {
+	return inWSL()
+}
+
+// GetWSLHost returns the addresses of the Windows host when running under WSL2, see
+// InWSL. In the default NAT mode of WSL2 the address of eth0, which Get returns, is
+// translated by Windows and cannot be reached from other LAN devices; a server in WSL is
+// reached through the Windows host's LAN address instead, with a port forwarding rule
+// (netsh interface portproxy) or in the mirrored networking mode of newer versions.
+//
+// The gateway is read from the routing table, falling back to the name server of
+// /etc/resolv.conf, which WSL points at the host. The LAN addresses are asked of Windows
+// with powershell.exe through the WSL interop, bounded by ctx.
+//
+// Returns:
+//   - WSLHost: The gateway (e.g., 172.28.0.1) and the LAN addresses (e.g., [192.168.1.20])
+//   - error: An error wrapping errors.ErrUnsupported outside WSL, or if neither the gateway
+//     nor any LAN address can be found
+func GetWSLHost(ctx context.Context) (WSLHost, error) {
+	if !InWSL() {
+		return WSLHost{}, fmt.Errorf("reading the WSL host: %w", errors.ErrUnsupported)
+	}
+	var host WSLHost
+	if r, err := defaultRoute(&config{family: ipv4}); err == nil && r.gw.IsValid() {
+		host.Gateway = r.gw
+	} else {
+		servers, _ := readResolvConf("/etc/resolv.conf")
+		for _, s := range servers {
+			if s.Addr.Is4() {
+				host.Gateway = s.Addr
+				break
+			}
+		}
+	}
+	lan, err := windowsLAN(ctx)
+	host.LAN = lan
+	if !host.Gateway.IsValid() && err != nil {
+		return WSLHost{}, fmt.Errorf("reading the WSL host: %w", err)
+	}
+	return host, nil
+}
+
+// windowsLAN runs wslHostScript on the Windows host.
+func windowsLAN(ctx context.Context) ([]netip.Addr, error) {
+	out, err := exec.CommandContext(ctx, "powershell.exe", "-NoProfile", "-NonInteractive", "-Command", wslHostScript).Output()
+	if err != nil {
+		return nil, fmt.Errorf("asking Windows: %w", err)
+	}
+	var lan []netip.Addr
+	for _, line := range strings.Fields(string(out)) {
+		if ip, err := netip.ParseAddr(line); err == nil && ip.Is4() {
+			lan = append(lan, ip)
+		}
+	}
+	return lan, nil
+}
@@ -0,0 +1,13 @@
+package localaddr
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+// inWSL reads the kernel release once.
+var inWSL = sync.OnceValue(func() bool {
+	release, err := os.ReadFile("/proc/sys/kernel/osrelease")
+	return err == nil && strings.Contains(strings.ToLower(string(release)), "microsoft-standard")
+})
@@ -0,0 +1,6 @@
+//go:build !linux
+
+package localaddr
+
+// inWSL reports false: WSL runs a Linux kernel.
+func inWSL() bool { return false }